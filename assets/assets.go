@@ -0,0 +1,8 @@
+package assets
+
+import "embed"
+
+// Html holds the email and page templates so the binary does not depend on its working directory.
+//
+//go:embed html
+var Html embed.FS
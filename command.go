@@ -0,0 +1,138 @@
+package main
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/app/divisi"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/database"
+	"daarul_mukhtarin/pkg/migrate"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runCommand runs a one off maintenance command instead of the server, e.g.
+//
+//	go run . drive-reconcile
+func runCommand(f *factory.Factory, args []string) error {
+	switch args[0] {
+	case "drive-reconcile":
+		if err := divisi.NewDriveSync(f).Reconcile(); err != nil {
+			return err
+		}
+		logrus.Info("drive folders reconciled")
+		return nil
+	case "search-index":
+		if err := f.SearchRepository.EnsureIndexes(&abstraction.Context{}); err != nil {
+			return err
+		}
+		logrus.Info("search indexes created")
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runMigrate manages the schema of DB_DRIVER with the migrations embedded from migrations/<driver>.
+// It needs nothing but the database, so it runs before the factory is set up, e.g.
+//
+//	go run . migrate up [n]
+//	go run . migrate down [n]
+//	go run . migrate status
+//	go run . migrate create <name>
+//
+// up applies every pending migration, or the next n, and then seeds the default roles and admin.
+// down reverts the last migration, or the last n. create adds the files for every database.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status|create")
+	}
+	driver := database.Driver()
+
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.New("usage: migrate create <name>")
+		}
+		// every database gets the migration, so their versions stay in step
+		for _, dir := range migrations.DRIVERS {
+			files, err := migrate.Create(filepath.Join("migrations", dir), strings.Join(args[1:], "_"))
+			if err != nil {
+				return err
+			}
+			for _, file := range files {
+				logrus.Info("created " + file)
+			}
+		}
+		return nil
+	}
+
+	n := 0
+	if len(args) > 1 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+			return fmt.Errorf("%q is not a number of migrations", args[1])
+		}
+	}
+
+	files, err := migrations.Files(driver)
+	if err != nil {
+		return err
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		return err
+	}
+	database.Init()
+	db, err := database.Connection(driver)
+	if err != nil {
+		return err
+	}
+	migrator := migrate.NewMigrator(db, list)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(n)
+		if err != nil {
+			return err
+		}
+		for _, m := range applied {
+			logrus.Info(fmt.Sprintf("applied %06d_%s", m.Version, m.Name))
+		}
+		if len(applied) == 0 {
+			logrus.Info("schema is up to date")
+		}
+		return migrations.Seed(db)
+	case "down":
+		reverted, err := migrator.Down(n)
+		if err != nil {
+			return err
+		}
+		for _, m := range reverted {
+			logrus.Info(fmt.Sprintf("reverted %06d_%s", m.Version, m.Name))
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if status.Changed {
+				state += ", changed since"
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
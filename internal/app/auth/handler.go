@@ -4,7 +4,7 @@ import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/dto"
 	"daarul_mukhtarin/internal/factory"
-	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/mailtemplate"
 	"daarul_mukhtarin/pkg/util/response"
 	"net/http"
 
@@ -21,6 +21,16 @@ func NewHandler(f *factory.Factory) *handler {
 	}
 }
 
+// @Summary      Login
+// @Description  Login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   body      dto.AuthLoginRequest                     true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.AuthLoginResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /auth/login [post]
 func (h *handler) Login(c echo.Context) error {
 	payload := new(dto.AuthLoginRequest)
 	if err := c.Bind(payload); err != nil {
@@ -36,6 +46,18 @@ func (h *handler) Login(c echo.Context) error {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Logout
+// @Description  Logout
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /auth/logout [post]
 func (h *handler) Logout(c echo.Context) error {
 	data, err := h.service.Logout(c.(*abstraction.Context))
 	if err != nil {
@@ -44,6 +66,18 @@ func (h *handler) Logout(c echo.Context) error {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Refresh the access token
+// @Description  Refresh the access token
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  response.MetaSuccess{data=dto.AuthTokenResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /auth/refresh-token [post]
 func (h *handler) RefreshToken(c echo.Context) error {
 	data, err := h.service.RefreshToken(c.(*abstraction.Context))
 	if err != nil {
@@ -52,6 +86,16 @@ func (h *handler) RefreshToken(c echo.Context) error {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Send the forgot password email
+// @Description  Send the forgot password email
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        payload   body      dto.AuthSendEmailForgotPasswordRequest   true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /auth/send-email/forgot-password [post]
 func (h *handler) SendEmailForgotPassword(c echo.Context) error {
 	payload := new(dto.AuthSendEmailForgotPasswordRequest)
 	if err := c.Bind(payload); err != nil {
@@ -67,6 +111,16 @@ func (h *handler) SendEmailForgotPassword(c echo.Context) error {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Reset the password from the emailed link
+// @Description  Reset the password from the emailed link
+// @Tags         Auth
+// @Produce      html
+// @Param        token     path      string                                   true  "reset token"
+// @Success      200  {string}  string
+// @Failure      400  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /auth/validation/reset-password/{token} [get]
 func (h *handler) ValidationResetPassword(c echo.Context) error {
 	payload := new(dto.AuthValidationResetPasswordRequest)
 	if err := c.Bind(payload); err != nil {
@@ -75,11 +129,22 @@ func (h *handler) ValidationResetPassword(c echo.Context) error {
 	if err := c.Validate(payload); err != nil {
 		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
 	}
+	lang := mailtemplate.Language(c.Request().Header.Get("Accept-Language"))
 	data, err := h.service.ValidationResetPassword(c.(*abstraction.Context), payload)
 	if err != nil {
-		htmlContent := general.ProcessHTMLResponseEmail("assets/html/reset_password_failed.html", "{{.Error}}", err.Error())
+		_, htmlContent, errRender := mailtemplate.Render(mailtemplate.TEMPLATE_RESET_PASSWORD_FAILED, lang, map[string]interface{}{
+			"ERROR": err.Error(),
+		})
+		if errRender != nil {
+			return response.ErrorResponse(errRender).SendError(c)
+		}
 		return c.HTML(200, htmlContent)
 	}
-	htmlContent := general.ProcessHTMLResponseEmail("assets/html/reset_password_success.html", "{{.Data}}", data)
+	_, htmlContent, err := mailtemplate.Render(mailtemplate.TEMPLATE_RESET_PASSWORD_SUCCESS, lang, map[string]interface{}{
+		"EMAIL": data,
+	})
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
 	return c.HTML(200, htmlContent)
 }
@@ -10,7 +10,7 @@ import (
 	modelToken "daarul_mukhtarin/internal/model/token"
 	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/constant"
-	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/mailtemplate"
 	"daarul_mukhtarin/pkg/util/aescrypt"
 	"daarul_mukhtarin/pkg/util/encoding"
 	"daarul_mukhtarin/pkg/util/general"
@@ -27,15 +27,16 @@ import (
 )
 
 type Service interface {
-	Login(ctx *abstraction.Context, payload *dto.AuthLoginRequest) (map[string]interface{}, error)
-	Logout(ctx *abstraction.Context) (map[string]interface{}, error)
-	RefreshToken(ctx *abstraction.Context) (map[string]interface{}, error)
-	SendEmailForgotPassword(ctx *abstraction.Context, payload *dto.AuthSendEmailForgotPasswordRequest) (map[string]interface{}, error)
+	Login(ctx *abstraction.Context, payload *dto.AuthLoginRequest) (*dto.AuthLoginResponse, error)
+	Logout(ctx *abstraction.Context) (*dto.MessageResponse, error)
+	RefreshToken(ctx *abstraction.Context) (*dto.AuthTokenResponse, error)
+	SendEmailForgotPassword(ctx *abstraction.Context, payload *dto.AuthSendEmailForgotPasswordRequest) (*dto.MessageResponse, error)
 	ValidationResetPassword(ctx *abstraction.Context, payload *dto.AuthValidationResetPasswordRequest) (string, error)
 }
 
 type service struct {
-	UserRepository repository.User
+	UserRepository        repository.User
+	EmailOutboxRepository repository.EmailOutbox
 
 	DB      *gorm.DB
 	DbRedis *redis.Client
@@ -43,7 +44,8 @@ type service struct {
 
 func NewService(f *factory.Factory) Service {
 	return &service{
-		UserRepository: f.UserRepository,
+		UserRepository:        f.UserRepository,
+		EmailOutboxRepository: f.EmailOutboxRepository,
 
 		DB:      f.Db,
 		DbRedis: f.DbRedis,
@@ -55,7 +57,7 @@ func (s *service) encryptTokenClaims(v int) (encryptedString string, err error)
 	return
 }
 
-func (s *service) Login(ctx *abstraction.Context, payload *dto.AuthLoginRequest) (map[string]interface{}, error) {
+func (s *service) Login(ctx *abstraction.Context, payload *dto.AuthLoginRequest) (*dto.AuthLoginResponse, error) {
 	var (
 		err   error
 		data  = new(model.UserEntityModel)
@@ -114,27 +116,13 @@ func (s *service) Login(ctx *abstraction.Context, payload *dto.AuthLoginRequest)
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"token": token,
-		"data": map[string]interface{}{
-			"id":         data.ID,
-			"name":       data.Name,
-			"email":      data.Email,
-			"created_at": data.CreatedAt,
-			"updated_at": data.UpdatedAt,
-			"role": map[string]interface{}{
-				"id":   data.Role.ID,
-				"name": data.Role.Name,
-			},
-			"divisi": map[string]interface{}{
-				"id":   data.Divisi.ID,
-				"name": data.Divisi.Name,
-			},
-		},
+	return &dto.AuthLoginResponse{
+		Token: token,
+		User:  dto.NewUserResponse(data),
 	}, nil
 }
 
-func (s *service) Logout(ctx *abstraction.Context) (map[string]interface{}, error) {
+func (s *service) Logout(ctx *abstraction.Context) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if err := s.UserRepository.UpdateLoginFrom(ctx, &ctx.Auth.ID, "").Error; err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
@@ -145,12 +133,10 @@ func (s *service) Logout(ctx *abstraction.Context) (map[string]interface{}, erro
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"message": "success logout!",
-	}, nil
+	return &dto.MessageResponse{Message: "success logout!"}, nil
 }
 
-func (s *service) RefreshToken(ctx *abstraction.Context) (map[string]interface{}, error) {
+func (s *service) RefreshToken(ctx *abstraction.Context) (*dto.AuthTokenResponse, error) {
 	var token string
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		data, err := s.UserRepository.FindById(ctx, ctx.Auth.ID)
@@ -190,12 +176,10 @@ func (s *service) RefreshToken(ctx *abstraction.Context) (map[string]interface{}
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"token": token,
-	}, nil
+	return &dto.AuthTokenResponse{Token: token}, nil
 }
 
-func (s *service) SendEmailForgotPassword(ctx *abstraction.Context, payload *dto.AuthSendEmailForgotPasswordRequest) (map[string]interface{}, error) {
+func (s *service) SendEmailForgotPassword(ctx *abstraction.Context, payload *dto.AuthSendEmailForgotPasswordRequest) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		data, err := s.UserRepository.FindByEmail(ctx, payload.Email)
 		if err != nil && err.Error() != "record not found" {
@@ -214,15 +198,15 @@ func (s *service) SendEmailForgotPassword(ctx *abstraction.Context, payload *dto
 
 		s.DbRedis.Set(context.Background(), *token, *token, 0)
 
-		if err = gomail.SendMail(data.Email, "Forgot Password for SelarasHomeId", general.ParseTemplateEmail("./assets/html/forgot_password.html", struct {
-			NAME  string
-			EMAIL string
-			LINK  string
-		}{
-			NAME:  data.Name,
-			EMAIL: data.Email,
-			LINK:  constant.BASE_URL + "/auth/validation/reset-password/" + *token,
-		})); err != nil {
+		message, err := mailtemplate.Message(mailtemplate.TEMPLATE_FORGOT_PASSWORD, data.Language, map[string]interface{}{
+			"NAME":  data.Name,
+			"EMAIL": data.Email,
+			"LINK":  constant.BASE_URL + "/auth/validation/reset-password/" + *token,
+		}, data.Email)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if err = s.EmailOutboxRepository.Enqueue(ctx, message); err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
 
@@ -231,9 +215,7 @@ func (s *service) SendEmailForgotPassword(ctx *abstraction.Context, payload *dto
 		return nil, err
 	}
 
-	return map[string]interface{}{
-		"message": "success send email forgot password!",
-	}, nil
+	return &dto.MessageResponse{Message: "success send email forgot password!"}, nil
 }
 
 func (s *service) ValidationResetPassword(ctx *abstraction.Context, payload *dto.AuthValidationResetPasswordRequest) (string, error) {
@@ -270,24 +252,27 @@ func (s *service) ValidationResetPassword(ctx *abstraction.Context, payload *dto
 		newUserData.Context = ctx
 		newUserData.ID = userData.ID
 		newUserData.Password = string(hashedPassword)
+		newUserData.Version = userData.Version
 
-		if err = s.UserRepository.Update(ctx, newUserData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		result := s.UserRepository.Update(ctx, newUserData)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "user was changed meanwhile, try again")
 		}
 
-		if err = gomail.SendMail(userData.Email, "Reset Password for SelarasHomeId", general.ParseTemplateEmail("./assets/html/reset_password_admin.html", struct {
-			NAME      string
-			RESETNAME string
-			EMAIL     string
-			PASSWORD  string
-			LINK      string
-		}{
-			NAME:      userData.Name,
-			RESETNAME: "System",
-			EMAIL:     userData.Email,
-			PASSWORD:  passwordString,
-			LINK:      constant.BASE_URL,
-		})); err != nil {
+		message, err := mailtemplate.Message(mailtemplate.TEMPLATE_RESET_PASSWORD_ADMIN, userData.Language, map[string]interface{}{
+			"NAME":      userData.Name,
+			"RESETNAME": "System",
+			"EMAIL":     userData.Email,
+			"PASSWORD":  passwordString,
+			"LINK":      constant.BASE_URL,
+		}, userData.Email)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if err = s.EmailOutboxRepository.Enqueue(ctx, message); err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
 
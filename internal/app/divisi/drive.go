@@ -0,0 +1,175 @@
+package divisi
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/config"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/gdrive"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// syncLocks serializes the syncs of one divisi, so two member changes in a row cannot
+// both add the same permission.
+var syncLocks sync.Map
+
+// DriveSync keeps a Drive folder per divisi, shared with the divisi members.
+type DriveSync struct {
+	DivisiRepository repository.Divisi
+	UserRepository   repository.User
+}
+
+func NewDriveSync(f *factory.Factory) *DriveSync {
+	return &DriveSync{
+		DivisiRepository: f.DivisiRepository,
+		UserRepository:   f.UserRepository,
+	}
+}
+
+// Enabled reports whether Drive is configured, without it every sync is skipped.
+func (d *DriveSync) Enabled() bool {
+	return config.Get().Drive.CredentialsDrive != ""
+}
+
+// Sync shares the folder of every divisi in divisiIds with exactly its current members, the
+// folder is created first when the divisi has none yet.
+func (d *DriveSync) Sync(divisiIds ...int) error {
+	if !d.Enabled() {
+		return nil
+	}
+	ctx := &abstraction.Context{}
+	var errs []error
+	for _, divisiId := range divisiIds {
+		divisiData, err := d.DivisiRepository.FindById(ctx, divisiId)
+		if err != nil {
+			if err.Error() != "record not found" {
+				errs = append(errs, fmt.Errorf("divisi %d: %w", divisiId, err))
+			}
+			continue
+		}
+		if err = d.sync(ctx, divisiData); err != nil {
+			errs = append(errs, fmt.Errorf("divisi %d: %w", divisiId, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SyncAsync runs Sync in the background, a failure is only logged since Reconcile repairs it later.
+func (d *DriveSync) SyncAsync(divisiIds ...int) {
+	go func() {
+		if err := d.Sync(divisiIds...); err != nil {
+			logrus.Error("failed sync drive folder: ", err)
+		}
+	}()
+}
+
+// Reconcile repairs drift for every divisi: missing or deleted folders are created again,
+// renamed divisi are renamed in Drive and the permissions are synced with the members.
+func (d *DriveSync) Reconcile() error {
+	if !d.Enabled() {
+		return gdrive.ErrNoCredentials
+	}
+	ctx := &abstraction.Context{}
+	data, err := d.DivisiRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, divisiData := range data {
+		if err = d.sync(ctx, divisiData); err != nil {
+			errs = append(errs, fmt.Errorf("divisi %d: %w", divisiData.ID, err))
+			continue
+		}
+		logrus.Info(fmt.Sprintf("drive folder of divisi %d (%s) is in sync", divisiData.ID, divisiData.Name))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *DriveSync) sync(ctx *abstraction.Context, divisiData *model.DivisiEntityModel) error {
+	lock, _ := syncLocks.LoadOrStore(divisiData.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	service, err := gdrive.InitGoogleDrive()
+	if err != nil {
+		return err
+	}
+
+	folderId, err := d.folder(ctx, service, divisiData)
+	if err != nil {
+		return err
+	}
+
+	members, err := d.UserRepository.FindAllByDivisiId(ctx, divisiData.ID)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]string, len(members))
+	for _, member := range members {
+		want[member.Email] = gdrive.ROLE_WRITER
+	}
+	keep, err := d.keep(ctx)
+	if err != nil {
+		return err
+	}
+	return gdrive.SyncPermissions(context.Background(), service, folderId, want, keep)
+}
+
+// keep lists the accounts whose access is never removed by a sync: admins, the account Drive is
+// used as and DRIVE_KEEP_EMAILS.
+func (d *DriveSync) keep(ctx *abstraction.Context) ([]string, error) {
+	admins, err := d.UserRepository.FindAllByRoleId(ctx, constant.ROLE_ID_ADMIN)
+	if err != nil {
+		return nil, err
+	}
+	keep := strings.Split(config.Get().Drive.KeepEmails, ",")
+	keep = append(keep, config.Get().Drive.SubjectDrive)
+	for _, admin := range admins {
+		keep = append(keep, admin.Email)
+	}
+	return keep, nil
+}
+
+// folder returns the Drive folder of divisiData, creating it when it is missing or was trashed.
+func (d *DriveSync) folder(ctx *abstraction.Context, service *drive.Service, divisiData *model.DivisiEntityModel) (string, error) {
+	if divisiData.DriveFolderId != "" {
+		file, err := service.Files.Get(divisiData.DriveFolderId).Fields("id", "name", "trashed").Do()
+		var apiErr *googleapi.Error
+		if err != nil && !(errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound) {
+			return "", err
+		}
+		if err == nil && !file.Trashed {
+			if file.Name != divisiData.Name {
+				if err = gdrive.RenameFolder(service, file.Id, divisiData.Name); err != nil {
+					return "", err
+				}
+			}
+			return file.Id, nil
+		}
+	}
+
+	parentId := config.Get().Drive.DivisiFolderId
+	if parentId == "" {
+		parentId = "root"
+	}
+	file, err := gdrive.FolderToDrive(service, divisiData.Name, parentId)
+	if err != nil {
+		return "", err
+	}
+	if err = d.DivisiRepository.UpdateDriveFolderId(ctx, divisiData.ID, file.Id).Error; err != nil {
+		return "", err
+	}
+	divisiData.DriveFolderId = file.Id
+	return file.Id, nil
+}
@@ -0,0 +1,55 @@
+package divisi
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"errors"
+	"net/http"
+)
+
+var exportColumns = []export.Column{
+	{Key: "id", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "ID", mailtemplate.LANGUAGE_EN: "ID"}},
+	{Key: "name", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Nama Divisi", mailtemplate.LANGUAGE_EN: "Division Name"}},
+	{Key: "drive_folder_id", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "ID Folder Drive", mailtemplate.LANGUAGE_EN: "Drive Folder ID"}},
+	{Key: "created_at", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Tanggal Dibuat", mailtemplate.LANGUAGE_EN: "Created At"}},
+	{Key: "updated_at", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Tanggal Diubah", mailtemplate.LANGUAGE_EN: "Updated At"}},
+}
+
+// Export writes the divisi matching the same filters as Find, without paging.
+func (s *service) Export(ctx *abstraction.Context, format string) (*export.Export, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.DivisiQuery)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	columns, err := export.Columns(exportColumns, ctx.QueryParam("columns"))
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+
+	return &export.Export{
+		Name:     "divisi",
+		Format:   format,
+		Language: mailtemplate.Language(ctx.QueryParam("lang") + "," + ctx.Request().Header.Get("Accept-Language")),
+		Columns:  columns,
+		Rows: func(row func(map[string]interface{}) error) error {
+			return s.DivisiRepository.Export(ctx, q, func(v *model.DivisiEntityModel) error {
+				return row(map[string]interface{}{
+					"id":              v.ID,
+					"name":            v.Name,
+					"drive_folder_id": v.DriveFolderId,
+					"created_at":      v.CreatedAt,
+					"updated_at":      v.UpdatedAt,
+				})
+			})
+		},
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/dto"
 	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/pkg/export"
 	"daarul_mukhtarin/pkg/util/response"
 	"net/http"
 
@@ -20,6 +21,19 @@ func NewHandler(f *factory.Factory) *handler {
 	}
 }
 
+// @Summary      Create divisi
+// @Description  Create divisi
+// @Tags         Divisi
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.DivisiCreateRequest                  true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /divisi [post]
 func (h *handler) Create(c echo.Context) (err error) {
 	payload := new(dto.DivisiCreateRequest)
 	if err = c.Bind(payload); err != nil {
@@ -35,14 +49,90 @@ func (h *handler) Create(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      List divisi
+// @Description  List divisi
+// @Tags         Divisi
+// @Produce      json
+// @Security     BearerAuth
+// @Param        search    query     string                                   false "search term"
+// @Param        page      query     int                                      false "page, starts at 1"
+// @Param        page_size query     int                                      false "page size"
+// @Param        order_by  query     string                                   false "asc or desc"
+// @Param        order     query     string                                   false "column to order by"
+// @Param        format    query     string                                   false "csv or xlsx to download the list instead"
+// @Param        fields    query     string                                   false "comma separated fields to send, the rest is left out"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.DivisiResponse,meta=response.Meta}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /divisi [get]
 func (h handler) Find(c echo.Context) (err error) {
-	data, err := h.service.Find(c.(*abstraction.Context))
+	format, err := export.Format(c.Request())
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param").SendError(c)
+	}
+	if format != "" {
+		file, err := h.service.Export(c.(*abstraction.Context), format)
+		if err != nil {
+			return response.ErrorResponse(err).SendError(c)
+		}
+		return file.Send(c)
+	}
+
+	data, meta, err := h.service.Find(c.(*abstraction.Context))
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.ListResponse(data, meta).SendSuccess(c)
+}
+
+// @Summary      Get divisi
+// @Description  Get divisi
+// @Tags         Divisi
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        fields    query     string                                   false "comma separated fields to send, the rest is left out"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DivisiResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /divisi/{id} [get]
+func (h handler) FindById(c echo.Context) (err error) {
+	payload := new(dto.DivisiFindByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.FindById(c.(*abstraction.Context), payload)
 	if err != nil {
 		return response.ErrorResponse(err).SendError(c)
 	}
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Update divisi
+// @Description  Update divisi
+// @Tags         Divisi
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        If-Match  header    string                                   false "ETag from the last read, the update fails with 412 when it changed"
+// @Param        payload   body      dto.DivisiUpdateRequest                  true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      412  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /divisi/{id} [put]
 func (h handler) Update(c echo.Context) (err error) {
 	payload := new(dto.DivisiUpdateRequest)
 	if err = c.Bind(payload); err != nil {
@@ -58,6 +148,22 @@ func (h handler) Update(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Delete divisi
+// @Description  Delete divisi
+// @Tags         Divisi
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        If-Match  header    string                                   false "ETag from the last read, the update fails with 412 when it changed"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      412  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /divisi/{id} [delete]
 func (h handler) Delete(c echo.Context) (err error) {
 	payload := new(dto.DivisiDeleteByIDRequest)
 	if err := c.Bind(payload); err != nil {
@@ -9,6 +9,7 @@ import (
 func (h *handler) Route(v *echo.Group) {
 	v.POST("", h.Create, middleware.Authentication)
 	v.GET("", h.Find, middleware.Authentication)
+	v.GET("/:id", h.FindById, middleware.Authentication)
 	v.PUT("/:id", h.Update, middleware.Authentication)
 	v.DELETE("/:id", h.Delete, middleware.Authentication)
 }
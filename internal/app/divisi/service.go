@@ -7,6 +7,10 @@ import (
 	"daarul_mukhtarin/internal/model"
 	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/util/etag"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
 	"daarul_mukhtarin/pkg/util/response"
 	"daarul_mukhtarin/pkg/util/trxmanager"
 	"errors"
@@ -16,16 +20,20 @@ import (
 )
 
 type Service interface {
-	Create(ctx *abstraction.Context, payload *dto.DivisiCreateRequest) (map[string]interface{}, error)
-	Find(ctx *abstraction.Context) (map[string]interface{}, error)
-	Update(ctx *abstraction.Context, payload *dto.DivisiUpdateRequest) (map[string]interface{}, error)
-	Delete(ctx *abstraction.Context, payload *dto.DivisiDeleteByIDRequest) (map[string]interface{}, error)
+	Create(ctx *abstraction.Context, payload *dto.DivisiCreateRequest) (*dto.MessageResponse, error)
+	Find(ctx *abstraction.Context) ([]*dto.DivisiResponse, *response.Meta, error)
+	FindById(ctx *abstraction.Context, payload *dto.DivisiFindByIDRequest) (*dto.DivisiResponse, error)
+	Export(ctx *abstraction.Context, format string) (*export.Export, error)
+	Update(ctx *abstraction.Context, payload *dto.DivisiUpdateRequest) (*dto.MessageResponse, error)
+	Delete(ctx *abstraction.Context, payload *dto.DivisiDeleteByIDRequest) (*dto.MessageResponse, error)
 }
 
 type service struct {
 	DivisiRepository repository.Divisi
 	UserRepository   repository.User
 
+	DriveSync *DriveSync
+
 	DB *gorm.DB
 }
 
@@ -34,17 +42,20 @@ func NewService(f *factory.Factory) Service {
 		DivisiRepository: f.DivisiRepository,
 		UserRepository:   f.UserRepository,
 
+		DriveSync: NewDriveSync(f),
+
 		DB: f.Db,
 	}
 }
 
-func (s *service) Create(ctx *abstraction.Context, payload *dto.DivisiCreateRequest) (map[string]interface{}, error) {
+func (s *service) Create(ctx *abstraction.Context, payload *dto.DivisiCreateRequest) (*dto.MessageResponse, error) {
+	var divisiId int
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
 		}
 
-		dataAllDivisi, err := s.DivisiRepository.Find(ctx)
+		dataAllDivisi, err := s.DivisiRepository.FindAll(ctx)
 		if err != nil && err.Error() != "record not found" {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
@@ -60,50 +71,69 @@ func (s *service) Create(ctx *abstraction.Context, payload *dto.DivisiCreateRequ
 			DivisiEntity: model.DivisiEntity{
 				Name:     *payload.Name,
 				IsDelete: false,
+				Version:  1,
 			},
 		}
 		if err := s.DivisiRepository.Create(ctx, modelDivisi).Error; err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
+		divisiId = modelDivisi.ID
 
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success create!",
+	s.DriveSync.SyncAsync(divisiId)
+	return &dto.MessageResponse{Message: "success create!"}, nil
+}
+
+func (s *service) Find(ctx *abstraction.Context) ([]*dto.DivisiResponse, *response.Meta, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.DivisiQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	sel, err := fields.Parse(ctx.QueryParams(), repository.DivisiFields)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.DivisiRepository.Find(ctx, q, sel)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	count, err := s.DivisiRepository.Count(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	fields.Set(ctx, sel)
+	return dto.NewDivisiResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
 	}, nil
 }
 
-func (s *service) Find(ctx *abstraction.Context) (map[string]interface{}, error) {
-	var res []map[string]interface{}
+func (s *service) FindById(ctx *abstraction.Context, payload *dto.DivisiFindByIDRequest) (*dto.DivisiResponse, error) {
 	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
 	}
-	data, err := s.DivisiRepository.Find(ctx)
-	if err != nil && err.Error() != "record not found" {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	sel, err := fields.Parse(ctx.QueryParams(), repository.DivisiFields)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
 	}
-	count, err := s.DivisiRepository.Count(ctx)
+	data, err := s.DivisiRepository.FindByIdSelect(ctx, payload.ID, sel)
 	if err != nil && err.Error() != "record not found" {
 		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
-	for _, v := range data {
-		res = append(res, map[string]interface{}{
-			"id":         v.ID,
-			"name":       v.Name,
-			"is_delete":  v.IsDelete,
-			"created_at": v.CreatedAt,
-			"updated_at": v.UpdatedAt,
-		})
+	if data != nil {
+		etag.Set(ctx, data.Version)
 	}
-	return map[string]interface{}{
-		"count": count,
-		"data":  res,
-	}, nil
+	fields.Set(ctx, sel)
+	return dto.NewDivisiResponse(data), nil
 }
 
-func (s *service) Update(ctx *abstraction.Context, payload *dto.DivisiUpdateRequest) (map[string]interface{}, error) {
+func (s *service) Update(ctx *abstraction.Context, payload *dto.DivisiUpdateRequest) (*dto.MessageResponse, error) {
+	var version int
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
@@ -116,27 +146,39 @@ func (s *service) Update(ctx *abstraction.Context, payload *dto.DivisiUpdateRequ
 		if divisiData == nil {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi not found")
 		}
+		if !etag.IfMatch(ctx.Request(), divisiData.Version) {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "divisi was changed since it was read")
+		}
 
 		newDivisiData := new(model.DivisiEntityModel)
 		newDivisiData.Context = ctx
 		newDivisiData.ID = payload.ID
+		newDivisiData.Version = divisiData.Version
 		if payload.Name != nil {
 			newDivisiData.Name = *payload.Name
 		}
 
-		if err = s.DivisiRepository.Update(ctx, newDivisiData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		result := s.DivisiRepository.Update(ctx, newDivisiData)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "divisi was changed since it was read")
 		}
+		version = newDivisiData.Version
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success update!",
-	}, nil
+	etag.Set(ctx, version)
+	if payload.Name != nil {
+		// keeps the folder name in Drive the same as the divisi
+		s.DriveSync.SyncAsync(payload.ID)
+	}
+	return &dto.MessageResponse{Message: "success update!"}, nil
 }
 
-func (s *service) Delete(ctx *abstraction.Context, payload *dto.DivisiDeleteByIDRequest) (map[string]interface{}, error) {
+func (s *service) Delete(ctx *abstraction.Context, payload *dto.DivisiDeleteByIDRequest) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
@@ -149,6 +191,9 @@ func (s *service) Delete(ctx *abstraction.Context, payload *dto.DivisiDeleteByID
 		if divisiData == nil {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi not found")
 		}
+		if !etag.IfMatch(ctx.Request(), divisiData.Version) {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "divisi was changed since it was read")
+		}
 
 		divisiInUserData, err := s.UserRepository.FindByDivisiId(ctx, &payload.ID)
 		if err != nil && err.Error() != "record not found" {
@@ -158,19 +203,20 @@ func (s *service) Delete(ctx *abstraction.Context, payload *dto.DivisiDeleteByID
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi data is being used")
 		}
 
-		newDivisiData := new(model.DivisiEntityModel)
-		newDivisiData.Context = ctx
-		newDivisiData.ID = divisiData.ID
-		newDivisiData.IsDelete = true
-
-		if err = s.DivisiRepository.Update(ctx, newDivisiData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		version := 0
+		if etag.HasIfMatch(ctx.Request()) {
+			version = divisiData.Version
+		}
+		result := s.DivisiRepository.UpdateDelete(ctx, divisiData.ID, true, version)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "divisi was changed since it was read")
 		}
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success delete!",
-	}, nil
+	return &dto.MessageResponse{Message: "success delete!"}, nil
 }
@@ -0,0 +1,82 @@
+package dokumen
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/upload"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	cleanerInterval  = 10 * time.Minute
+	cleanerBatchSize = 100
+
+	// assembleTimeout is how long an upload may stay assembling before it is taken for abandoned.
+	assembleTimeout = 2 * time.Hour
+)
+
+// Cleaner removes the parts of uploads that expired before being completed or aborted, or whose
+// completion never finished.
+type Cleaner struct {
+	UploadSessionRepository repository.UploadSession
+
+	Chunks *upload.Chunks
+}
+
+func NewCleaner(f *factory.Factory) *Cleaner {
+	return &Cleaner{
+		UploadSessionRepository: f.UploadSessionRepository,
+
+		Chunks: f.Chunks,
+	}
+}
+
+// Start cleans expired uploads until ctx is cancelled.
+func (c *Cleaner) Start(ctx context.Context) {
+	ticker := time.NewTicker(cleanerInterval)
+	defer ticker.Stop()
+
+	logrus.Info("Upload cleaner started")
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Upload cleaner stopped")
+			return
+		case <-ticker.C:
+			if err := c.Clean(); err != nil {
+				logrus.Error("failed clean expired uploads: ", err)
+			}
+		}
+	}
+}
+
+// Clean expires every upload FindExpired returns, paging by id. An upload whose parts cannot be
+// removed is skipped and tried again on the next tick, so it never holds up the others.
+func (c *Cleaner) Clean() error {
+	ctx := &abstraction.Context{}
+	lastId := 0
+	for {
+		data, err := c.UploadSessionRepository.FindExpired(ctx, time.Now(), time.Now().Add(-assembleTimeout), lastId, cleanerBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, v := range data {
+			lastId = v.ID
+			if err = c.Chunks.Remove(v.Uid); err != nil {
+				logrus.Error("failed remove upload ", v.Uid, ": ", err)
+				continue
+			}
+			if err = c.UploadSessionRepository.UpdateStatus(ctx, v.ID, constant.UPLOAD_STATUS_EXPIRED, nil).Error; err != nil {
+				return err
+			}
+		}
+		if len(data) < cleanerBatchSize {
+			return nil
+		}
+	}
+}
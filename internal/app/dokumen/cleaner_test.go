@@ -0,0 +1,82 @@
+package dokumen
+
+import (
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/migrate"
+	"daarul_mukhtarin/pkg/upload"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCleanSkipsFailedUploads(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := migrations.Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.NewMigrator(db, list).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := upload.NewChunks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a full batch whose parts cannot be removed, the uid is not valid, and one more that can
+	expired := time.Now().Add(-time.Hour)
+	for i := 0; i <= cleanerBatchSize; i++ {
+		uid := fmt.Sprintf("broken uid %d", i)
+		if i == cleanerBatchSize {
+			uid = "valid_uid"
+		}
+		data := &model.UploadSessionEntityModel{UploadSessionEntity: model.UploadSessionEntity{
+			Uid: uid, Name: "a.pdf", Size: 1, DivisiId: 1, UserId: 1, Status: constant.UPLOAD_STATUS_PENDING, ExpiredAt: expired,
+		}}
+		if err = db.Create(data).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := &Cleaner{UploadSessionRepository: repository.NewUploadSession(db), Chunks: chunks}
+	done := make(chan error, 1)
+	go func() { done <- c.Clean() }()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Fatalf("Clean() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Clean() does not return when the uploads of a batch cannot be removed")
+	}
+
+	var pending int64
+	if err = db.Model(&model.UploadSessionEntityModel{}).Where("status = ?", constant.UPLOAD_STATUS_PENDING).Count(&pending).Error; err != nil {
+		t.Fatal(err)
+	}
+	if pending != cleanerBatchSize {
+		t.Errorf("pending uploads = %d, want %d", pending, cleanerBatchSize)
+	}
+	var valid model.UploadSessionEntityModel
+	if err = db.Where("uid = ?", "valid_uid").First(&valid).Error; err != nil {
+		t.Fatal(err)
+	}
+	if valid.Status != constant.UPLOAD_STATUS_EXPIRED {
+		t.Errorf("upload after a failed batch is %s, want %s", valid.Status, constant.UPLOAD_STATUS_EXPIRED)
+	}
+}
@@ -0,0 +1,487 @@
+package dokumen
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/util/response"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service: NewService(f),
+	}
+}
+
+// @Summary      Upload dokumen
+// @Description  Upload dokumen
+// @Tags         Dokumen
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        files     formData  file                                     true  "files"
+// @Param        folder_id formData  int                                      false "folder id"
+// @Param        divisi_id formData  int                                      false "divisi id"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.DokumenResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen [post]
+func (h handler) Upload(c echo.Context) (err error) {
+	payload := new(dto.DokumenUploadRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	data, err := h.service.Upload(c.(*abstraction.Context), payload, form.File["files"])
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      List dokumen
+// @Description  List dokumen
+// @Tags         Dokumen
+// @Produce      json
+// @Security     BearerAuth
+// @Param        search    query     string                                   false "search term"
+// @Param        page      query     int                                      false "page, starts at 1"
+// @Param        page_size query     int                                      false "page size"
+// @Param        order_by  query     string                                   false "asc or desc"
+// @Param        order     query     string                                   false "column to order by"
+// @Param        cursor    query     string                                   false "cursor from meta.pagination.next, instead of page"
+// @Param        fields    query     string                                   false "comma separated fields to send, the rest is left out"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.DokumenResponse,meta=response.Meta}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen [get]
+func (h handler) Find(c echo.Context) (err error) {
+	data, meta, err := h.service.Find(c.(*abstraction.Context))
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.ListResponse(data, meta).SendSuccess(c)
+}
+
+// @Summary      Get dokumen
+// @Description  Get dokumen
+// @Tags         Dokumen
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        fields    query     string                                   false "comma separated fields to send, the rest is left out"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DokumenResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/{id} [get]
+func (h handler) FindById(c echo.Context) (err error) {
+	payload := new(dto.DokumenFindByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.FindById(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Download dokumen
+// @Description  Download dokumen
+// @Tags         Dokumen
+// @Produce      octet-stream
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/{id}/download [get]
+func (h handler) Download(c echo.Context) (err error) {
+	payload := new(dto.DokumenFindByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, content, err := h.service.Download(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	defer content.Close()
+	return stream(c, data, content)
+}
+
+// @Summary      Delete dokumen
+// @Description  Delete dokumen
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/{id} [delete]
+func (h handler) Delete(c echo.Context) (err error) {
+	payload := new(dto.DokumenDeleteByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Delete(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Create folder
+// @Description  Create folder
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.DokumenFolderCreateRequest           true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/folder [post]
+func (h handler) CreateFolder(c echo.Context) (err error) {
+	payload := new(dto.DokumenFolderCreateRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.CreateFolder(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Folder tree
+// @Description  Folder tree
+// @Tags         Dokumen
+// @Produce      json
+// @Security     BearerAuth
+// @Param        divisi_id query     int                                      false "divisi id"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.DokumenFolderResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/folder [get]
+func (h handler) FindFolder(c echo.Context) (err error) {
+	payload := new(dto.DokumenFolderFindRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	data, err := h.service.FindFolder(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.ListResponse(data, nil).SendSuccess(c)
+}
+
+// @Summary      Delete folder
+// @Description  Delete folder
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/folder/{id} [delete]
+func (h handler) DeleteFolder(c echo.Context) (err error) {
+	payload := new(dto.DokumenFolderDeleteByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.DeleteFolder(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Start a resumable upload
+// @Description  Start a resumable upload
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.DokumenUploadInitiateRequest         true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DokumenUploadSessionResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/upload [post]
+func (h handler) InitiateUpload(c echo.Context) (err error) {
+	payload := new(dto.DokumenUploadInitiateRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.InitiateUpload(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Get a resumable upload
+// @Description  Get a resumable upload
+// @Tags         Dokumen
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string                                   true  "upload id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DokumenUploadSessionResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/upload/{id} [get]
+func (h handler) FindUpload(c echo.Context) (err error) {
+	payload := new(dto.DokumenUploadSessionRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.FindUpload(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Complete a resumable upload
+// @Description  Complete a resumable upload
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string                                   true  "upload id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DokumenResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/upload/{id}/complete [post]
+func (h handler) CompleteUpload(c echo.Context) (err error) {
+	payload := new(dto.DokumenUploadSessionRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.CompleteUpload(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Abort a resumable upload
+// @Description  Abort a resumable upload
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string                                   true  "upload id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/upload/{id} [delete]
+func (h handler) AbortUpload(c echo.Context) (err error) {
+	payload := new(dto.DokumenUploadSessionRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.AbortUpload(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Upload a part
+// @Description  Upload a part
+// @Tags         Dokumen
+// @Accept       application/octet-stream
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string                                   true  "upload id"
+// @Param        part      path      int                                      true  "part number"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DokumenUploadPartResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/upload/{id}/{part} [put]
+func (h handler) UploadPart(c echo.Context) (err error) {
+	payload := new(dto.DokumenUploadPartRequest)
+	if err = (&echo.DefaultBinder{}).BindPathParams(c, payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.UploadPart(c.(*abstraction.Context), payload, c.Request().Body)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Create a share link
+// @Description  Create a share link
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        payload   body      dto.DokumenCreateLinkRequest             true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.DokumenLinkResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/{id}/link [post]
+func (h handler) CreateLink(c echo.Context) (err error) {
+	payload := new(dto.DokumenCreateLinkRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.CreateLink(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Revoke a share link
+// @Description  Revoke a share link
+// @Tags         Dokumen
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.DokumenRevokeLinkRequest             true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /dokumen/link/revoke [post]
+func (h handler) RevokeLink(c echo.Context) (err error) {
+	payload := new(dto.DokumenRevokeLinkRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.RevokeLink(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Download from a share link
+// @Description  Download from a share link
+// @Tags         Dokumen
+// @Produce      octet-stream
+// @Param        token     path      string                                   true  "link token"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /download/{token} [get]
+func (h handler) DownloadLink(c echo.Context) (err error) {
+	payload := new(dto.DokumenDownloadLinkRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, content, err := h.service.DownloadLink(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	defer content.Close()
+	return stream(c, data, content)
+}
+
+func stream(c echo.Context, data *model.DokumenEntityModel, content io.Reader) error {
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": data.Name}))
+	c.Response().Header().Set(echo.HeaderContentLength, strconv.FormatInt(data.Size, 10))
+	return c.Stream(http.StatusOK, data.MimeType, content)
+}
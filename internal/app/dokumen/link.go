@@ -0,0 +1,127 @@
+package dokumen
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/signedurl"
+	"daarul_mukhtarin/pkg/util/response"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	LINK_RESOURCE      = "dokumen"
+	defaultLinkExpires = 1 * time.Hour
+)
+
+// Link signs a public download url for dokumenId, for use in emails and notification links.
+func (s *service) Link(dokumenId int, expiry time.Duration, singleUse bool) (string, *signedurl.Claims, error) {
+	token, claims, err := s.Signer.Sign(LINK_RESOURCE, dokumenId, expiry, singleUse)
+	if err != nil {
+		return "", nil, err
+	}
+	return constant.BASE_URL + "/download/" + token, claims, nil
+}
+
+func (s *service) CreateLink(ctx *abstraction.Context, payload *dto.DokumenCreateLinkRequest) (*dto.DokumenLinkResponse, error) {
+	data, err := s.DokumenRepository.FindById(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if data == nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "dokumen not found")
+	}
+	if !canAccess(ctx, data.DivisiId) {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+
+	expiry := defaultLinkExpires
+	if payload.ExpiresIn > 0 {
+		expiry = time.Duration(payload.ExpiresIn) * time.Second
+	}
+	url, claims, err := s.Link(data.ID, expiry, payload.SingleUse)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	return &dto.DokumenLinkResponse{
+		Url:       url,
+		SingleUse: claims.SingleUse,
+		ExpiredAt: claims.Expiry(),
+	}, nil
+}
+
+// RevokeLink denylists a link until it would have expired anyway.
+func (s *service) RevokeLink(ctx *abstraction.Context, payload *dto.DokumenRevokeLinkRequest) (*dto.MessageResponse, error) {
+	claims, err := s.Signer.Verify(payload.Token)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid link")
+	}
+	if claims.Resource != LINK_RESOURCE {
+		return nil, response.ErrorBuilder(http.StatusNotFound, signedurl.ErrInvalid, "dokumen not found")
+	}
+	data, err := s.DokumenRepository.FindById(ctx, claims.ResourceId)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	// without the dokumen nobody can be told allowed, and its links no longer download anyway
+	if data == nil {
+		return nil, response.ErrorBuilder(http.StatusNotFound, errors.New("not_found"), "dokumen not found")
+	}
+	if !canManage(ctx, data.DivisiId, data.UserId) {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+
+	key := fmt.Sprintf(constant.REDIS_DOWNLOAD_DENY_KEYS, claims.Jti)
+	if err = s.DbRedis.Set(ctx.Request().Context(), key, 1, time.Until(claims.Expiry())).Err(); err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return &dto.MessageResponse{Message: "success revoke!"}, nil
+}
+
+// DownloadLink streams the document a signed link points to, no session is needed.
+func (s *service) DownloadLink(ctx *abstraction.Context, payload *dto.DokumenDownloadLinkRequest) (*model.DokumenEntityModel, io.ReadCloser, error) {
+	claims, err := s.Signer.Verify(payload.Token)
+	if err != nil {
+		if errors.Is(err, signedurl.ErrExpired) || errors.Is(err, signedurl.ErrUnknownKey) {
+			return nil, nil, response.ErrorBuilder(http.StatusGone, err, "link is no longer valid")
+		}
+		return nil, nil, response.ErrorBuilder(http.StatusNotFound, err, "dokumen not found")
+	}
+	if claims.Resource != LINK_RESOURCE {
+		return nil, nil, response.ErrorBuilder(http.StatusNotFound, signedurl.ErrInvalid, "dokumen not found")
+	}
+
+	reqCtx := ctx.Request().Context()
+	denied, err := s.DbRedis.Exists(reqCtx, fmt.Sprintf(constant.REDIS_DOWNLOAD_DENY_KEYS, claims.Jti)).Result()
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if denied > 0 {
+		return nil, nil, response.ErrorBuilder(http.StatusGone, errors.New("gone"), "link is no longer valid")
+	}
+
+	usedKey := fmt.Sprintf(constant.REDIS_DOWNLOAD_USED_KEYS, claims.Jti)
+	if claims.SingleUse {
+		// claim the link before serving it so two concurrent requests cannot both use it
+		ok, err := s.DbRedis.SetNX(reqCtx, usedKey, 1, time.Until(claims.Expiry())).Result()
+		if err != nil {
+			return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if !ok {
+			return nil, nil, response.ErrorBuilder(http.StatusGone, errors.New("gone"), "link is no longer valid")
+		}
+	}
+
+	data, content, err := s.open(ctx, claims.ResourceId, false)
+	if err != nil && claims.SingleUse {
+		// nothing was served, let the link be used again
+		s.DbRedis.Del(reqCtx, usedKey)
+	}
+	return data, content, err
+}
@@ -0,0 +1,247 @@
+package dokumen
+
+import (
+	"crypto/rand"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/config"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/upload"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultUploadExpire = 24 * time.Hour
+
+// uploadExpire is how long an incomplete upload is kept, from UPLOAD_EXPIRE.
+func uploadExpire() time.Duration {
+	if expire, err := time.ParseDuration(config.Get().Upload.Expire); err == nil && expire > 0 {
+		return expire
+	}
+	return defaultUploadExpire
+}
+
+func (s *service) InitiateUpload(ctx *abstraction.Context, payload *dto.DokumenUploadInitiateRequest) (*dto.DokumenUploadSessionResponse, error) {
+	if payload.Size > upload.LargePolicy.MaxSize {
+		return nil, response.ErrorBuilder(http.StatusRequestEntityTooLarge, errors.New("request_entity_too_large"), upload.CODE_TOO_LARGE)
+	}
+
+	divisiId, err := s.targetDivisi(ctx, payload.DivisiId)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkFolder(ctx, payload.FolderId, divisiId); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, 16)
+	if _, err = rand.Read(b); err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	modelSession := &model.UploadSessionEntityModel{
+		Context: ctx,
+		UploadSessionEntity: model.UploadSessionEntity{
+			Uid:       hex.EncodeToString(b),
+			Name:      upload.SanitizeFilename(payload.Name),
+			Size:      payload.Size,
+			FolderId:  payload.FolderId,
+			DivisiId:  divisiId,
+			UserId:    ctx.Auth.ID,
+			Status:    constant.UPLOAD_STATUS_PENDING,
+			ExpiredAt: time.Now().Add(uploadExpire()),
+		},
+	}
+	if err = s.UploadSessionRepository.Create(ctx, modelSession).Error; err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	return dto.NewDokumenUploadSessionResponse(modelSession, nil), nil
+}
+
+// pendingSession loads an upload the caller owns and can still add to.
+func (s *service) pendingSession(ctx *abstraction.Context, id string, forUpdate bool) (*model.UploadSessionEntityModel, error) {
+	var (
+		sessionData *model.UploadSessionEntityModel
+		err         error
+	)
+	if forUpdate {
+		sessionData, err = s.UploadSessionRepository.FindByUidForUpdate(ctx, id)
+	} else {
+		sessionData, err = s.UploadSessionRepository.FindByUid(ctx, id)
+	}
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if sessionData == nil || sessionData.UserId != ctx.Auth.ID {
+		return nil, response.ErrorBuilder(http.StatusNotFound, errors.New("not_found"), "upload not found")
+	}
+	if sessionData.Status != constant.UPLOAD_STATUS_PENDING || time.Now().After(sessionData.ExpiredAt) {
+		return nil, response.ErrorBuilder(http.StatusGone, errors.New("gone"), "upload is no longer active")
+	}
+	return sessionData, nil
+}
+
+func (s *service) UploadPart(ctx *abstraction.Context, payload *dto.DokumenUploadPartRequest, content io.Reader) (*dto.DokumenUploadPartResponse, error) {
+	sessionData, err := s.pendingSession(ctx, payload.ID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := s.Chunks.PutPart(sessionData.Uid, payload.Part, sessionData.Size, content)
+	if err != nil {
+		if errors.Is(err, upload.ErrPartTooLarge) || errors.Is(err, upload.ErrSizeExceeded) {
+			return nil, response.ErrorBuilder(http.StatusRequestEntityTooLarge, err, upload.CODE_TOO_LARGE)
+		}
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	return &dto.DokumenUploadPartResponse{
+		Part: payload.Part,
+		Size: size,
+	}, nil
+}
+
+func (s *service) FindUpload(ctx *abstraction.Context, payload *dto.DokumenUploadSessionRequest) (*dto.DokumenUploadSessionResponse, error) {
+	sessionData, err := s.UploadSessionRepository.FindByUid(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if sessionData == nil || sessionData.UserId != ctx.Auth.ID {
+		return nil, response.ErrorBuilder(http.StatusNotFound, errors.New("not_found"), "upload not found")
+	}
+
+	var parts []upload.Part
+	if sessionData.Status == constant.UPLOAD_STATUS_PENDING {
+		if parts, err = s.Chunks.Parts(sessionData.Uid); err != nil {
+			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+	}
+
+	return dto.NewDokumenUploadSessionResponse(sessionData, parts), nil
+}
+
+// CompleteUpload assembles, scans and stores an upload. That can take minutes for a large file,
+// so it happens between two short transactions: the first claims the session as assembling, the
+// second creates the dokumen. A failed attempt puts the session back to pending.
+func (s *service) CompleteUpload(ctx *abstraction.Context, payload *dto.DokumenUploadSessionRequest) (*dto.DokumenResponse, error) {
+	var sessionData *model.UploadSessionEntityModel
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		var err error
+		if sessionData, err = s.pendingSession(ctx, payload.ID, true); err != nil {
+			return err
+		}
+		if err = s.UploadSessionRepository.UpdateStatus(ctx, sessionData.ID, constant.UPLOAD_STATUS_ASSEMBLING, nil).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	res, err := s.completeUpload(ctx, sessionData)
+	if err != nil {
+		if errRevert := s.UploadSessionRepository.UpdateStatusFrom(ctx, sessionData.ID, constant.UPLOAD_STATUS_ASSEMBLING, constant.UPLOAD_STATUS_PENDING).Error; errRevert != nil {
+			logrus.Error(fmt.Sprintf("failed reopen upload %s: %v", sessionData.Uid, errRevert))
+		}
+		return nil, err
+	}
+
+	if err := s.Chunks.Remove(sessionData.Uid); err != nil {
+		logrus.Error(fmt.Sprintf("failed remove upload %s: %v", sessionData.Uid, err))
+	}
+	return res, nil
+}
+
+func (s *service) completeUpload(ctx *abstraction.Context, sessionData *model.UploadSessionEntityModel) (*dto.DokumenResponse, error) {
+	assembled, err := s.Chunks.Assemble(sessionData.Uid, sessionData.Size)
+	if err != nil {
+		if errors.Is(err, upload.ErrPartsMissing) || errors.Is(err, upload.ErrSizeMismatch) {
+			return nil, response.ErrorBuilder(http.StatusBadRequest, err, "upload is incomplete")
+		}
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	file, err := upload.LargePolicy.OpenFile(ctx.Request().Context(), s.Scanner, sessionData.Name, sessionData.Size, assembled)
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	storageKey, isNew, err := s.put(ctx, sessionData.DivisiId, file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var res *dto.DokumenResponse
+	if err = trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		current, err := s.UploadSessionRepository.FindByUidForUpdate(ctx, sessionData.Uid)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if current.Status != constant.UPLOAD_STATUS_ASSEMBLING {
+			return response.ErrorBuilder(http.StatusGone, errors.New("gone"), "upload is no longer active")
+		}
+
+		modelDokumen := &model.DokumenEntityModel{
+			Context: ctx,
+			DokumenEntity: model.DokumenEntity{
+				Name:       file.Name,
+				Size:       file.Size,
+				MimeType:   file.ContentType,
+				Checksum:   file.Checksum,
+				StorageKey: storageKey,
+				FolderId:   sessionData.FolderId,
+				DivisiId:   sessionData.DivisiId,
+				UserId:     sessionData.UserId,
+				IsDelete:   false,
+			},
+		}
+		if err = s.DokumenRepository.Create(ctx, modelDokumen).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if err = s.UploadSessionRepository.UpdateStatus(ctx, sessionData.ID, constant.UPLOAD_STATUS_COMPLETED, &modelDokumen.ID).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+
+		res = dto.NewDokumenResponse(modelDokumen)
+		return nil
+	}); err != nil {
+		if isNew {
+			if errDelete := s.Storage.Delete(ctx.Request().Context(), storageKey); errDelete != nil {
+				logrus.Error("failed delete orphan dokumen ", storageKey, ": ", errDelete)
+			}
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *service) AbortUpload(ctx *abstraction.Context, payload *dto.DokumenUploadSessionRequest) (*dto.MessageResponse, error) {
+	var uid string
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		sessionData, err := s.pendingSession(ctx, payload.ID, true)
+		if err != nil {
+			return err
+		}
+		uid = sessionData.Uid
+
+		if err = s.UploadSessionRepository.UpdateStatus(ctx, sessionData.ID, constant.UPLOAD_STATUS_ABORTED, nil).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.Chunks.Remove(uid); err != nil {
+		logrus.Error(fmt.Sprintf("failed remove upload %s: %v", uid, err))
+	}
+	return &dto.MessageResponse{Message: "success abort!"}, nil
+}
@@ -0,0 +1,29 @@
+package dokumen
+
+import (
+	"daarul_mukhtarin/internal/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) Route(v *echo.Group) {
+	v.POST("", h.Upload, middleware.Authentication)
+	v.GET("", h.Find, middleware.Authentication)
+	v.POST("/folder", h.CreateFolder, middleware.Authentication)
+	v.GET("/folder", h.FindFolder, middleware.Authentication)
+	v.DELETE("/folder/:id", h.DeleteFolder, middleware.Authentication)
+	v.POST("/upload", h.InitiateUpload, middleware.Authentication)
+	v.GET("/upload/:id", h.FindUpload, middleware.Authentication)
+	v.PUT("/upload/:id/:part", h.UploadPart, middleware.Authentication)
+	v.POST("/upload/:id/complete", h.CompleteUpload, middleware.Authentication)
+	v.DELETE("/upload/:id", h.AbortUpload, middleware.Authentication)
+	v.POST("/link/revoke", h.RevokeLink, middleware.Authentication)
+	v.POST("/:id/link", h.CreateLink, middleware.Authentication)
+	v.GET("/:id", h.FindById, middleware.Authentication)
+	v.GET("/:id/download", h.Download, middleware.Authentication)
+	v.DELETE("/:id", h.Delete, middleware.Authentication)
+}
+
+func (h *handler) RouteDownload(v *echo.Group) {
+	v.GET("/:token", h.DownloadLink)
+}
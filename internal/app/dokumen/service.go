@@ -0,0 +1,397 @@
+package dokumen
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/signedurl"
+	"daarul_mukhtarin/pkg/storage"
+	"daarul_mukhtarin/pkg/upload"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Upload(ctx *abstraction.Context, payload *dto.DokumenUploadRequest, files []*multipart.FileHeader) ([]*dto.DokumenResponse, error)
+	Find(ctx *abstraction.Context) ([]*dto.DokumenResponse, *response.Meta, error)
+	FindById(ctx *abstraction.Context, payload *dto.DokumenFindByIDRequest) (*dto.DokumenResponse, error)
+	Download(ctx *abstraction.Context, payload *dto.DokumenFindByIDRequest) (*model.DokumenEntityModel, io.ReadCloser, error)
+	Delete(ctx *abstraction.Context, payload *dto.DokumenDeleteByIDRequest) (*dto.MessageResponse, error)
+	CreateFolder(ctx *abstraction.Context, payload *dto.DokumenFolderCreateRequest) (*dto.MessageResponse, error)
+	FindFolder(ctx *abstraction.Context, payload *dto.DokumenFolderFindRequest) ([]*dto.DokumenFolderResponse, error)
+	DeleteFolder(ctx *abstraction.Context, payload *dto.DokumenFolderDeleteByIDRequest) (*dto.MessageResponse, error)
+	InitiateUpload(ctx *abstraction.Context, payload *dto.DokumenUploadInitiateRequest) (*dto.DokumenUploadSessionResponse, error)
+	UploadPart(ctx *abstraction.Context, payload *dto.DokumenUploadPartRequest, content io.Reader) (*dto.DokumenUploadPartResponse, error)
+	FindUpload(ctx *abstraction.Context, payload *dto.DokumenUploadSessionRequest) (*dto.DokumenUploadSessionResponse, error)
+	CompleteUpload(ctx *abstraction.Context, payload *dto.DokumenUploadSessionRequest) (*dto.DokumenResponse, error)
+	AbortUpload(ctx *abstraction.Context, payload *dto.DokumenUploadSessionRequest) (*dto.MessageResponse, error)
+	Link(dokumenId int, expiry time.Duration, singleUse bool) (string, *signedurl.Claims, error)
+	CreateLink(ctx *abstraction.Context, payload *dto.DokumenCreateLinkRequest) (*dto.DokumenLinkResponse, error)
+	RevokeLink(ctx *abstraction.Context, payload *dto.DokumenRevokeLinkRequest) (*dto.MessageResponse, error)
+	DownloadLink(ctx *abstraction.Context, payload *dto.DokumenDownloadLinkRequest) (*model.DokumenEntityModel, io.ReadCloser, error)
+}
+
+type service struct {
+	DokumenRepository       repository.Dokumen
+	DivisiRepository        repository.Divisi
+	UploadSessionRepository repository.UploadSession
+
+	Storage storage.Storage
+	Scanner upload.Scanner
+	Chunks  *upload.Chunks
+	Signer  *signedurl.Signer
+
+	DB      *gorm.DB
+	DbRedis *redis.Client
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		DokumenRepository:       f.DokumenRepository,
+		DivisiRepository:        f.DivisiRepository,
+		UploadSessionRepository: f.UploadSessionRepository,
+
+		Storage: f.Storage,
+		Scanner: f.Scanner,
+		Chunks:  f.Chunks,
+		Signer:  f.Signer,
+
+		DB:      f.Db,
+		DbRedis: f.DbRedis,
+	}
+}
+
+// scope returns the divisi the caller is limited to, admins are not limited.
+func scope(ctx *abstraction.Context) *int {
+	if ctx.Auth.RoleID == constant.ROLE_ID_ADMIN {
+		return nil
+	}
+	return &ctx.Auth.DivisiID
+}
+
+func canAccess(ctx *abstraction.Context, divisiId int) bool {
+	return ctx.Auth.RoleID == constant.ROLE_ID_ADMIN || ctx.Auth.DivisiID == divisiId
+}
+
+// canManage reports whether the caller may delete something in divisiId created by userId.
+func canManage(ctx *abstraction.Context, divisiId int, userId int) bool {
+	if ctx.Auth.RoleID == constant.ROLE_ID_ADMIN {
+		return true
+	}
+	if ctx.Auth.DivisiID != divisiId {
+		return false
+	}
+	return ctx.Auth.RoleID == constant.ROLE_ID_KEPALA_DIVISI || ctx.Auth.ID == userId
+}
+
+// targetDivisi resolves the divisi a new document or folder belongs to, only admins may pick one.
+func (s *service) targetDivisi(ctx *abstraction.Context, divisiId *int) (int, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		if divisiId != nil && *divisiId != ctx.Auth.DivisiID {
+			return 0, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+		return ctx.Auth.DivisiID, nil
+	}
+	if divisiId == nil {
+		return 0, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi_id is required")
+	}
+	divisiData, err := s.DivisiRepository.FindById(ctx, *divisiId)
+	if err != nil && err.Error() != "record not found" {
+		return 0, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if divisiData == nil {
+		return 0, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi not found")
+	}
+	return divisiData.ID, nil
+}
+
+func (s *service) checkFolder(ctx *abstraction.Context, folderId *int, divisiId int) error {
+	if folderId == nil {
+		return nil
+	}
+	folderData, err := s.DokumenRepository.FindFolderById(ctx, *folderId)
+	if err != nil && err.Error() != "record not found" {
+		return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if folderData == nil || folderData.DivisiId != divisiId {
+		return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "folder not found")
+	}
+	return nil
+}
+
+func (s *service) Upload(ctx *abstraction.Context, payload *dto.DokumenUploadRequest, files []*multipart.FileHeader) ([]*dto.DokumenResponse, error) {
+	if err := upload.DokumenPolicy.CheckCount(files); err != nil {
+		return nil, uploadError(err)
+	}
+
+	divisiId, err := s.targetDivisi(ctx, payload.DivisiId)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.checkFolder(ctx, payload.FolderId, divisiId); err != nil {
+		return nil, err
+	}
+
+	var (
+		res      []*dto.DokumenResponse
+		uploaded []string
+	)
+	if err = trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		for _, header := range files {
+			file, err := upload.DokumenPolicy.Open(ctx.Request().Context(), s.Scanner, header)
+			if err != nil {
+				return uploadError(err)
+			}
+			storageKey, isNew, err := s.put(ctx, divisiId, file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+			if isNew {
+				uploaded = append(uploaded, storageKey)
+			}
+
+			modelDokumen := &model.DokumenEntityModel{
+				Context: ctx,
+				DokumenEntity: model.DokumenEntity{
+					Name:       file.Name,
+					Size:       file.Size,
+					MimeType:   file.ContentType,
+					Checksum:   file.Checksum,
+					StorageKey: storageKey,
+					FolderId:   payload.FolderId,
+					DivisiId:   divisiId,
+					UserId:     ctx.Auth.ID,
+					IsDelete:   false,
+				},
+			}
+			if err = s.DokumenRepository.Create(ctx, modelDokumen).Error; err != nil {
+				return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+			}
+			res = append(res, dto.NewDokumenResponse(modelDokumen))
+		}
+		return nil
+	}); err != nil {
+		// nothing references the uploaded objects once the transaction is rolled back
+		for _, key := range uploaded {
+			if errDelete := s.Storage.Delete(ctx.Request().Context(), key); errDelete != nil {
+				logrus.Error("failed delete orphan dokumen ", key, ": ", errDelete)
+			}
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// put stores file unless the divisi already has the same content, in which case the existing
+// object is shared. isNew reports whether an object was created.
+func (s *service) put(ctx *abstraction.Context, divisiId int, file *upload.File) (storageKey string, isNew bool, err error) {
+	dokumenData, err := s.DokumenRepository.FindByChecksum(ctx, divisiId, file.Checksum)
+	if err != nil && err.Error() != "record not found" {
+		return "", false, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if dokumenData != nil {
+		return dokumenData.StorageKey, false, nil
+	}
+
+	key := fmt.Sprintf("dokumen/%d/%d_%s", divisiId, time.Now().UnixNano(), file.Name)
+	object, err := s.Storage.Put(ctx.Request().Context(), key, file.Content, file.Size, file.ContentType)
+	if err != nil {
+		return "", false, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return object.Key, true, nil
+}
+
+func uploadError(err error) error {
+	var uploadErr *upload.Error
+	if errors.As(err, &uploadErr) {
+		return response.ErrorBuilder(uploadErr.Status, uploadErr, uploadErr.Code)
+	}
+	return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+}
+
+func (s *service) Find(ctx *abstraction.Context) ([]*dto.DokumenResponse, *response.Meta, error) {
+	q, err := query.Parse(ctx.QueryParams(), repository.DokumenQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	sel, err := fields.Parse(ctx.QueryParams(), repository.DokumenFields)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.DokumenRepository.Find(ctx, scope(ctx), q, sel)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	count, err := s.DokumenRepository.Count(ctx, scope(ctx), q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	fields.Set(ctx, sel)
+	return dto.NewDokumenResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
+	}, nil
+}
+
+func (s *service) FindById(ctx *abstraction.Context, payload *dto.DokumenFindByIDRequest) (*dto.DokumenResponse, error) {
+	sel, err := fields.Parse(ctx.QueryParams(), repository.DokumenFields)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.DokumenRepository.FindByIdSelect(ctx, payload.ID, sel)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if data != nil && !canAccess(ctx, data.DivisiId) {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	fields.Set(ctx, sel)
+	return dto.NewDokumenResponse(data), nil
+}
+
+func (s *service) Download(ctx *abstraction.Context, payload *dto.DokumenFindByIDRequest) (*model.DokumenEntityModel, io.ReadCloser, error) {
+	return s.open(ctx, payload.ID, true)
+}
+
+// open loads dokumen id and its content, checking the caller's divisi when authorize is set.
+func (s *service) open(ctx *abstraction.Context, id int, authorize bool) (*model.DokumenEntityModel, io.ReadCloser, error) {
+	data, err := s.DokumenRepository.FindById(ctx, id)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if data == nil {
+		return nil, nil, response.ErrorBuilder(http.StatusNotFound, errors.New("not_found"), "dokumen not found")
+	}
+	if authorize && !canAccess(ctx, data.DivisiId) {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	content, _, err := s.Storage.Get(ctx.Request().Context(), data.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, response.ErrorBuilder(http.StatusNotFound, err, "dokumen file not found")
+		}
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return data, content, nil
+}
+
+func (s *service) Delete(ctx *abstraction.Context, payload *dto.DokumenDeleteByIDRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		dokumenData, err := s.DokumenRepository.FindById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if dokumenData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "dokumen not found")
+		}
+		if !canManage(ctx, dokumenData.DivisiId, dokumenData.UserId) {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		if err = s.DokumenRepository.UpdateDelete(ctx, dokumenData.ID).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success delete!"}, nil
+}
+
+func (s *service) CreateFolder(ctx *abstraction.Context, payload *dto.DokumenFolderCreateRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID == constant.ROLE_ID_STAF {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		divisiId, err := s.targetDivisi(ctx, payload.DivisiId)
+		if err != nil {
+			return err
+		}
+		if err = s.checkFolder(ctx, payload.ParentId, divisiId); err != nil {
+			return err
+		}
+
+		modelFolder := &model.DokumenFolderEntityModel{
+			Context: ctx,
+			DokumenFolderEntity: model.DokumenFolderEntity{
+				Name:      payload.Name,
+				ParentId:  payload.ParentId,
+				DivisiId:  divisiId,
+				CreatedBy: ctx.Auth.ID,
+				IsDelete:  false,
+			},
+		}
+		if err = s.DokumenRepository.CreateFolder(ctx, modelFolder).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success create!"}, nil
+}
+
+// FindFolder returns the folder tree of every divisi the caller can see.
+func (s *service) FindFolder(ctx *abstraction.Context, payload *dto.DokumenFolderFindRequest) ([]*dto.DokumenFolderResponse, error) {
+	divisiId := scope(ctx)
+	if divisiId == nil {
+		divisiId = payload.DivisiId
+	}
+	data, err := s.DokumenRepository.FindFolders(ctx, divisiId)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	return dto.NewDokumenFolderTree(data), nil
+}
+
+func (s *service) DeleteFolder(ctx *abstraction.Context, payload *dto.DokumenFolderDeleteByIDRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		folderData, err := s.DokumenRepository.FindFolderById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if folderData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "folder not found")
+		}
+		if ctx.Auth.RoleID == constant.ROLE_ID_STAF || !canAccess(ctx, folderData.DivisiId) {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		countFolder, err := s.DokumenRepository.CountFolderByParentId(ctx, folderData.ID)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		countDokumen, err := s.DokumenRepository.CountByFolderId(ctx, folderData.ID)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if *countFolder > 0 || *countDokumen > 0 {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "folder is not empty")
+		}
+
+		if err = s.DokumenRepository.UpdateFolderDelete(ctx, folderData.ID).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success delete!"}, nil
+}
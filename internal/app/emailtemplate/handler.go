@@ -0,0 +1,218 @@
+package emailtemplate
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/pkg/util/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service: NewService(f),
+	}
+}
+
+// @Summary      Create email template
+// @Description  Create email template
+// @Tags         EmailTemplate
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.EmailTemplateCreateRequest           true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template [post]
+func (h handler) Create(c echo.Context) (err error) {
+	payload := new(dto.EmailTemplateCreateRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Create(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      List email templates
+// @Description  List email templates
+// @Tags         EmailTemplate
+// @Produce      json
+// @Security     BearerAuth
+// @Param        search    query     string                                   false "search term"
+// @Param        page      query     int                                      false "page, starts at 1"
+// @Param        page_size query     int                                      false "page size"
+// @Param        order_by  query     string                                   false "asc or desc"
+// @Param        order     query     string                                   false "column to order by"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.EmailTemplateResponse,meta=response.Meta}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template [get]
+func (h handler) Find(c echo.Context) (err error) {
+	data, meta, err := h.service.Find(c.(*abstraction.Context))
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.ListResponse(data, meta).SendSuccess(c)
+}
+
+// @Summary      Get email template
+// @Description  Get email template
+// @Tags         EmailTemplate
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.EmailTemplateDetailResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template/{id} [get]
+func (h handler) FindById(c echo.Context) (err error) {
+	payload := new(dto.EmailTemplateFindByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.FindById(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Update email template
+// @Description  Update email template
+// @Tags         EmailTemplate
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        payload   body      dto.EmailTemplateUpdateRequest           true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template/{id} [put]
+func (h handler) Update(c echo.Context) (err error) {
+	payload := new(dto.EmailTemplateUpdateRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Update(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Delete email template
+// @Description  Delete email template
+// @Tags         EmailTemplate
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template/{id} [delete]
+func (h handler) Delete(c echo.Context) (err error) {
+	payload := new(dto.EmailTemplateDeleteByIDRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Delete(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Restore email template version
+// @Description  Restore email template version
+// @Tags         EmailTemplate
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        version   path      int                                      true  "version"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template/{id}/restore/{version} [post]
+func (h handler) Restore(c echo.Context) (err error) {
+	payload := new(dto.EmailTemplateRestoreRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Restore(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Preview email template
+// @Description  Preview email template
+// @Tags         EmailTemplate
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.EmailTemplatePreviewRequest          true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.EmailTemplatePreviewResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /email-template/preview [post]
+func (h handler) Preview(c echo.Context) (err error) {
+	payload := new(dto.EmailTemplatePreviewRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Preview(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
@@ -0,0 +1,17 @@
+package emailtemplate
+
+import (
+	"daarul_mukhtarin/internal/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) Route(v *echo.Group) {
+	v.POST("", h.Create, middleware.Authentication)
+	v.GET("", h.Find, middleware.Authentication)
+	v.POST("/preview", h.Preview, middleware.Authentication)
+	v.GET("/:id", h.FindById, middleware.Authentication)
+	v.PUT("/:id", h.Update, middleware.Authentication)
+	v.DELETE("/:id", h.Delete, middleware.Authentication)
+	v.POST("/:id/restore/:version", h.Restore, middleware.Authentication)
+}
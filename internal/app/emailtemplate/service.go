@@ -0,0 +1,302 @@
+package emailtemplate
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"errors"
+	"net/http"
+
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Create(ctx *abstraction.Context, payload *dto.EmailTemplateCreateRequest) (*dto.MessageResponse, error)
+	Find(ctx *abstraction.Context) ([]*dto.EmailTemplateResponse, *response.Meta, error)
+	FindById(ctx *abstraction.Context, payload *dto.EmailTemplateFindByIDRequest) (*dto.EmailTemplateDetailResponse, error)
+	Update(ctx *abstraction.Context, payload *dto.EmailTemplateUpdateRequest) (*dto.MessageResponse, error)
+	Delete(ctx *abstraction.Context, payload *dto.EmailTemplateDeleteByIDRequest) (*dto.MessageResponse, error)
+	Restore(ctx *abstraction.Context, payload *dto.EmailTemplateRestoreRequest) (*dto.MessageResponse, error)
+	Preview(ctx *abstraction.Context, payload *dto.EmailTemplatePreviewRequest) (*dto.EmailTemplatePreviewResponse, error)
+}
+
+type service struct {
+	EmailTemplateRepository repository.EmailTemplate
+
+	DB *gorm.DB
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		EmailTemplateRepository: f.EmailTemplateRepository,
+
+		DB: f.Db,
+	}
+}
+
+func (s *service) Create(ctx *abstraction.Context, payload *dto.EmailTemplateCreateRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		if err := mailtemplate.Validate(payload.Name, payload.Language, payload.Subject, payload.BodyHtml); err != nil {
+			return response.ErrorBuilder(http.StatusBadRequest, err, "invalid email template")
+		}
+
+		templateData, err := s.EmailTemplateRepository.FindByNameLanguage(ctx, payload.Name, payload.Language, true)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if templateData != nil && !templateData.IsDelete {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email template already exist")
+		}
+
+		if templateData != nil {
+			// a deleted template keeps its history, saving it again adds a new version
+			return s.saveVersion(ctx, templateData, payload.Subject, payload.BodyHtml)
+		}
+
+		modelTemplate := &model.EmailTemplateEntityModel{
+			Context: ctx,
+			EmailTemplateEntity: model.EmailTemplateEntity{
+				Name:     payload.Name,
+				Language: payload.Language,
+				Subject:  payload.Subject,
+				BodyHtml: payload.BodyHtml,
+				Version:  1,
+				IsDelete: false,
+			},
+		}
+		if err = s.EmailTemplateRepository.Create(ctx, modelTemplate).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if err = s.EmailTemplateRepository.CreateVersion(ctx, &model.EmailTemplateVersionEntityModel{
+			Context: ctx,
+			EmailTemplateVersionEntity: model.EmailTemplateVersionEntity{
+				EmailTemplateId: modelTemplate.ID,
+				Version:         modelTemplate.Version,
+				Subject:         modelTemplate.Subject,
+				BodyHtml:        modelTemplate.BodyHtml,
+				CreatedBy:       ctx.Auth.ID,
+			},
+		}).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success create!"}, nil
+}
+
+func (s *service) Find(ctx *abstraction.Context) ([]*dto.EmailTemplateResponse, *response.Meta, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.EmailTemplateQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.EmailTemplateRepository.Find(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	count, err := s.EmailTemplateRepository.Count(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return dto.NewEmailTemplateResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
+	}, nil
+}
+
+func (s *service) FindById(ctx *abstraction.Context, payload *dto.EmailTemplateFindByIDRequest) (*dto.EmailTemplateDetailResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	data, err := s.EmailTemplateRepository.FindById(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if data == nil {
+		return nil, nil
+	}
+	versions, err := s.EmailTemplateRepository.FindVersions(ctx, data.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return &dto.EmailTemplateDetailResponse{
+		EmailTemplateResponse: *dto.NewEmailTemplateResponse(data),
+		BodyHtml:              data.BodyHtml,
+		Placeholders:          mailtemplate.Placeholders[data.Name],
+		Versions:              dto.NewEmailTemplateVersionResponses(versions),
+	}, nil
+}
+
+func (s *service) Update(ctx *abstraction.Context, payload *dto.EmailTemplateUpdateRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		templateData, err := s.EmailTemplateRepository.FindById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if templateData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email template not found")
+		}
+
+		subject, body := templateData.Subject, templateData.BodyHtml
+		if payload.Subject != nil {
+			subject = *payload.Subject
+		}
+		if payload.BodyHtml != nil {
+			body = *payload.BodyHtml
+		}
+		if err = mailtemplate.Validate(templateData.Name, templateData.Language, subject, body); err != nil {
+			return response.ErrorBuilder(http.StatusBadRequest, err, "invalid email template")
+		}
+
+		return s.saveVersion(ctx, templateData, subject, body)
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success update!"}, nil
+}
+
+func (s *service) Delete(ctx *abstraction.Context, payload *dto.EmailTemplateDeleteByIDRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		templateData, err := s.EmailTemplateRepository.FindById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if templateData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email template not found")
+		}
+
+		if err = s.EmailTemplateRepository.UpdateDelete(ctx, &templateData.ID, true).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success delete!"}, nil
+}
+
+func (s *service) Restore(ctx *abstraction.Context, payload *dto.EmailTemplateRestoreRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		templateData, err := s.EmailTemplateRepository.FindById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if templateData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email template not found")
+		}
+
+		versionData, err := s.EmailTemplateRepository.FindVersion(ctx, templateData.ID, payload.Version)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if versionData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email template version not found")
+		}
+		if err = mailtemplate.Validate(templateData.Name, templateData.Language, versionData.Subject, versionData.BodyHtml); err != nil {
+			return response.ErrorBuilder(http.StatusBadRequest, err, "invalid email template")
+		}
+
+		return s.saveVersion(ctx, templateData, versionData.Subject, versionData.BodyHtml)
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success restore!"}, nil
+}
+
+func (s *service) Preview(ctx *abstraction.Context, payload *dto.EmailTemplatePreviewRequest) (*dto.EmailTemplatePreviewResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+
+	lang := mailtemplate.Language(payload.Language)
+	subject, body := payload.Subject, payload.BodyHtml
+	if subject == "" || body == "" {
+		defaultSubject, defaultBody, err := mailtemplate.Default(payload.Name, lang)
+		if err != nil {
+			return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid email template")
+		}
+		templateData, err := s.EmailTemplateRepository.FindByNameLanguage(ctx, payload.Name, lang, false)
+		if err != nil && err.Error() != "record not found" {
+			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if templateData != nil {
+			isSeeded, err := seeded(ctx, s.EmailTemplateRepository, templateData)
+			if err != nil {
+				return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+			}
+			if !isSeeded {
+				defaultSubject, defaultBody = templateData.Subject, templateData.BodyHtml
+			}
+		}
+		if subject == "" {
+			subject = defaultSubject
+		}
+		if body == "" {
+			body = defaultBody
+		}
+	}
+
+	renderedSubject, renderedBody, err := mailtemplate.Preview(payload.Name, lang, subject, body, payload.Data)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid email template")
+	}
+	return &dto.EmailTemplatePreviewResponse{
+		Subject:      renderedSubject,
+		BodyHtml:     renderedBody,
+		Placeholders: mailtemplate.Placeholders[payload.Name],
+	}, nil
+}
+
+// saveVersion stores subject and body as the next version of templateData.
+func (s *service) saveVersion(ctx *abstraction.Context, templateData *model.EmailTemplateEntityModel, subject string, body string) error {
+	newTemplateData := new(model.EmailTemplateEntityModel)
+	newTemplateData.Context = ctx
+	newTemplateData.ID = templateData.ID
+	newTemplateData.Subject = subject
+	newTemplateData.BodyHtml = body
+	newTemplateData.Version = templateData.Version + 1
+
+	if err := s.EmailTemplateRepository.UpdateContent(ctx, newTemplateData).Error; err != nil {
+		return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if err := s.EmailTemplateRepository.CreateVersion(ctx, &model.EmailTemplateVersionEntityModel{
+		Context: ctx,
+		EmailTemplateVersionEntity: model.EmailTemplateVersionEntity{
+			EmailTemplateId: templateData.ID,
+			Version:         newTemplateData.Version,
+			Subject:         subject,
+			BodyHtml:        body,
+			CreatedBy:       ctx.Auth.ID,
+		},
+	}).Error; err != nil {
+		return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package emailtemplate
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// store serves templates edited by admins to pkg/mailtemplate.
+type store struct {
+	EmailTemplateRepository repository.EmailTemplate
+}
+
+func NewStore(f *factory.Factory) mailtemplate.Store {
+	return &store{
+		EmailTemplateRepository: f.EmailTemplateRepository,
+	}
+}
+
+// Find returns the template saved by an admin. A seeded template nobody edited is skipped, the
+// default of the binary or of MAIL_TEMPLATE_DIR is used instead so its fixes are not masked.
+func (s *store) Find(name, lang string) (*mailtemplate.Override, error) {
+	ctx := &abstraction.Context{}
+	data, err := s.EmailTemplateRepository.FindByNameLanguage(ctx, name, lang, false)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	isSeeded, err := seeded(ctx, s.EmailTemplateRepository, data)
+	if err != nil {
+		return nil, err
+	}
+	if isSeeded {
+		return nil, nil
+	}
+	return &mailtemplate.Override{
+		Subject: data.Subject,
+		Body:    data.BodyHtml,
+		Version: data.Version,
+	}, nil
+}
+
+// Seed stores the embedded templates as version 1 for every template that has never been saved,
+// templates deleted by an admin are left alone. The seeded rows only give admins something to
+// edit, mails keep using the defaults until a template is saved again.
+func Seed(f *factory.Factory) error {
+	return seed(f.EmailTemplateRepository, f.Db)
+}
+
+func seed(emailTemplateRepository repository.EmailTemplate, db *gorm.DB) error {
+	return trxmanager.New(db).WithTrx(&abstraction.Context{}, func(ctx *abstraction.Context) error {
+		for _, lang := range mailtemplate.Languages {
+			for _, name := range mailtemplate.Templates {
+				templateData, err := emailTemplateRepository.FindByNameLanguage(ctx, name, lang, true)
+				if err != nil && err.Error() != "record not found" {
+					return err
+				}
+				if templateData != nil {
+					continue
+				}
+
+				subject, body, err := mailtemplate.Default(name, lang)
+				if err != nil {
+					return err
+				}
+				modelTemplate := &model.EmailTemplateEntityModel{
+					Context: ctx,
+					EmailTemplateEntity: model.EmailTemplateEntity{
+						Name:     name,
+						Language: lang,
+						Subject:  subject,
+						BodyHtml: body,
+						Version:  1,
+						IsDelete: false,
+					},
+				}
+				if err = emailTemplateRepository.Create(ctx, modelTemplate).Error; err != nil {
+					return err
+				}
+				if err = emailTemplateRepository.CreateVersion(ctx, &model.EmailTemplateVersionEntityModel{
+					Context: ctx,
+					EmailTemplateVersionEntity: model.EmailTemplateVersionEntity{
+						EmailTemplateId: modelTemplate.ID,
+						Version:         1,
+						Subject:         subject,
+						BodyHtml:        body,
+					},
+				}).Error; err != nil {
+					return err
+				}
+				logrus.Infof("Seeded email template %s/%s", lang, name)
+			}
+		}
+		return nil
+	})
+}
+
+// seeded reports whether templateData is still the copy stored by Seed: its only version is the
+// first one and nobody created it.
+func seeded(ctx *abstraction.Context, emailTemplateRepository repository.EmailTemplate, templateData *model.EmailTemplateEntityModel) (bool, error) {
+	if templateData.Version != 1 {
+		return false, nil
+	}
+	versionData, err := emailTemplateRepository.FindVersion(ctx, templateData.ID, 1)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return false, nil
+		}
+		return false, err
+	}
+	return versionData.CreatedBy == 0, nil
+}
@@ -0,0 +1,79 @@
+package emailtemplate
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/migrate"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := migrations.Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.NewMigrator(db, list).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestStoreSkipsSeeded(t *testing.T) {
+	if err := mailtemplate.Init(); err != nil {
+		t.Fatal(err)
+	}
+	db := newDB(t)
+	emailTemplateRepository := repository.NewEmailTemplate(db)
+	if err := seed(emailTemplateRepository, db); err != nil {
+		t.Fatalf("seed() error = %v", err)
+	}
+	s := &store{EmailTemplateRepository: emailTemplateRepository}
+	name, lang := mailtemplate.TEMPLATE_CREATE_USER, mailtemplate.LANGUAGE_EN
+
+	override, err := s.Find(name, lang)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if override != nil {
+		t.Fatalf("Find() = %+v for a seeded template, want nil", override)
+	}
+
+	templateData, err := emailTemplateRepository.FindByNameLanguage(&abstraction.Context{}, name, lang, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject := "Welcome {{.NAME}}"
+	ctx := &abstraction.Context{Auth: &abstraction.AuthContext{ID: 1, RoleID: constant.ROLE_ID_ADMIN}}
+	if _, err = (&service{EmailTemplateRepository: emailTemplateRepository, DB: db}).Update(ctx, &dto.EmailTemplateUpdateRequest{
+		ID:      templateData.ID,
+		Subject: &subject,
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	override, err = s.Find(name, lang)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if override == nil || override.Subject != subject || override.Version != 2 {
+		t.Fatalf("Find() = %+v, want the edited subject as version 2", override)
+	}
+}
@@ -0,0 +1,55 @@
+package notifikasi
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"net/http"
+)
+
+var exportColumns = []export.Column{
+	{Key: "id", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "ID", mailtemplate.LANGUAGE_EN: "ID"}},
+	{Key: "title", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Judul", mailtemplate.LANGUAGE_EN: "Title"}},
+	{Key: "message", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Pesan", mailtemplate.LANGUAGE_EN: "Message"}},
+	{Key: "is_read", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Dibaca", mailtemplate.LANGUAGE_EN: "Read"}},
+	{Key: "link", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Tautan", mailtemplate.LANGUAGE_EN: "Link"}},
+	{Key: "created_at", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Tanggal Dibuat", mailtemplate.LANGUAGE_EN: "Created At"}},
+}
+
+// Export writes the caller's notifikasi matching the same filters as Find, without paging.
+func (s *service) Export(ctx *abstraction.Context, format string) (*export.Export, error) {
+	q, err := query.Parse(ctx.QueryParams(), repository.NotifikasiQuery)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	if q.IsCursor() {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, &query.Error{Param: "cursor", Message: "can not be used with an export"}, "invalid query param")
+	}
+	columns, err := export.Columns(exportColumns, ctx.QueryParam("columns"))
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+
+	return &export.Export{
+		Name:     "notifikasi",
+		Format:   format,
+		Language: mailtemplate.Language(ctx.QueryParam("lang") + "," + ctx.Request().Header.Get("Accept-Language")),
+		Columns:  columns,
+		Rows: func(row func(map[string]interface{}) error) error {
+			return s.NotifikasiRepository.ExportByUserId(ctx, &ctx.Auth.ID, q, func(v *model.NotifikasiEntityModel) error {
+				return row(map[string]interface{}{
+					"id":         v.ID,
+					"title":      v.Title,
+					"message":    v.Message,
+					"is_read":    v.IsRead,
+					"link":       v.Link,
+					"created_at": v.CreatedAt,
+				})
+			})
+		},
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/dto"
 	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/pkg/export"
 	"daarul_mukhtarin/pkg/util/response"
 	"net/http"
 
@@ -20,14 +21,58 @@ func NewHandler(f *factory.Factory) *handler {
 	}
 }
 
+// @Summary      List notifikasi
+// @Description  List notifikasi
+// @Tags         Notifikasi
+// @Produce      json
+// @Security     BearerAuth
+// @Param        search    query     string                                   false "search term"
+// @Param        page      query     int                                      false "page, starts at 1"
+// @Param        page_size query     int                                      false "page size"
+// @Param        order_by  query     string                                   false "asc or desc"
+// @Param        order     query     string                                   false "column to order by"
+// @Param        cursor    query     string                                   false "cursor from meta.pagination.next, instead of page, meta.summary is then left out"
+// @Param        format    query     string                                   false "csv or xlsx to download the list instead"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.NotifikasiResponse,meta=response.Meta{summary=dto.NotifikasiSummaryResponse}}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /notifikasi [get]
 func (h handler) Find(c echo.Context) (err error) {
-	data, err := h.service.Find(c.(*abstraction.Context))
+	format, err := export.Format(c.Request())
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param").SendError(c)
+	}
+	if format != "" {
+		file, err := h.service.Export(c.(*abstraction.Context), format)
+		if err != nil {
+			return response.ErrorResponse(err).SendError(c)
+		}
+		return file.Send(c)
+	}
+
+	data, meta, err := h.service.Find(c.(*abstraction.Context))
 	if err != nil {
 		return response.ErrorResponse(err).SendError(c)
 	}
-	return response.SuccessResponse(data).SendSuccess(c)
+	return response.ListResponse(data, meta).SendSuccess(c)
 }
 
+// @Summary      Mark notifikasi as read
+// @Description  Mark notifikasi as read
+// @Tags         Notifikasi
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /notifikasi/set-read/{id} [put]
 func (h handler) SetRead(c echo.Context) (err error) {
 	payload := new(dto.NotifikasiSetReadRequest)
 	if err = c.Bind(payload); err != nil {
@@ -6,6 +6,8 @@ import (
 	"daarul_mukhtarin/internal/factory"
 	"daarul_mukhtarin/internal/model"
 	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/util/query"
 	"daarul_mukhtarin/pkg/util/response"
 	"daarul_mukhtarin/pkg/util/trxmanager"
 	"errors"
@@ -15,8 +17,9 @@ import (
 )
 
 type Service interface {
-	Find(ctx *abstraction.Context) (map[string]interface{}, error)
-	SetRead(ctx *abstraction.Context, payload *dto.NotifikasiSetReadRequest) (map[string]interface{}, error)
+	Find(ctx *abstraction.Context) ([]*dto.NotifikasiResponse, *response.Meta, error)
+	Export(ctx *abstraction.Context, format string) (*export.Export, error)
+	SetRead(ctx *abstraction.Context, payload *dto.NotifikasiSetReadRequest) (*dto.MessageResponse, error)
 }
 
 type service struct {
@@ -33,37 +36,42 @@ func NewService(f *factory.Factory) Service {
 	}
 }
 
-func (s *service) Find(ctx *abstraction.Context) (map[string]interface{}, error) {
-	var res []map[string]interface{}
-	data, err := s.NotifikasiRepository.FindByUserId(ctx, &ctx.Auth.ID)
-	if err != nil && err.Error() != "record not found" {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+func (s *service) Find(ctx *abstraction.Context) ([]*dto.NotifikasiResponse, *response.Meta, error) {
+	q, err := query.Parse(ctx.QueryParams(), repository.NotifikasiQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
 	}
-	countTotal, countRead, countUnread, err := s.NotifikasiRepository.CountByUserId(ctx, &ctx.Auth.ID)
+	data, err := s.NotifikasiRepository.FindByUserId(ctx, &ctx.Auth.ID, q)
 	if err != nil && err.Error() != "record not found" {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
-	for _, v := range data {
-		res = append(res, map[string]interface{}{
-			"id":         v.ID,
-			"title":      v.Title,
-			"message":    v.Message,
-			"is_read":    v.IsRead,
-			"user_id":    v.UserId,
-			"link":       v.Link,
-			"created_at": v.CreatedAt,
-			"updated_at": v.UpdatedAt,
-		})
+
+	meta := &response.Meta{}
+	if q.IsCursor() {
+		// the counts cost the scan the cursor avoids, so the summary is left out
+		n, more := q.Trim(len(data))
+		data = data[:n]
+		var last int64
+		if n > 0 {
+			last = int64(data[n-1].ID)
+		}
+		meta.Pagination = q.CursorPagination(ctx.Request().URL, more, last)
+	} else {
+		countTotal, countRead, countUnread, err := s.NotifikasiRepository.CountByUserId(ctx, &ctx.Auth.ID, q)
+		if err != nil && err.Error() != "record not found" {
+			return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		meta.Pagination = q.Pagination(ctx.Request().URL, *countTotal)
+		meta.Summary = &dto.NotifikasiSummaryResponse{
+			CountTotal:  *countTotal,
+			CountRead:   *countRead,
+			CountUnread: *countUnread,
+		}
 	}
-	return map[string]interface{}{
-		"count_total":  countTotal,
-		"count_read":   countRead,
-		"count_unread": countUnread,
-		"data":         res,
-	}, nil
+	return dto.NewNotifikasiResponses(data), meta, nil
 }
 
-func (s *service) SetRead(ctx *abstraction.Context, payload *dto.NotifikasiSetReadRequest) (map[string]interface{}, error) {
+func (s *service) SetRead(ctx *abstraction.Context, payload *dto.NotifikasiSetReadRequest) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		notifikasiData, err := s.NotifikasiRepository.FindById(ctx, payload.ID)
 		if err != nil && err.Error() != "record not found" {
@@ -85,7 +93,5 @@ func (s *service) SetRead(ctx *abstraction.Context, payload *dto.NotifikasiSetRe
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success set read!",
-	}, nil
+	return &dto.MessageResponse{Message: "success set read!"}, nil
 }
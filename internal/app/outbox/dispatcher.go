@@ -0,0 +1,212 @@
+package outbox
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/config"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInterval    = 10 * time.Second
+	defaultBatchSize   = 20
+	defaultMaxAttempts = 8
+
+	// claimLease keeps a claimed message away from other dispatchers while it is being sent.
+	claimLease = 5 * time.Minute
+
+	backoffBase = 30 * time.Second
+	backoffMax  = 1 * time.Hour
+
+	defaultRetentionDays = 30
+	purgeInterval        = 1 * time.Hour
+	purgeBatchSize       = 500
+)
+
+type Dispatcher struct {
+	EmailOutboxRepository repository.EmailOutbox
+
+	DB     *gorm.DB
+	Mailer gomail.Mailer
+
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	retention   time.Duration
+}
+
+func NewDispatcher(f *factory.Factory) *Dispatcher {
+	d := &Dispatcher{
+		EmailOutboxRepository: f.EmailOutboxRepository,
+
+		DB:     f.Db,
+		Mailer: f.Mailer,
+
+		interval:    defaultInterval,
+		batchSize:   defaultBatchSize,
+		maxAttempts: defaultMaxAttempts,
+		retention:   defaultRetentionDays * 24 * time.Hour,
+	}
+	if interval, err := time.ParseDuration(config.Get().Outbox.Interval); err == nil && interval > 0 {
+		d.interval = interval
+	}
+	if batchSize, _ := strconv.Atoi(config.Get().Outbox.BatchSize); batchSize > 0 {
+		d.batchSize = batchSize
+	}
+	if maxAttempts, _ := strconv.Atoi(config.Get().Outbox.MaxAttempts); maxAttempts > 0 {
+		d.maxAttempts = maxAttempts
+	}
+	if days, _ := strconv.Atoi(config.Get().Outbox.RetentionDays); days > 0 {
+		d.retention = time.Duration(days) * 24 * time.Hour
+	}
+	return d
+}
+
+// Start polls the outbox until ctx is cancelled, and hourly purges the messages that finished
+// longer than the retention ago, OUTBOX_RETENTION_DAYS.
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(purgeInterval)
+	defer purgeTicker.Stop()
+
+	logrus.Info("Email outbox dispatcher started")
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Email outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+			if err := d.Dispatch(); err != nil {
+				logrus.Error("Error dispatching email outbox: ", err.Error())
+			}
+		case <-purgeTicker.C:
+			if err := d.Purge(); err != nil {
+				logrus.Error("Error purging email outbox: ", err.Error())
+			}
+		}
+	}
+}
+
+// Purge deletes the sent and dead messages last changed before the retention.
+func (d *Dispatcher) Purge() error {
+	ctx := &abstraction.Context{}
+	before := time.Now().Add(-d.retention)
+	for {
+		ids, err := d.EmailOutboxRepository.FindFinishedIds(ctx, before, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err = d.EmailOutboxRepository.DeleteByIds(ctx, ids).Error; err != nil {
+			return err
+		}
+		if len(ids) < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+// Dispatch sends one batch of due messages.
+func (d *Dispatcher) Dispatch() error {
+	messages, err := d.claim()
+	if err != nil {
+		return err
+	}
+
+	ctx := &abstraction.Context{}
+	for _, v := range messages {
+		now := time.Now()
+		attempts := v.Attempts + 1
+
+		message, err := toMessage(v)
+		if err == nil {
+			err = d.Mailer.Send(message)
+		}
+		if err == nil {
+			if err = d.EmailOutboxRepository.UpdateSent(ctx, v.ID, attempts, now).Error; err != nil {
+				logrus.Error("Error updating email outbox: ", err.Error())
+			}
+			continue
+		}
+
+		status := constant.OUTBOX_STATUS_PENDING
+		if attempts >= d.maxAttempts {
+			status = constant.OUTBOX_STATUS_DEAD
+			logrus.Errorf("Email outbox %d moved to dead letter after %d attempts: %s", v.ID, attempts, err.Error())
+		}
+		if err = d.EmailOutboxRepository.UpdateFailed(ctx, v.ID, status, attempts, err.Error(), now.Add(backoff(attempts))).Error; err != nil {
+			logrus.Error("Error updating email outbox: ", err.Error())
+		}
+	}
+	return nil
+}
+
+// claim picks due messages and pushes their next attempt forward in a short transaction,
+// so sending never happens while a database transaction is open.
+func (d *Dispatcher) claim() (messages []*model.EmailOutboxEntityModel, err error) {
+	ctx := &abstraction.Context{}
+	err = trxmanager.New(d.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		now := time.Now()
+		messages, err = d.EmailOutboxRepository.FindDueForUpdate(ctx, now, d.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, v := range messages {
+			if err = d.EmailOutboxRepository.UpdateNextAttempt(ctx, v.ID, now.Add(claimLease)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func toMessage(data *model.EmailOutboxEntityModel) (*gomail.Message, error) {
+	message := gomail.NewMessage(data.Subject, data.BodyHtml, splitAddress(data.Recipient)...).
+		AddCc(splitAddress(data.Cc)...).
+		AddBcc(splitAddress(data.Bcc)...).
+		SetReplyTo(data.ReplyTo).
+		SetText(data.BodyText)
+	if data.FromName != "" || data.FromAddress != "" {
+		message.SetFrom(data.FromName, data.FromAddress)
+	}
+	if data.Attachments != "" {
+		if err := json.Unmarshal([]byte(data.Attachments), &message.Attachments); err != nil {
+			return nil, err
+		}
+	}
+	return message, nil
+}
+
+func splitAddress(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func backoff(attempts int) time.Duration {
+	wait := backoffBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= backoffMax {
+			return backoffMax
+		}
+	}
+	return wait
+}
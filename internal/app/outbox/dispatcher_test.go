@@ -0,0 +1,203 @@
+package outbox
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/migrate"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type failingMailer struct{}
+
+func (failingMailer) Send(message *gomail.Message) error {
+	return errors.New("smtp is down")
+}
+
+func newDispatcher(t *testing.T, mailer gomail.Mailer) *Dispatcher {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := migrations.Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.NewMigrator(db, list).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return &Dispatcher{
+		EmailOutboxRepository: repository.NewEmailOutbox(db),
+
+		DB:     db,
+		Mailer: mailer,
+
+		interval:    defaultInterval,
+		batchSize:   defaultBatchSize,
+		maxAttempts: 2,
+		retention:   defaultRetentionDays * 24 * time.Hour,
+	}
+}
+
+func enqueue(t *testing.T, d *Dispatcher) *model.EmailOutboxEntityModel {
+	t.Helper()
+	ctx := &abstraction.Context{}
+	message := gomail.NewMessage("Akun baru", "<p>password: rahasia</p>", "ahmad@example.com").
+		SetText("password: rahasia").
+		Attach("kartu.txt", "text/plain", []byte("rahasia"))
+	if err := d.EmailOutboxRepository.Enqueue(ctx, message); err != nil {
+		t.Fatal(err)
+	}
+	var data model.EmailOutboxEntityModel
+	if err := d.DB.Order("id DESC").First(&data).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &data
+}
+
+func reload(t *testing.T, d *Dispatcher, id int) *model.EmailOutboxEntityModel {
+	t.Helper()
+	data, err := d.EmailOutboxRepository.FindById(&abstraction.Context{}, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: 1 * time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 7, want: 32 * time.Minute},
+		{attempts: 8, want: backoffMax},
+		{attempts: 50, want: backoffMax},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchSent(t *testing.T) {
+	mailer := gomail.NewMemoryMailer()
+	d := newDispatcher(t, mailer)
+	queued := enqueue(t, d)
+
+	if err := d.Dispatch(); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	messages := mailer.Messages()
+	if len(messages) != 1 || messages[0].BodyText != "password: rahasia" || len(messages[0].Attachments) != 1 {
+		t.Fatalf("sent %+v, want the queued message", messages)
+	}
+
+	data := reload(t, d, queued.ID)
+	if data.Status != constant.OUTBOX_STATUS_SENT || data.Attempts != 1 || data.SentAt == nil {
+		t.Errorf("outbox = %s after %d attempts, want sent after 1", data.Status, data.Attempts)
+	}
+	if data.BodyHtml != "" || data.BodyText != "" || data.Attachments != "" {
+		t.Errorf("a sent message kept its content: %q %q %q", data.BodyHtml, data.BodyText, data.Attachments)
+	}
+	if data.Recipient != "ahmad@example.com" || data.Subject != "Akun baru" {
+		t.Errorf("a sent message lost its envelope: %q %q", data.Recipient, data.Subject)
+	}
+
+	if err := d.Dispatch(); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if len(mailer.Messages()) != 1 {
+		t.Errorf("a sent message was sent again")
+	}
+}
+
+func TestDispatchRetry(t *testing.T) {
+	d := newDispatcher(t, failingMailer{})
+	queued := enqueue(t, d)
+
+	start := time.Now()
+	if err := d.Dispatch(); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	data := reload(t, d, queued.ID)
+	if data.Status != constant.OUTBOX_STATUS_PENDING || data.Attempts != 1 || data.LastError != "smtp is down" {
+		t.Fatalf("outbox = %s after %d attempts (%s), want pending after 1", data.Status, data.Attempts, data.LastError)
+	}
+	if wait := data.NextAttemptAt.Sub(start); wait < backoffBase-time.Second || wait > backoffBase+time.Minute {
+		t.Errorf("next attempt in %v, want about %v", wait, backoffBase)
+	}
+
+	// not due yet, nothing is attempted
+	if err := d.Dispatch(); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if data = reload(t, d, queued.ID); data.Attempts != 1 {
+		t.Fatalf("attempted %d times before the backoff passed", data.Attempts)
+	}
+
+	d.DB.Model(&model.EmailOutboxEntityModel{}).Where("id = ?", queued.ID).Update("next_attempt_at", time.Now().Add(-time.Second))
+	if err := d.Dispatch(); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	data = reload(t, d, queued.ID)
+	if data.Status != constant.OUTBOX_STATUS_DEAD || data.Attempts != 2 {
+		t.Errorf("outbox = %s after %d attempts, want dead after 2", data.Status, data.Attempts)
+	}
+	if data.BodyHtml == "" {
+		t.Error("a dead message lost its content, it can not be resent")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	d := newDispatcher(t, gomail.NewMemoryMailer())
+	old := time.Now().Add(-d.retention - time.Hour)
+	rows := []struct {
+		status  string
+		updated time.Time
+		kept    bool
+	}{
+		{status: constant.OUTBOX_STATUS_SENT, updated: old, kept: false},
+		{status: constant.OUTBOX_STATUS_DEAD, updated: old, kept: false},
+		{status: constant.OUTBOX_STATUS_PENDING, updated: old, kept: true},
+		{status: constant.OUTBOX_STATUS_SENT, updated: time.Now(), kept: true},
+		{status: constant.OUTBOX_STATUS_DEAD, updated: time.Now(), kept: true},
+	}
+	var ids []int
+	for _, row := range rows {
+		data := enqueue(t, d)
+		d.DB.Model(&model.EmailOutboxEntityModel{}).Where("id = ?", data.ID).Updates(map[string]interface{}{
+			"status":     row.status,
+			"updated_at": row.updated,
+		})
+		ids = append(ids, data.ID)
+	}
+
+	if err := d.Purge(); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	for i, row := range rows {
+		_, err := d.EmailOutboxRepository.FindById(&abstraction.Context{}, ids[i])
+		if kept := err == nil; kept != row.kept {
+			t.Errorf("%s message changed at %v kept = %v, want %v", row.status, row.updated, kept, row.kept)
+		}
+	}
+}
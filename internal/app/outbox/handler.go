@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/pkg/util/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service: NewService(f),
+	}
+}
+
+// @Summary      List email outbox
+// @Description  List email outbox
+// @Tags         Outbox
+// @Produce      json
+// @Security     BearerAuth
+// @Param        status    query     string                                   false "pending, sent or dead"
+// @Param        search    query     string                                   false "search term"
+// @Param        page      query     int                                      false "page, starts at 1"
+// @Param        page_size query     int                                      false "page size"
+// @Param        order_by  query     string                                   false "asc or desc"
+// @Param        order     query     string                                   false "column to order by"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.EmailOutboxResponse,meta=response.Meta}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /outbox [get]
+func (h handler) Find(c echo.Context) (err error) {
+	payload := new(dto.EmailOutboxFindRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, meta, err := h.service.Find(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.ListResponse(data, meta).SendSuccess(c)
+}
+
+// @Summary      Resend email
+// @Description  Resend email
+// @Tags         Outbox
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /outbox/resend/{id} [post]
+func (h handler) Resend(c echo.Context) (err error) {
+	payload := new(dto.EmailOutboxResendRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Resend(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
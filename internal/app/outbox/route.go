@@ -0,0 +1,12 @@
+package outbox
+
+import (
+	"daarul_mukhtarin/internal/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) Route(v *echo.Group) {
+	v.GET("", h.Find, middleware.Authentication)
+	v.POST("/resend/:id", h.Resend, middleware.Authentication)
+}
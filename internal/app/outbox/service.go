@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Find(ctx *abstraction.Context, payload *dto.EmailOutboxFindRequest) ([]*dto.EmailOutboxResponse, *response.Meta, error)
+	Resend(ctx *abstraction.Context, payload *dto.EmailOutboxResendRequest) (*dto.MessageResponse, error)
+}
+
+type service struct {
+	EmailOutboxRepository repository.EmailOutbox
+
+	DB *gorm.DB
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		EmailOutboxRepository: f.EmailOutboxRepository,
+
+		DB: f.Db,
+	}
+}
+
+func (s *service) Find(ctx *abstraction.Context, payload *dto.EmailOutboxFindRequest) ([]*dto.EmailOutboxResponse, *response.Meta, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.EmailOutboxQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.EmailOutboxRepository.Find(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	meta := &response.Meta{}
+	if q.IsCursor() {
+		// counting a large outbox is as slow as the offset scan the cursor avoids, so it is skipped
+		n, more := q.Trim(len(data))
+		data = data[:n]
+		var last int64
+		if n > 0 {
+			last = int64(data[n-1].ID)
+		}
+		meta.Pagination = q.CursorPagination(ctx.Request().URL, more, last)
+	} else {
+		count, err := s.EmailOutboxRepository.Count(ctx, q)
+		if err != nil && err.Error() != "record not found" {
+			return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		meta.Pagination = q.Pagination(ctx.Request().URL, *count)
+	}
+	return dto.NewEmailOutboxResponses(data), meta, nil
+}
+
+func (s *service) Resend(ctx *abstraction.Context, payload *dto.EmailOutboxResendRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		outboxData, err := s.EmailOutboxRepository.FindById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if outboxData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email not found")
+		}
+		if outboxData.Status == constant.OUTBOX_STATUS_SENT {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email already sent")
+		}
+
+		if err = s.EmailOutboxRepository.UpdateResend(ctx, outboxData.ID, time.Now()).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &dto.MessageResponse{Message: "success resend!"}, nil
+}
@@ -8,5 +8,6 @@ import (
 
 func (h *handler) Route(v *echo.Group) {
 	v.GET("", h.Find, middleware.Authentication)
+	v.GET("/:id", h.FindById, middleware.Authentication)
 	v.PUT("/:id", h.Update, middleware.Authentication)
 }
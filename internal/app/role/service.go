@@ -7,6 +7,10 @@ import (
 	"daarul_mukhtarin/internal/model"
 	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/util/etag"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
 	"daarul_mukhtarin/pkg/util/response"
 	"daarul_mukhtarin/pkg/util/trxmanager"
 	"errors"
@@ -16,8 +20,10 @@ import (
 )
 
 type Service interface {
-	Find(ctx *abstraction.Context) (map[string]interface{}, error)
-	Update(ctx *abstraction.Context, payload *dto.RoleUpdateRequest) (map[string]interface{}, error)
+	Find(ctx *abstraction.Context) ([]*dto.RoleResponse, *response.Meta, error)
+	FindById(ctx *abstraction.Context, payload *dto.RoleFindByIDRequest) (*dto.RoleResponse, error)
+	Export(ctx *abstraction.Context, format string) (*export.Export, error)
+	Update(ctx *abstraction.Context, payload *dto.RoleUpdateRequest) (*dto.MessageResponse, error)
 }
 
 type service struct {
@@ -34,33 +40,53 @@ func NewService(f *factory.Factory) Service {
 	}
 }
 
-func (s *service) Find(ctx *abstraction.Context) (map[string]interface{}, error) {
+func (s *service) Find(ctx *abstraction.Context) ([]*dto.RoleResponse, *response.Meta, error) {
 	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
-		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.RoleQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	sel, err := fields.Parse(ctx.QueryParams(), repository.RoleFields)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
 	}
-	data, err := s.RoleRepository.Find(ctx)
+	data, err := s.RoleRepository.Find(ctx, q, sel)
 	if err != nil && err.Error() != "record not found" {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	count, err := s.RoleRepository.Count(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	fields.Set(ctx, sel)
+	return dto.NewRoleResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
+	}, nil
+}
+
+func (s *service) FindById(ctx *abstraction.Context, payload *dto.RoleFindByIDRequest) (*dto.RoleResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
 	}
-	count, err := s.RoleRepository.Count(ctx)
+	sel, err := fields.Parse(ctx.QueryParams(), repository.RoleFields)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.RoleRepository.FindByIdSelect(ctx, payload.ID, sel)
 	if err != nil && err.Error() != "record not found" {
 		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
-	var res []map[string]interface{}
-	for _, v := range data {
-		res = append(res, map[string]interface{}{
-			"id":        v.ID,
-			"name":      v.Name,
-			"is_delete": v.IsDelete,
-		})
+	if data != nil {
+		etag.Set(ctx, data.Version)
 	}
-	return map[string]interface{}{
-		"count": count,
-		"data":  res,
-	}, nil
+	fields.Set(ctx, sel)
+	return dto.NewRoleResponse(data), nil
 }
 
-func (s *service) Update(ctx *abstraction.Context, payload *dto.RoleUpdateRequest) (map[string]interface{}, error) {
+func (s *service) Update(ctx *abstraction.Context, payload *dto.RoleUpdateRequest) (*dto.MessageResponse, error) {
+	var version int
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
@@ -73,22 +99,30 @@ func (s *service) Update(ctx *abstraction.Context, payload *dto.RoleUpdateReques
 		if roleData == nil {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "role not found")
 		}
+		if !etag.IfMatch(ctx.Request(), roleData.Version) {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "role was changed since it was read")
+		}
 
 		newRoleData := new(model.RoleEntityModel)
 		newRoleData.Context = ctx
 		newRoleData.ID = payload.ID
+		newRoleData.Version = roleData.Version
 		if payload.Name != nil {
 			newRoleData.Name = *payload.Name
 		}
 
-		if err = s.RoleRepository.Update(ctx, newRoleData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		result := s.RoleRepository.Update(ctx, newRoleData)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
 		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "role was changed since it was read")
+		}
+		version = newRoleData.Version
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success update!",
-	}, nil
+	etag.Set(ctx, version)
+	return &dto.MessageResponse{Message: "success update!"}, nil
 }
@@ -0,0 +1,50 @@
+package search
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/pkg/util/response"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type handler struct {
+	service Service
+}
+
+func NewHandler(f *factory.Factory) *handler {
+	return &handler{
+		service: NewService(f),
+	}
+}
+
+// @Summary      Search
+// @Description  Search
+// @Tags         Search
+// @Produce      json
+// @Security     BearerAuth
+// @Param        q         query     string                                   true  "search term"
+// @Param        type      query     string                                   false "user, divisi or dokumen"
+// @Param        limit     query     int                                      false "max results"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.SearchResultResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /search [get]
+func (h handler) Search(c echo.Context) (err error) {
+	payload := new(dto.SearchRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Search(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.ListResponse(data, nil).SendSuccess(c)
+}
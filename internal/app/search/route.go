@@ -0,0 +1,11 @@
+package search
+
+import (
+	"daarul_mukhtarin/internal/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) Route(v *echo.Group) {
+	v.GET("", h.Search, middleware.Authentication)
+}
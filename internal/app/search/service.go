@@ -0,0 +1,117 @@
+package search
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/util/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const defaultLimit = 20
+
+type Service interface {
+	Search(ctx *abstraction.Context, payload *dto.SearchRequest) ([]*dto.SearchResultResponse, error)
+}
+
+type service struct {
+	SearchRepository repository.Search
+}
+
+func NewService(f *factory.Factory) Service {
+	return &service{
+		SearchRepository: f.SearchRepository,
+	}
+}
+
+// Search ranks users, divisi, documents and folders together. Everyone but admin only finds what
+// belongs to their own divisi.
+func (s *service) Search(ctx *abstraction.Context, payload *dto.SearchRequest) ([]*dto.SearchResultResponse, error) {
+	terms := repository.SearchTerms(payload.Q)
+	if terms == "" {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "q has no searchable words")
+	}
+
+	sources, err := sources(payload.Type)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+
+	limit := defaultLimit
+	if payload.Limit > 0 {
+		limit = payload.Limit
+	}
+
+	var divisiId *int
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		divisiId = &ctx.Auth.DivisiID
+	}
+
+	var (
+		results []*model.SearchResultModel
+		paths   = map[string]string{}
+	)
+	for _, source := range sources {
+		data, err := s.SearchRepository.Search(ctx, source, terms, divisiId, limit)
+		if err != nil {
+			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		results = append(results, data...)
+		paths[source.Type] = source.Path
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	res := make([]*dto.SearchResultResponse, 0, len(results))
+	for _, v := range results {
+		id := v.ID
+		if v.Type == "folder" {
+			// folders are listed per divisi, not on their own
+			id = v.DivisiId
+		}
+		res = append(res, &dto.SearchResultResponse{
+			Type:     v.Type,
+			ID:       v.ID,
+			Title:    v.Title,
+			Subtitle: v.Subtitle,
+			DivisiId: v.DivisiId,
+			Score:    v.Score,
+			Url:      constant.BASE_URL + fmt.Sprintf(paths[v.Type], id),
+		})
+	}
+	return res, nil
+}
+
+// sources picks the sources named in types, a comma separated list, or all of them when empty.
+func sources(types string) ([]repository.SearchSource, error) {
+	if types == "" {
+		return repository.SearchSources, nil
+	}
+	var res []repository.SearchSource
+	for _, t := range strings.Split(types, ",") {
+		t = strings.TrimSpace(t)
+		found := false
+		for _, source := range repository.SearchSources {
+			if source.Type == t {
+				res = append(res, source)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+	}
+	return res, nil
+}
@@ -26,14 +26,11 @@ func NewHandler(f *factory.Factory) *handler {
 // @Summary      Test
 // @Description  Test
 // @Tags         Test
-// @Accept       json
 // @Produce      json
-// @Success      200      {object}  dto.TestResponse
-// @Failure      400      {object}  res.errorResponse
-// @Failure      401      {object}  res.errorResponse
-// @Failure      404      {object}  res.errorResponse
-// @Failure      500      {object}  res.errorResponse
-// @Router       /api/v1/test [get]
+// @Success      200  {object}  response.MetaSuccess{data=dto.TestResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /test [get]
 func (h *handler) Test(c echo.Context) error {
 	cc := c.(*abstraction.Context)
 
@@ -53,6 +50,16 @@ func (h *handler) Test(c echo.Context) error {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Send a test email
+// @Description  Send a test email
+// @Tags         Test
+// @Accept       json
+// @Produce      json
+// @Param        payload   body      dto.TestGomailRequest                    true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.TestResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /test/gomail [post]
 func (h *handler) TestGomail(c echo.Context) error {
 	cc := c.(*abstraction.Context)
 
@@ -64,7 +71,7 @@ func (h *handler) TestGomail(c echo.Context) error {
 		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
 	}
 
-	data, err := h.service.TestGomail(cc, payload.Recipient)
+	data, err := h.service.TestGomail(cc, payload)
 	if err != nil {
 		return response.ErrorResponse(err).SendError(c)
 	}
@@ -72,6 +79,16 @@ func (h *handler) TestGomail(c echo.Context) error {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Upload test files
+// @Description  Upload test files to the storage selected by STORAGE_DRIVER
+// @Tags         Test
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        files     formData  file                                     true  "files"
+// @Success      200  {object}  response.MetaSuccess{data=dto.TestResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /test/gdrive [post]
 func (h *handler) TestDrive(c echo.Context) error {
 	cc := c.(*abstraction.Context)
 
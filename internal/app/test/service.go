@@ -7,31 +7,51 @@ import (
 	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/gdrive"
 	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/storage"
+	"daarul_mukhtarin/pkg/upload"
 	"daarul_mukhtarin/pkg/util/response"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"net/http"
+	"path"
 
 	"gorm.io/gorm"
 )
 
 type Service interface {
 	Test(*abstraction.Context) (*dto.TestResponse, error)
-	TestGomail(*abstraction.Context, string) (*dto.TestResponse, error)
+	TestGomail(*abstraction.Context, *dto.TestGomailRequest) (*dto.TestResponse, error)
 	TestDrive(*abstraction.Context, []*multipart.FileHeader) (*dto.TestResponse, error)
 }
 
+// testPolicy accepts the same files as dokumen with smaller limits.
+var testPolicy = upload.Policy{
+	MaxSize:  10 << 20,
+	MaxCount: 5,
+	Allowed:  upload.DokumenPolicy.Allowed,
+}
+
 type service struct {
 	Repository repository.Test
 	Db         *gorm.DB
+	Mailer     gomail.Mailer
+	Storage    storage.Storage
+	Scanner    upload.Scanner
 }
 
 func NewService(f *factory.Factory) Service {
 	repository := f.TestRepository
 	db := f.Db
+	mailer := f.Mailer
+	storage := f.Storage
+	scanner := f.Scanner
 	return &service{
 		repository,
 		db,
+		mailer,
+		storage,
+		scanner,
 	}
 }
 
@@ -42,8 +62,28 @@ func (s *service) Test(ctx *abstraction.Context) (*dto.TestResponse, error) {
 	return &result, nil
 }
 
-func (s *service) TestGomail(ctx *abstraction.Context, recipient string) (*dto.TestResponse, error) {
-	err := gomail.SendMail(recipient, "Test Email", "Hello World!")
+func (s *service) TestGomail(ctx *abstraction.Context, payload *dto.TestGomailRequest) (*dto.TestResponse, error) {
+	message := gomail.NewMessage("Test Email", "<p>Hello World!</p>", payload.Recipient).
+		AddCc(payload.Cc...).
+		AddBcc(payload.Bcc...).
+		SetReplyTo(payload.ReplyTo)
+
+	if payload.DriveFileId != "" {
+		srvDrive, err := gdrive.InitGoogleDrive()
+		if err != nil {
+			return nil, driveError(err)
+		}
+		file, content, err := gdrive.FileFromDrive(srvDrive, payload.DriveFileId)
+		if err != nil {
+			return nil, driveError(err)
+		}
+		defer content.Close()
+		if err = message.AttachReader(file.Name, file.MimeType, content); err != nil {
+			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+	}
+
+	err := s.Mailer.Send(message)
 	if err != nil {
 		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
@@ -54,26 +94,15 @@ func (s *service) TestGomail(ctx *abstraction.Context, recipient string) (*dto.T
 }
 
 func (s *service) TestDrive(ctx *abstraction.Context, files []*multipart.FileHeader) (*dto.TestResponse, error) {
-	srvDrive, err := gdrive.InitGoogleDrive()
-	if err != nil {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	if err := testPolicy.CheckCount(files); err != nil {
+		return nil, uploadError(err)
 	}
 
-	newFolder, err := gdrive.FolderToDrive(srvDrive, "Example New Folder", "root")
-	if err != nil {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
-	}
 	var uploadedFiles []string
 	for _, file := range files {
-		f, err := file.Open()
-		if err != nil {
-			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
-		}
-		defer f.Close()
-
-		newFile, err := gdrive.FileToDrive(srvDrive, file.Filename, "application/octet-stream", f, newFolder.Id)
+		newFile, err := s.upload(ctx, file)
 		if err != nil {
-			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+			return nil, err
 		}
 
 		uploadedFiles = append(uploadedFiles, newFile.Name)
@@ -84,3 +113,32 @@ func (s *service) TestDrive(ctx *abstraction.Context, files []*multipart.FileHea
 	}
 	return &result, nil
 }
+
+func (s *service) upload(ctx *abstraction.Context, header *multipart.FileHeader) (*storage.Object, error) {
+	file, err := testPolicy.Open(ctx.Request().Context(), s.Scanner, header)
+	if err != nil {
+		return nil, uploadError(err)
+	}
+	defer file.Close()
+
+	object, err := s.Storage.Put(ctx.Request().Context(), path.Join("test", file.Name), file.Content, file.Size, file.ContentType)
+	if err != nil {
+		return nil, driveError(err)
+	}
+	return object, nil
+}
+
+func uploadError(err error) error {
+	var uploadErr *upload.Error
+	if errors.As(err, &uploadErr) {
+		return response.ErrorBuilder(uploadErr.Status, uploadErr, uploadErr.Code)
+	}
+	return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+}
+
+func driveError(err error) error {
+	if gdrive.IsAuthError(err) {
+		return response.ErrorBuilder(http.StatusServiceUnavailable, err, "google drive is not authorized")
+	}
+	return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+}
@@ -0,0 +1,99 @@
+package trash
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/config"
+	"daarul_mukhtarin/internal/factory"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetentionDays = 30
+	defaultPurgeInterval = 1 * time.Hour
+	purgeBatchSize       = 100
+)
+
+// Purger anonymizes the users and removes the divisi that stayed in the trash longer than the
+// retention, TRASH_RETENTION_DAYS.
+type Purger struct {
+	service *service
+
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurger(f *factory.Factory) *Purger {
+	p := &Purger{
+		service: newService(f),
+
+		retention: defaultRetentionDays * 24 * time.Hour,
+		interval:  defaultPurgeInterval,
+	}
+	if days, _ := strconv.Atoi(config.Get().Trash.RetentionDays); days > 0 {
+		p.retention = time.Duration(days) * 24 * time.Hour
+	}
+	if interval, err := time.ParseDuration(config.Get().Trash.PurgeInterval); err == nil && interval > 0 {
+		p.interval = interval
+	}
+	return p
+}
+
+// Start purges the trash until ctx is cancelled.
+func (p *Purger) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	logrus.Info("Trash purger started")
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("Trash purger stopped")
+			return
+		case <-ticker.C:
+			if err := p.Purge(); err != nil {
+				logrus.Error("failed purge trash: ", err)
+			}
+		}
+	}
+}
+
+// Purge handles everything deleted before the retention. Users go first so the divisi they
+// were the last members of can be removed in the same run.
+func (p *Purger) Purge() error {
+	before := time.Now().Add(-p.retention)
+	for {
+		users, err := p.service.UserRepository.FindPurgeable(&abstraction.Context{}, before, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, v := range users {
+			if err = p.service.purgeUser(&abstraction.Context{}, v); err != nil {
+				return err
+			}
+		}
+		if len(users) < purgeBatchSize {
+			break
+		}
+	}
+
+	// divisi still in use stay in the trash, so they are not paged through
+	divisi, err := p.service.DivisiRepository.FindPurgeable(&abstraction.Context{}, before, purgeBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, v := range divisi {
+		err = p.service.purgeDivisi(&abstraction.Context{}, v.ID)
+		if err == errDivisiInUse {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		logrus.Info("purged divisi ", v.ID, " (", v.Name, ")")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package trash
+
+import (
+	"daarul_mukhtarin/internal/middleware"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *handler) Route(v *echo.Group) {
+	v.GET("/user", h.FindUser, middleware.Authentication)
+	v.POST("/user/:id/restore", h.RestoreUser, middleware.Authentication)
+	v.DELETE("/user/:id", h.PurgeUser, middleware.Authentication)
+	v.GET("/divisi", h.FindDivisi, middleware.Authentication)
+	v.POST("/divisi/:id/restore", h.RestoreDivisi, middleware.Authentication)
+	v.DELETE("/divisi/:id", h.PurgeDivisi, middleware.Authentication)
+}
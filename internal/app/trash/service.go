@@ -0,0 +1,316 @@
+package trash
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/app/divisi"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/avatar"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/storage"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// errDivisiInUse keeps a divisi that still has users, dokumen or folders from being purged.
+var errDivisiInUse = errors.New("divisi still has users, dokumen or folders")
+
+type Service interface {
+	FindUser(ctx *abstraction.Context) ([]*dto.TrashUserResponse, *response.Meta, error)
+	RestoreUser(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error)
+	PurgeUser(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error)
+	FindDivisi(ctx *abstraction.Context) ([]*dto.TrashDivisiResponse, *response.Meta, error)
+	RestoreDivisi(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error)
+	PurgeDivisi(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error)
+}
+
+type service struct {
+	UserRepository    repository.User
+	RoleRepository    repository.Role
+	DivisiRepository  repository.Divisi
+	DokumenRepository repository.Dokumen
+
+	EmailOutboxRepository repository.EmailOutbox
+
+	Storage storage.Storage
+
+	DriveSync *divisi.DriveSync
+
+	DB *gorm.DB
+}
+
+func NewService(f *factory.Factory) Service {
+	return newService(f)
+}
+
+func newService(f *factory.Factory) *service {
+	return &service{
+		UserRepository:    f.UserRepository,
+		RoleRepository:    f.RoleRepository,
+		DivisiRepository:  f.DivisiRepository,
+		DokumenRepository: f.DokumenRepository,
+
+		EmailOutboxRepository: f.EmailOutboxRepository,
+
+		Storage: f.Storage,
+
+		DriveSync: divisi.NewDriveSync(f),
+
+		DB: f.Db,
+	}
+}
+
+func (s *service) FindUser(ctx *abstraction.Context) ([]*dto.TrashUserResponse, *response.Meta, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.DeletedUserQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.UserRepository.FindDeleted(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	count, err := s.UserRepository.CountDeleted(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return dto.NewTrashUserResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
+	}, nil
+}
+
+// RestoreUser brings a deleted user back, unless its email was taken meanwhile or its role or
+// divisi is gone, which answers 409.
+func (s *service) RestoreUser(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error) {
+	var divisiId int
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		userData, err := s.UserRepository.FindDeletedById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if userData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "user not found in trash")
+		}
+
+		userEmail, err := s.UserRepository.FindByEmail(ctx, userData.Email)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if userEmail != nil {
+			return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "email is used by another user")
+		}
+		if userData.RoleId != 0 {
+			roleData, err := s.RoleRepository.FindById(ctx, userData.RoleId)
+			if err != nil && err.Error() != "record not found" {
+				return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+			}
+			if roleData == nil {
+				return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "role of the user is deleted")
+			}
+		}
+		if userData.DivisiId != 0 {
+			divisiData, err := s.DivisiRepository.FindById(ctx, userData.DivisiId)
+			if err != nil && err.Error() != "record not found" {
+				return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+			}
+			if divisiData == nil {
+				return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "divisi of the user is deleted, restore it first")
+			}
+		}
+
+		if err = s.UserRepository.UpdateDelete(ctx, &userData.ID, false, 0).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		divisiId = userData.DivisiId
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	s.DriveSync.SyncAsync(divisiId)
+	return &dto.MessageResponse{Message: "success restore!"}, nil
+}
+
+// PurgeUser anonymizes a deleted user right away instead of waiting for the retention.
+func (s *service) PurgeUser(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	userData, err := s.UserRepository.FindDeletedById(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if userData == nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "user not found in trash")
+	}
+	if err = s.purgeUser(ctx, userData); err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return &dto.MessageResponse{Message: "success purge!"}, nil
+}
+
+func (s *service) FindDivisi(ctx *abstraction.Context) ([]*dto.TrashDivisiResponse, *response.Meta, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	q, err := query.Parse(ctx.QueryParams(), repository.DeletedDivisiQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.DivisiRepository.FindDeleted(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	count, err := s.DivisiRepository.CountDeleted(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return dto.NewTrashDivisiResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
+	}, nil
+}
+
+// RestoreDivisi brings a deleted divisi back, unless another divisi took its name meanwhile,
+// which answers 409.
+func (s *service) RestoreDivisi(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error) {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		}
+
+		divisiData, err := s.DivisiRepository.FindDeletedById(ctx, payload.ID)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if divisiData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi not found in trash")
+		}
+
+		data, err := s.DivisiRepository.FindAll(ctx)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		for _, v := range data {
+			if strings.EqualFold(v.Name, divisiData.Name) {
+				return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "divisi name is used by another divisi")
+			}
+		}
+
+		if err = s.DivisiRepository.UpdateDelete(ctx, divisiData.ID, false, 0).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	s.DriveSync.SyncAsync(payload.ID)
+	return &dto.MessageResponse{Message: "success restore!"}, nil
+}
+
+// PurgeDivisi removes a deleted divisi for good right away, as long as nothing refers to it.
+func (s *service) PurgeDivisi(ctx *abstraction.Context, payload *dto.TrashByIDRequest) (*dto.MessageResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	divisiData, err := s.DivisiRepository.FindDeletedById(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if divisiData == nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi not found in trash")
+	}
+	if err = s.purgeDivisi(ctx, divisiData.ID); err != nil {
+		if err == errDivisiInUse {
+			return nil, response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), err.Error())
+		}
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return &dto.MessageResponse{Message: "success purge!"}, nil
+}
+
+// purgeUser anonymizes a deleted user, drops the mails sent to it, which hold its name and
+// email, and removes its avatar.
+func (s *service) purgeUser(ctx *abstraction.Context, userData *model.UserEntityModel) error {
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if err := s.UserRepository.Anonymize(ctx, userData.ID).Error; err != nil {
+			return err
+		}
+		return s.EmailOutboxRepository.DeleteByRecipient(ctx, userData.Email).Error
+	}); err != nil {
+		return err
+	}
+	if a := avatar.Parse(userData.Avatar); a != nil {
+		for _, key := range a.Keys {
+			if err := s.Storage.Delete(context.Background(), key); err != nil {
+				logrus.Error("failed delete avatar ", key, ": ", err)
+			}
+		}
+	}
+	return nil
+}
+
+// purgeDivisi deletes a divisi row together with its deleted dokumen and folders, the anonymized
+// users still pointing to it are detached. The objects of the dokumen are removed once that is
+// committed.
+func (s *service) purgeDivisi(ctx *abstraction.Context, divisiId int) error {
+	var keys []string
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		users, err := s.UserRepository.CountByDivisiId(ctx, divisiId)
+		if err != nil {
+			return err
+		}
+		dokumen, err := s.DokumenRepository.CountByDivisiId(ctx, divisiId)
+		if err != nil {
+			return err
+		}
+		if users+dokumen > 0 {
+			return errDivisiInUse
+		}
+
+		deleted, err := s.DokumenRepository.FindDeletedByDivisiId(ctx, divisiId)
+		if err != nil {
+			return err
+		}
+		// documents with the same content share one object
+		seen := make(map[string]bool, len(deleted))
+		for _, v := range deleted {
+			if v.StorageKey != "" && !seen[v.StorageKey] {
+				seen[v.StorageKey] = true
+				keys = append(keys, v.StorageKey)
+			}
+		}
+		if err = s.DokumenRepository.DeleteByDivisiId(ctx, divisiId).Error; err != nil {
+			return err
+		}
+		if err = s.DokumenRepository.DeleteFoldersByDivisiId(ctx, divisiId).Error; err != nil {
+			return err
+		}
+		if err = s.UserRepository.DetachDivisi(ctx, divisiId).Error; err != nil {
+			return err
+		}
+		return s.DivisiRepository.Delete(ctx, divisiId).Error
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.Storage.Delete(context.Background(), key); err != nil {
+			logrus.Error("failed delete dokumen ", key, ": ", err)
+		}
+	}
+	return nil
+}
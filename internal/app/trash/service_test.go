@@ -0,0 +1,243 @@
+package trash
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/app/divisi"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/migrate"
+	"daarul_mukhtarin/pkg/storage"
+	"daarul_mukhtarin/pkg/util/response"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+var admin = &abstraction.AuthContext{ID: 1, RoleID: constant.ROLE_ID_ADMIN}
+
+func newTestService(t *testing.T) *service {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := migrations.Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.NewMigrator(db, list).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	local, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := repository.NewUser(db)
+	divisiRepository := repository.NewDivisi(db)
+	return &service{
+		UserRepository:    userRepository,
+		RoleRepository:    repository.NewRole(db),
+		DivisiRepository:  divisiRepository,
+		DokumenRepository: repository.NewDokumen(db),
+
+		EmailOutboxRepository: repository.NewEmailOutbox(db),
+
+		Storage: local,
+
+		DriveSync: &divisi.DriveSync{DivisiRepository: divisiRepository, UserRepository: userRepository},
+
+		DB: db,
+	}
+}
+
+func create(t *testing.T, s *service, value interface{}) {
+	t.Helper()
+	if err := s.DB.Omit(clause.Associations).Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newRole(t *testing.T, s *service, name string, deleted bool) int {
+	t.Helper()
+	data := &model.RoleEntityModel{RoleEntity: model.RoleEntity{Name: name, IsDelete: deleted, Version: 1}}
+	create(t, s, data)
+	return data.ID
+}
+
+func newDivisi(t *testing.T, s *service, name string, deleted bool) int {
+	t.Helper()
+	data := &model.DivisiEntityModel{DivisiEntity: model.DivisiEntity{Name: name, IsDelete: deleted, Version: 1}}
+	create(t, s, data)
+	return data.ID
+}
+
+func newUser(t *testing.T, s *service, email string, roleId, divisiId int, deleted bool) int {
+	t.Helper()
+	data := &model.UserEntityModel{UserEntity: model.UserEntity{
+		Name: "Ahmad", Email: email, Password: "-", RoleId: roleId, DivisiId: divisiId, IsDelete: deleted, Version: 1,
+	}}
+	create(t, s, data)
+	return data.ID
+}
+
+func newDokumen(t *testing.T, s *service, divisiId int, key string, deleted bool) {
+	t.Helper()
+	if _, err := s.Storage.Put(context.Background(), key, strings.NewReader("isi"), 3, "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	create(t, s, &model.DokumenEntityModel{DokumenEntity: model.DokumenEntity{
+		Name: filepath.Base(key), Size: 3, MimeType: "text/plain", StorageKey: key, DivisiId: divisiId, UserId: 1, IsDelete: deleted,
+	}})
+}
+
+// status is the http status of err, or 200 without an error.
+func status(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return http.StatusOK
+	}
+	var metaErr *response.MetaError
+	if !errors.As(err, &metaErr) {
+		t.Fatalf("error = %v, want a response error", err)
+	}
+	return metaErr.Code
+}
+
+func TestRestoreUser(t *testing.T) {
+	s := newTestService(t)
+	role := newRole(t, s, "Anggota", false)
+	deletedRole := newRole(t, s, "Tamu", true)
+	humas := newDivisi(t, s, "Humas", false)
+	deletedDivisi := newDivisi(t, s, "Dapur", true)
+
+	newUser(t, s, "taken@example.com", role, humas, false)
+	tests := []struct {
+		name   string
+		id     int
+		status int
+	}{
+		{name: "not in trash", id: newUser(t, s, "live@example.com", role, humas, false), status: http.StatusBadRequest},
+		{name: "email taken meanwhile", id: newUser(t, s, "taken@example.com", role, humas, true), status: http.StatusConflict},
+		{name: "role deleted", id: newUser(t, s, "role@example.com", deletedRole, humas, true), status: http.StatusConflict},
+		{name: "divisi deleted", id: newUser(t, s, "divisi@example.com", role, deletedDivisi, true), status: http.StatusConflict},
+		{name: "restorable", id: newUser(t, s, "ok@example.com", role, humas, true), status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.RestoreUser(&abstraction.Context{Auth: admin}, &dto.TrashByIDRequest{ID: tt.id})
+			if got := status(t, err); got != tt.status {
+				t.Errorf("RestoreUser() status = %d, want %d", got, tt.status)
+			}
+		})
+	}
+}
+
+func TestRestoreDivisi(t *testing.T) {
+	s := newTestService(t)
+	newDivisi(t, s, "Humas", false)
+	taken := newDivisi(t, s, "HUMAS", true)
+	free := newDivisi(t, s, "Dapur", true)
+
+	_, err := s.RestoreDivisi(&abstraction.Context{Auth: admin}, &dto.TrashByIDRequest{ID: taken})
+	if got := status(t, err); got != http.StatusConflict {
+		t.Errorf("RestoreDivisi() of a taken name status = %d, want %d", got, http.StatusConflict)
+	}
+	_, err = s.RestoreDivisi(&abstraction.Context{Auth: admin}, &dto.TrashByIDRequest{ID: free})
+	if got := status(t, err); got != http.StatusOK {
+		t.Errorf("RestoreDivisi() status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestPurgeDivisi(t *testing.T) {
+	s := newTestService(t)
+	role := newRole(t, s, "Anggota", false)
+
+	withUser := newDivisi(t, s, "Humas", true)
+	newUser(t, s, "ahmad@example.com", role, withUser, true)
+	withDokumen := newDivisi(t, s, "Dapur", true)
+	newDokumen(t, s, withDokumen, "dokumen/2/menu.txt", false)
+	withDeleted := newDivisi(t, s, "Gudang", true)
+	newDokumen(t, s, withDeleted, "dokumen/3/stok.txt", true)
+	newDokumen(t, s, withDeleted, "dokumen/3/stok.txt", true)
+	create(t, s, &model.DokumenFolderEntityModel{DokumenFolderEntity: model.DokumenFolderEntity{Name: "Arsip", DivisiId: withDeleted, IsDelete: true}})
+
+	tests := []struct {
+		name   string
+		id     int
+		status int
+	}{
+		{name: "a user is left", id: withUser, status: http.StatusConflict},
+		{name: "a live dokumen is left", id: withDokumen, status: http.StatusConflict},
+		{name: "only deleted dokumen are left", id: withDeleted, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.PurgeDivisi(&abstraction.Context{Auth: admin}, &dto.TrashByIDRequest{ID: tt.id})
+			if got := status(t, err); got != tt.status {
+				t.Errorf("PurgeDivisi() status = %d, want %d", got, tt.status)
+			}
+		})
+	}
+
+	for _, table := range []string{"dokumen", "dokumen_folder"} {
+		var count int64
+		if err := s.DB.Table(table).Where("divisi_id = ?", withDeleted).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s rows of the purged divisi = %d, want 0", table, count)
+		}
+	}
+	if _, err := s.Storage.Stat(context.Background(), "dokumen/3/stok.txt"); err == nil {
+		t.Errorf("object of a purged dokumen still exists")
+	}
+	if _, err := s.Storage.Stat(context.Background(), "dokumen/2/menu.txt"); err != nil {
+		t.Errorf("object of a kept dokumen: %v", err)
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+	s := newTestService(t)
+	id := newUser(t, s, "ahmad@example.com", 0, 0, true)
+	for _, to := range []string{"Ahmad@Example.com", "budi@example.com"} {
+		if err := s.EmailOutboxRepository.Enqueue(&abstraction.Context{}, gomail.NewMessage("Akun baru", "<p>Ahmad</p>", to)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := s.PurgeUser(&abstraction.Context{Auth: admin}, &dto.TrashByIDRequest{ID: id}); err != nil {
+		t.Fatalf("PurgeUser() error = %v", err)
+	}
+
+	var recipients []string
+	if err := s.DB.Model(&model.EmailOutboxEntityModel{}).Pluck("recipient", &recipients).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(recipients) != 1 || recipients[0] != "budi@example.com" {
+		t.Errorf("outbox recipients = %v, want only budi@example.com", recipients)
+	}
+	var data model.UserEntityModel
+	if err := s.DB.Where("id = ?", id).First(&data).Error; err != nil {
+		t.Fatal(err)
+	}
+	if data.PurgedAt == nil || data.Email == "ahmad@example.com" {
+		t.Errorf("user is not anonymized: %+v", data.UserEntity)
+	}
+}
@@ -0,0 +1,134 @@
+package contact
+
+import (
+	"bytes"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/pkg/avatar"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/upload"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *service) UpdateAvatar(ctx *abstraction.Context, payload *dto.UserUpdateAvatarRequest, header *multipart.FileHeader) (*dto.UserAvatarResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN && ctx.Auth.ID != payload.ID {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	if header == nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "avatar is required")
+	}
+
+	userData, err := s.UserRepository.FindById(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if userData == nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "user not found")
+	}
+
+	file, err := upload.ImagePolicy.Open(ctx.Request().Context(), s.Scanner, header)
+	if err != nil {
+		var uploadErr *upload.Error
+		if errors.As(err, &uploadErr) {
+			return nil, response.ErrorBuilder(uploadErr.Status, uploadErr, uploadErr.Code)
+		}
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	thumbnails, err := avatar.Thumbnails(file.Content)
+	file.Close()
+	if err != nil {
+		if err == avatar.ErrInvalidImage || err == avatar.ErrImageTooBig {
+			return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid avatar")
+		}
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	newAvatar := &avatar.Avatar{
+		// nanoseconds keep two uploads within a second from sharing keys
+		Version: time.Now().UnixNano(),
+		Keys:    make(map[int]string),
+	}
+	for _, thumbnail := range thumbnails {
+		key := fmt.Sprintf("avatar/%d/%d_%d.jpg", userData.ID, newAvatar.Version, thumbnail.Size)
+		object, err := s.Storage.Put(ctx.Request().Context(), key, bytes.NewReader(thumbnail.Content), int64(len(thumbnail.Content)), "image/jpeg")
+		if err != nil {
+			s.removeAvatar(ctx, newAvatar, nil)
+			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		newAvatar.Keys[thumbnail.Size] = object.Key
+	}
+
+	if err = trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if err := s.UserRepository.UpdateAvatar(ctx, &userData.ID, newAvatar.String()).Error; err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		return nil
+	}); err != nil {
+		s.removeAvatar(ctx, newAvatar, nil)
+		return nil, err
+	}
+
+	// the previous thumbnails are unreachable once the new avatar is saved
+	s.removeAvatar(ctx, avatar.Parse(userData.Avatar), newAvatar)
+
+	return &dto.UserAvatarResponse{
+		Message:   "success update avatar!",
+		AvatarUrl: avatar.URL(userData.ID, newAvatar.String()),
+	}, nil
+}
+
+// removeAvatar deletes the thumbnails of a, except the ones keep still uses.
+func (s *service) removeAvatar(ctx *abstraction.Context, a *avatar.Avatar, keep *avatar.Avatar) {
+	if a == nil {
+		return
+	}
+	kept := make(map[string]bool)
+	if keep != nil {
+		for _, key := range keep.Keys {
+			kept[key] = true
+		}
+	}
+	for _, key := range a.Keys {
+		if kept[key] {
+			continue
+		}
+		if err := s.Storage.Delete(ctx.Request().Context(), key); err != nil {
+			logrus.Error("failed delete avatar ", key, ": ", err)
+		}
+	}
+}
+
+// Avatar streams the avatar thumbnail closest to payload.Size, it is public so it can be used
+// directly in img tags.
+func (s *service) Avatar(ctx *abstraction.Context, payload *dto.UserAvatarRequest) (io.ReadCloser, error) {
+	userData, err := s.UserRepository.FindById(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	var a *avatar.Avatar
+	if userData != nil {
+		a = avatar.Parse(userData.Avatar)
+	}
+	if a == nil {
+		return nil, response.ErrorBuilder(http.StatusNotFound, errors.New("not_found"), "avatar not found")
+	}
+
+	size := payload.Size
+	if size == 0 {
+		size = avatar.DEFAULT_SIZE
+	}
+	content, _, err := s.Storage.Get(ctx.Request().Context(), a.Key(size))
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusNotFound, err, "avatar not found")
+	}
+	return content, nil
+}
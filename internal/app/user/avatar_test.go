@@ -0,0 +1,38 @@
+package contact
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/pkg/avatar"
+	"daarul_mukhtarin/pkg/storage"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRemoveAvatarKeepsSharedKeys(t *testing.T) {
+	local, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"avatar/1/1_64.jpg", "avatar/1/1_128.jpg"} {
+		if _, err = local.Put(context.Background(), key, strings.NewReader("jpg"), 3, "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &service{Storage: local}
+	ctx := &abstraction.Context{Context: echo.New().NewContext(httptest.NewRequest("PUT", "/user/1/avatar", nil), httptest.NewRecorder())}
+
+	old := &avatar.Avatar{Version: 1, Keys: map[int]string{64: "avatar/1/1_64.jpg", 128: "avatar/1/1_128.jpg"}}
+	keep := &avatar.Avatar{Version: 2, Keys: map[int]string{64: "avatar/1/2_64.jpg", 128: "avatar/1/1_128.jpg"}}
+	s.removeAvatar(ctx, old, keep)
+
+	if _, err = local.Stat(context.Background(), "avatar/1/1_64.jpg"); err == nil {
+		t.Errorf("unused thumbnail still exists")
+	}
+	if _, err = local.Stat(context.Background(), "avatar/1/1_128.jpg"); err != nil {
+		t.Errorf("thumbnail still in use was removed: %v", err)
+	}
+}
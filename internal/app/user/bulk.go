@@ -0,0 +1,255 @@
+package contact
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/util/query"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	BULK_ACTION_LOCK        = "lock"
+	BULK_ACTION_UNLOCK      = "unlock"
+	BULK_ACTION_DELETE      = "delete"
+	BULK_ACTION_MOVE_DIVISI = "move_divisi"
+	BULK_ACTION_CHANGE_ROLE = "change_role"
+
+	// MAX_BULK_ITEMS caps the users one bulk request may touch.
+	MAX_BULK_ITEMS = 1000
+
+	bulkChanged      = "changed"
+	bulkUnchanged    = "unchanged"
+	bulkNotFound     = "not_found"
+	bulkNotPermitted = "not_permitted"
+)
+
+// bulkItem is the outcome for one user together with the user it applies to.
+type bulkItem struct {
+	dto.UserBulkItem
+
+	user *model.UserEntityModel
+}
+
+// Bulk applies one action to the users given by ids or matching a filter, all in one
+// transaction. With preview nothing is written, the items tell what would change.
+func (s *service) Bulk(ctx *abstraction.Context, payload *dto.UserBulkRequest) (*dto.UserBulkResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	if (len(payload.Ids) == 0) == (len(payload.Filter) == 0) {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "either ids or filter is required")
+	}
+	if len(payload.Ids) > MAX_BULK_ITEMS {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), fmt.Sprintf("at most %d users can be changed at once", MAX_BULK_ITEMS))
+	}
+	var q *query.Query
+	if len(payload.Filter) > 0 {
+		values := url.Values{}
+		for key, value := range payload.Filter {
+			values.Set(key, value)
+		}
+		var err error
+		if q, err = query.Parse(values, repository.UserQuery); err != nil {
+			return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid filter")
+		}
+	}
+
+	var (
+		items []*bulkItem
+		moved []int
+	)
+	plan := func(ctx *abstraction.Context) (err error) {
+		if err = s.checkBulkTarget(ctx, payload); err != nil {
+			return err
+		}
+		items, err = s.bulkItems(ctx, payload, q)
+		return err
+	}
+	if payload.Preview {
+		if err := plan(ctx); err != nil {
+			return nil, err
+		}
+	} else if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		if err := plan(ctx); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if item.Status != bulkChanged {
+				continue
+			}
+			if err := s.bulkApply(ctx, payload, item.user); err != nil {
+				return err
+			}
+			switch payload.Action {
+			case BULK_ACTION_DELETE:
+				moved = append(moved, item.user.DivisiId)
+			case BULK_ACTION_MOVE_DIVISI:
+				moved = append(moved, item.user.DivisiId, payload.DivisiId)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(moved) > 0 {
+		// the drive folder permissions follow the divisi of the users
+		s.DriveSync.SyncAsync(unique(moved)...)
+	}
+
+	res := &dto.UserBulkResponse{
+		Action:  payload.Action,
+		Preview: payload.Preview,
+		Total:   len(items),
+		Items:   make([]*dto.UserBulkItem, 0, len(items)),
+	}
+	for _, item := range items {
+		switch item.Status {
+		case bulkChanged:
+			res.Changed++
+		case bulkUnchanged:
+			res.Unchanged++
+		case bulkNotFound:
+			res.NotFound++
+		case bulkNotPermitted:
+			res.NotPermitted++
+		}
+		res.Items = append(res.Items, &item.UserBulkItem)
+	}
+	return res, nil
+}
+
+// checkBulkTarget checks the divisi or role the users are moved to.
+func (s *service) checkBulkTarget(ctx *abstraction.Context, payload *dto.UserBulkRequest) error {
+	switch payload.Action {
+	case BULK_ACTION_MOVE_DIVISI:
+		if payload.DivisiId == 0 {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi_id is required")
+		}
+		divisiData, err := s.DivisiRepository.FindById(ctx, payload.DivisiId)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if divisiData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "divisi not found")
+		}
+	case BULK_ACTION_CHANGE_ROLE:
+		if payload.RoleId == 0 {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "role_id is required")
+		}
+		roleData, err := s.RoleRepository.FindById(ctx, payload.RoleId)
+		if err != nil && err.Error() != "record not found" {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if roleData == nil {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "role not found")
+		}
+	}
+	return nil
+}
+
+// bulkItems finds the users of the request and decides what happens to each of them.
+func (s *service) bulkItems(ctx *abstraction.Context, payload *dto.UserBulkRequest, q *query.Query) ([]*bulkItem, error) {
+	var (
+		data []*model.UserEntityModel
+		err  error
+	)
+	if q != nil {
+		data, err = s.UserRepository.FindAllByQuery(ctx, q, MAX_BULK_ITEMS+1)
+	} else {
+		data, err = s.UserRepository.FindByIds(ctx, unique(payload.Ids))
+	}
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if len(data) > MAX_BULK_ITEMS {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), fmt.Sprintf("the filter matches more than %d users", MAX_BULK_ITEMS))
+	}
+
+	var items []*bulkItem
+	if q != nil {
+		for _, v := range data {
+			items = append(items, &bulkItem{UserBulkItem: dto.UserBulkItem{ID: v.ID}, user: v})
+		}
+	} else {
+		users := make(map[int]*model.UserEntityModel, len(data))
+		for _, v := range data {
+			users[v.ID] = v
+		}
+		for _, id := range unique(payload.Ids) {
+			items = append(items, &bulkItem{UserBulkItem: dto.UserBulkItem{ID: id}, user: users[id]})
+		}
+	}
+
+	for _, item := range items {
+		v := item.user
+		if v == nil {
+			item.Status = bulkNotFound
+			continue
+		}
+		item.Name, item.Email = v.Name, v.Email
+		switch {
+		case v.ID == ctx.Auth.ID && payload.Action != BULK_ACTION_UNLOCK && payload.Action != BULK_ACTION_MOVE_DIVISI:
+			item.Status, item.Message = bulkNotPermitted, "an admin can not "+payload.Action+" their own account"
+		case payload.Action == BULK_ACTION_LOCK && v.IsLocked,
+			payload.Action == BULK_ACTION_UNLOCK && !v.IsLocked,
+			payload.Action == BULK_ACTION_MOVE_DIVISI && v.DivisiId == payload.DivisiId,
+			payload.Action == BULK_ACTION_CHANGE_ROLE && v.RoleId == payload.RoleId:
+			item.Status = bulkUnchanged
+		default:
+			item.Status = bulkChanged
+		}
+	}
+	return items, nil
+}
+
+// bulkApply makes the change of payload to one user the way the single user endpoints do.
+func (s *service) bulkApply(ctx *abstraction.Context, payload *dto.UserBulkRequest, userData *model.UserEntityModel) error {
+	var result *gorm.DB
+	switch payload.Action {
+	case BULK_ACTION_LOCK, BULK_ACTION_UNLOCK:
+		result = s.UserRepository.UpdateLocked(ctx, &userData.ID, payload.Action == BULK_ACTION_LOCK)
+	case BULK_ACTION_DELETE:
+		result = s.UserRepository.UpdateDelete(ctx, &userData.ID, true, userData.Version)
+	default:
+		newUserData := new(model.UserEntityModel)
+		newUserData.Context = ctx
+		newUserData.ID = userData.ID
+		newUserData.Version = userData.Version
+		switch payload.Action {
+		case BULK_ACTION_MOVE_DIVISI:
+			newUserData.DivisiId = payload.DivisiId
+		case BULK_ACTION_CHANGE_ROLE:
+			newUserData.RoleId = payload.RoleId
+		}
+		result = s.UserRepository.Update(ctx, newUserData)
+	}
+	if result.Error != nil {
+		return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+	}
+	if result.RowsAffected == 0 {
+		return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), fmt.Sprintf("user %d was changed meanwhile, try again", userData.ID))
+	}
+	return nil
+}
+
+func unique(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var res []int
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
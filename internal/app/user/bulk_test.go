@@ -0,0 +1,147 @@
+package contact
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/util/response"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// newBulkService adds to the import fixtures the locked user budi, id 2, the admin citra, id 3,
+// who runs the requests, and the divisi 2.
+func newBulkService(t *testing.T) (*service, *abstraction.Context) {
+	t.Helper()
+	s := newImportService(t)
+	for _, data := range []*model.UserEntityModel{
+		{UserEntity: model.UserEntity{Name: "Budi", Email: "budi@example.com", Password: "-", RoleId: 2, DivisiId: 1, IsLocked: true, Version: 1}},
+		{UserEntity: model.UserEntity{Name: "Citra", Email: "citra@example.com", Password: "-", RoleId: 1, DivisiId: 1, Version: 1}},
+	} {
+		if err := s.DB.Create(data).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DB.Create(&model.DivisiEntityModel{DivisiEntity: model.DivisiEntity{Name: "Dapur", Version: 1}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return s, &abstraction.Context{Auth: &abstraction.AuthContext{ID: 3, RoleID: constant.ROLE_ID_ADMIN}}
+}
+
+func bulkStatuses(res *dto.UserBulkResponse) map[int]string {
+	statuses := make(map[int]string, len(res.Items))
+	for _, item := range res.Items {
+		statuses[item.ID] = item.Status
+	}
+	return statuses
+}
+
+func TestBulkPlan(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  dto.UserBulkRequest
+		statuses map[int]string
+	}{
+		{
+			name:     "lock",
+			payload:  dto.UserBulkRequest{Action: BULK_ACTION_LOCK, Ids: []int{1, 2, 3, 99, 1}},
+			statuses: map[int]string{1: bulkChanged, 2: bulkUnchanged, 3: bulkNotPermitted, 99: bulkNotFound},
+		},
+		{
+			name:     "unlock of the own account is permitted",
+			payload:  dto.UserBulkRequest{Action: BULK_ACTION_UNLOCK, Ids: []int{1, 2, 3}},
+			statuses: map[int]string{1: bulkUnchanged, 2: bulkChanged, 3: bulkUnchanged},
+		},
+		{
+			name:     "move divisi",
+			payload:  dto.UserBulkRequest{Action: BULK_ACTION_MOVE_DIVISI, Ids: []int{1, 3}, DivisiId: 2},
+			statuses: map[int]string{1: bulkChanged, 3: bulkChanged},
+		},
+		{
+			name:     "change role by filter",
+			payload:  dto.UserBulkRequest{Action: BULK_ACTION_CHANGE_ROLE, Filter: map[string]string{"divisi_id": "1"}, RoleId: 2},
+			statuses: map[int]string{1: bulkUnchanged, 2: bulkUnchanged, 3: bulkNotPermitted},
+		},
+		{
+			name:     "delete",
+			payload:  dto.UserBulkRequest{Action: BULK_ACTION_DELETE, Ids: []int{2, 3}},
+			statuses: map[int]string{2: bulkChanged, 3: bulkNotPermitted},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, ctx := newBulkService(t)
+			tt.payload.Preview = true
+			res, err := s.Bulk(ctx, &tt.payload)
+			if err != nil {
+				t.Fatalf("Bulk() error = %v", err)
+			}
+			if got := bulkStatuses(res); !reflect.DeepEqual(got, tt.statuses) {
+				t.Errorf("statuses = %v, want %v", got, tt.statuses)
+			}
+			if res.Total != len(tt.statuses) {
+				t.Errorf("Total = %d, want %d", res.Total, len(tt.statuses))
+			}
+
+			// a preview writes nothing
+			var versions []int
+			if err = s.DB.Model(&model.UserEntityModel{}).Order("id").Pluck("version", &versions).Error; err != nil {
+				t.Fatal(err)
+			}
+			if want := []int{1, 1, 1}; !reflect.DeepEqual(versions, want) {
+				t.Errorf("versions after preview = %v, want %v", versions, want)
+			}
+		})
+	}
+}
+
+func TestBulkApply(t *testing.T) {
+	s, ctx := newBulkService(t)
+	res, err := s.Bulk(ctx, &dto.UserBulkRequest{Action: BULK_ACTION_MOVE_DIVISI, Ids: []int{1, 2}, DivisiId: 2})
+	if err != nil {
+		t.Fatalf("Bulk() error = %v", err)
+	}
+	if res.Changed != 2 {
+		t.Errorf("Changed = %d, want 2", res.Changed)
+	}
+	var data []*model.UserEntityModel
+	if err = s.DB.Order("id").Find(&data).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range data {
+		divisiId, version := 2, 2
+		if v.ID == 3 {
+			divisiId, version = 1, 1
+		}
+		if v.DivisiId != divisiId || v.Version != version {
+			t.Errorf("user %d: divisi %d version %d, want divisi %d version %d", v.ID, v.DivisiId, v.Version, divisiId, version)
+		}
+	}
+}
+
+func TestBulkInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload dto.UserBulkRequest
+	}{
+		{name: "ids and filter", payload: dto.UserBulkRequest{Action: BULK_ACTION_LOCK, Ids: []int{1}, Filter: map[string]string{"divisi_id": "1"}}},
+		{name: "neither ids nor filter", payload: dto.UserBulkRequest{Action: BULK_ACTION_LOCK}},
+		{name: "too many ids", payload: dto.UserBulkRequest{Action: BULK_ACTION_LOCK, Ids: make([]int, MAX_BULK_ITEMS+1)}},
+		{name: "unknown filter", payload: dto.UserBulkRequest{Action: BULK_ACTION_LOCK, Filter: map[string]string{"password": "x"}}},
+		{name: "divisi not found", payload: dto.UserBulkRequest{Action: BULK_ACTION_MOVE_DIVISI, Ids: []int{1}, DivisiId: 9}},
+		{name: "role missing", payload: dto.UserBulkRequest{Action: BULK_ACTION_CHANGE_ROLE, Ids: []int{1}}},
+	}
+	s, ctx := newBulkService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Bulk(ctx, &tt.payload)
+			var metaErr *response.MetaError
+			if !errors.As(err, &metaErr) || metaErr.Code != http.StatusBadRequest {
+				t.Errorf("Bulk() error = %v, want a 400", err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/dto"
 	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/pkg/export"
 	"daarul_mukhtarin/pkg/util/response"
 	"net/http"
 
@@ -20,6 +21,19 @@ func NewHandler(f *factory.Factory) *handler {
 	}
 }
 
+// @Summary      Create user
+// @Description  Create user
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.UserCreateRequest                    true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user [post]
 func (h *handler) Create(c echo.Context) (err error) {
 	payload := new(dto.UserCreateRequest)
 	if err = c.Bind(payload); err != nil {
@@ -35,14 +49,61 @@ func (h *handler) Create(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      List users
+// @Description  List users
+// @Tags         User
+// @Produce      json
+// @Security     BearerAuth
+// @Param        search    query     string                                   false "search term"
+// @Param        page      query     int                                      false "page, starts at 1"
+// @Param        page_size query     int                                      false "page size"
+// @Param        order_by  query     string                                   false "asc or desc"
+// @Param        order     query     string                                   false "column to order by"
+// @Param        cursor    query     string                                   false "cursor from meta.pagination.next, instead of page"
+// @Param        format    query     string                                   false "csv or xlsx to download the list instead"
+// @Param        fields    query     string                                   false "comma separated fields to send, the rest is left out"
+// @Param        expand    query     string                                   false "comma separated relations to send, role and divisi"
+// @Success      200  {object}  response.MetaSuccess{data=[]dto.UserResponse,meta=response.Meta}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user [get]
 func (h handler) Find(c echo.Context) (err error) {
-	data, err := h.service.Find(c.(*abstraction.Context))
+	format, err := export.Format(c.Request())
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param").SendError(c)
+	}
+	if format != "" {
+		file, err := h.service.Export(c.(*abstraction.Context), format)
+		if err != nil {
+			return response.ErrorResponse(err).SendError(c)
+		}
+		return file.Send(c)
+	}
+
+	data, meta, err := h.service.Find(c.(*abstraction.Context))
 	if err != nil {
 		return response.ErrorResponse(err).SendError(c)
 	}
-	return response.SuccessResponse(data).SendSuccess(c)
+	return response.ListResponse(data, meta).SendSuccess(c)
 }
 
+// @Summary      Get user
+// @Description  Get user
+// @Tags         User
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        fields    query     string                                   false "comma separated fields to send, the rest is left out"
+// @Param        expand    query     string                                   false "comma separated relations to send, role and divisi"
+// @Success      200  {object}  response.MetaSuccess{data=dto.UserResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/{id} [get]
 func (h handler) FindById(c echo.Context) (err error) {
 	payload := new(dto.UserFindByIDRequest)
 	if err := c.Bind(payload); err != nil {
@@ -58,6 +119,23 @@ func (h handler) FindById(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Update user
+// @Description  Update user
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        If-Match  header    string                                   false "ETag from the last read, the update fails with 412 when it changed"
+// @Param        payload   body      dto.UserUpdateRequest                    true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      412  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/{id} [put]
 func (h handler) Update(c echo.Context) (err error) {
 	payload := new(dto.UserUpdateRequest)
 	if err = c.Bind(payload); err != nil {
@@ -73,6 +151,22 @@ func (h handler) Update(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Delete user
+// @Description  Delete user
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        If-Match  header    string                                   false "ETag from the last read, the update fails with 412 when it changed"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      412  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/{id} [delete]
 func (h handler) Delete(c echo.Context) (err error) {
 	payload := new(dto.UserDeleteByIDRequest)
 	if err := c.Bind(payload); err != nil {
@@ -88,6 +182,21 @@ func (h handler) Delete(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Change password
+// @Description  Change password
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        payload   body      dto.UserChangePasswordRequest            true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/change-password/{id} [post]
 func (h handler) ChangePassword(c echo.Context) (err error) {
 	payload := new(dto.UserChangePasswordRequest)
 	if err = c.Bind(payload); err != nil {
@@ -103,6 +212,20 @@ func (h handler) ChangePassword(c echo.Context) (err error) {
 	return response.SuccessResponse(data).SendSuccess(c)
 }
 
+// @Summary      Reset password
+// @Description  Reset password
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.MessageResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/reset-password/{id} [post]
 func (h handler) ResetPassword(c echo.Context) (err error) {
 	payload := new(dto.UserResetPasswordRequest)
 	if err = c.Bind(payload); err != nil {
@@ -117,3 +240,190 @@ func (h handler) ResetPassword(c echo.Context) (err error) {
 	}
 	return response.SuccessResponse(data).SendSuccess(c)
 }
+
+// @Summary      Upload avatar
+// @Description  Upload avatar
+// @Tags         User
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      int                                      true  "id"
+// @Param        avatar    formData  file                                     true  "image"
+// @Success      200  {object}  response.MetaSuccess{data=dto.UserAvatarResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/{id}/avatar [put]
+func (h *handler) UpdateAvatar(c echo.Context) (err error) {
+	payload := new(dto.UserUpdateAvatarRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	data, err := h.service.UpdateAvatar(c.(*abstraction.Context), payload, file)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Upload own avatar
+// @Description  Upload own avatar
+// @Tags         User
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        avatar    formData  file                                     true  "image"
+// @Success      200  {object}  response.MetaSuccess{data=dto.UserAvatarResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /me/avatar [put]
+func (h *handler) UpdateMyAvatar(c echo.Context) (err error) {
+	cc := c.(*abstraction.Context)
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	data, err := h.service.UpdateAvatar(cc, &dto.UserUpdateAvatarRequest{ID: cc.Auth.ID}, file)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Get avatar
+// @Description  Get avatar
+// @Tags         User
+// @Produce      jpeg
+// @Param        id        path      int                                      true  "id"
+// @Param        size      query     int                                      false "size in pixels"
+// @Success      200  {file}  file
+// @Failure      400  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/{id}/avatar [get]
+func (h *handler) Avatar(c echo.Context) (err error) {
+	payload := new(dto.UserAvatarRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	content, err := h.service.Avatar(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	defer content.Close()
+
+	// the url carries the avatar version, a new upload gets a new url
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=86400")
+	return c.Stream(http.StatusOK, "image/jpeg", content)
+}
+
+// @Summary      Import users from CSV or XLSX
+// @Description  Import users from CSV or XLSX
+// @Tags         User
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        file      formData  file                                     true  "csv or xlsx"
+// @Param        dry_run   formData  boolean                                  false "only validate the rows"
+// @Success      200  {object}  response.MetaSuccess{data=dto.UserImportReportResponse}
+// @Success      202  {object}  response.MetaSuccess{data=dto.ImportJobResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/import [post]
+func (h *handler) Import(c echo.Context) (err error) {
+	payload := new(dto.UserImportRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	// the body binding skips the query string on POST, dry_run may be given in either
+	if err = echo.QueryParamsBinder(c).Bool("dry_run", &payload.DryRun).BindError(); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	data, job, err := h.service.Import(c.(*abstraction.Context), payload, file)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	if job != nil {
+		return response.SuccessBuilder(http.StatusAccepted, job).SendSuccess(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Get import job
+// @Description  Get import job
+// @Tags         User
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string                                   true  "job id"
+// @Success      200  {object}  response.MetaSuccess{data=dto.ImportJobResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      404  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/import/{id} [get]
+func (h *handler) ImportJob(c echo.Context) (err error) {
+	payload := new(dto.UserImportJobRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.ImportJob(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
+
+// @Summary      Bulk action on users
+// @Description  Bulk action on users
+// @Tags         User
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        payload   body      dto.UserBulkRequest                      true  "payload"
+// @Success      200  {object}  response.MetaSuccess{data=dto.UserBulkResponse}
+// @Failure      400  {object}  response.MetaError
+// @Failure      401  {object}  response.MetaError
+// @Failure      403  {object}  response.MetaError
+// @Failure      500  {object}  response.MetaError
+// @Router       /user/bulk [post]
+func (h *handler) Bulk(c echo.Context) (err error) {
+	payload := new(dto.UserBulkRequest)
+	if err = c.Bind(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = echo.QueryParamsBinder(c).Bool("preview", &payload.Preview).BindError(); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error bind payload").SendError(c)
+	}
+	if err = c.Validate(payload); err != nil {
+		return response.ErrorBuilder(http.StatusBadRequest, err, "error validate payload").SendError(c)
+	}
+	data, err := h.service.Bulk(c.(*abstraction.Context), payload)
+	if err != nil {
+		return response.ErrorResponse(err).SendError(c)
+	}
+	return response.SuccessResponse(data).SendSuccess(c)
+}
@@ -0,0 +1,405 @@
+package contact
+
+import (
+	"crypto/rand"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/dto"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/upload"
+	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/util/response"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	importKind = "user"
+
+	// importBatchSize is how many credentials are hashed between two progress calls. A file of at
+	// most one batch is imported within its request, a longer one is handed to the ImportWorker as
+	// a background job, since every user costs a bcrypt hash.
+	importBatchSize = 200
+
+	importRowValid   = "valid"
+	importRowInvalid = "invalid"
+	importRowCreated = "created"
+)
+
+var importColumns = []export.Column{
+	{Key: "name", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Nama", mailtemplate.LANGUAGE_EN: "Name"}},
+	{Key: "email", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Email", mailtemplate.LANGUAGE_EN: "Email"}},
+	{Key: "role_id", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "ID Peran", mailtemplate.LANGUAGE_EN: "Role ID"}},
+	{Key: "divisi_id", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "ID Divisi", mailtemplate.LANGUAGE_EN: "Division ID"}},
+	{Key: "language", Headers: map[string]string{mailtemplate.LANGUAGE_ID: "Bahasa", mailtemplate.LANGUAGE_EN: "Language"}},
+}
+
+// importRow is one data row of an import file and its outcome.
+type importRow = dto.UserImportRow
+
+func invalid(row *importRow, message string) {
+	row.Status = importRowInvalid
+	row.Errors = append(row.Errors, message)
+}
+
+// Import creates a user for every valid row of a csv or xlsx file, or with dry_run only reports
+// what would happen. Files of more than importBatchSize rows are stored and imported by the
+// ImportWorker.
+func (s *service) Import(ctx *abstraction.Context, payload *dto.UserImportRequest, header *multipart.FileHeader) (*dto.UserImportReportResponse, *dto.ImportJobResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	if header == nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "file is required")
+	}
+
+	file, err := upload.SpreadsheetPolicy.Open(ctx.Request().Context(), s.Scanner, header)
+	if err != nil {
+		var uploadErr *upload.Error
+		if errors.As(err, &uploadErr) {
+			return nil, nil, response.ErrorBuilder(uploadErr.Status, uploadErr, uploadErr.Code)
+		}
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	defer file.Close()
+	format := export.ImportFormat(file.ContentType)
+
+	rows, err := readImport(file.Content, format)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid file")
+	}
+	if len(rows) > importBatchSize {
+		if _, err = file.Content.Seek(0, io.SeekStart); err != nil {
+			return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		job, err := s.enqueueImport(ctx, payload, file, format)
+		return nil, job, err
+	}
+
+	if err = s.runImport(ctx, rows, payload.DryRun, nil); err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return importReport(payload.DryRun, rows), nil, nil
+}
+
+// ImportJob returns the state of a background import and its report once it is done.
+func (s *service) ImportJob(ctx *abstraction.Context, payload *dto.UserImportJobRequest) (*dto.ImportJobResponse, error) {
+	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+	}
+	jobData, err := s.ImportJobRepository.FindByUid(ctx, payload.ID)
+	if err != nil && err.Error() != "record not found" {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	if jobData == nil || jobData.Kind != importKind {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "import job not found")
+	}
+
+	res := dto.NewImportJobResponse(jobData)
+	if jobData.Report != "" {
+		if err = json.Unmarshal([]byte(jobData.Report), &res.Rows); err != nil {
+			return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+	}
+	return res, nil
+}
+
+func (s *service) enqueueImport(ctx *abstraction.Context, payload *dto.UserImportRequest, file *upload.File, format string) (*dto.ImportJobResponse, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	uid := hex.EncodeToString(b)
+	key := fmt.Sprintf("import/%s/%s.%s", importKind, uid, format)
+	if _, err := s.Storage.Put(ctx.Request().Context(), key, file.Content, file.Size, file.ContentType); err != nil {
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+
+	modelJob := &model.ImportJobEntityModel{
+		Context: ctx,
+		ImportJobEntity: model.ImportJobEntity{
+			Uid:        uid,
+			Kind:       importKind,
+			UserId:     ctx.Auth.ID,
+			FileName:   file.Name,
+			StorageKey: key,
+			Format:     format,
+			DryRun:     payload.DryRun,
+			Status:     constant.IMPORT_STATUS_PENDING,
+		},
+	}
+	if err := s.ImportJobRepository.Create(ctx, modelJob).Error; err != nil {
+		s.Storage.Delete(ctx.Request().Context(), key)
+		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	}
+	return dto.NewImportJobResponse(modelJob), nil
+}
+
+// readImport reads the rows of an import file, a cell that cannot be parsed makes its row
+// invalid rather than failing the file.
+func readImport(r io.Reader, format string) ([]*importRow, error) {
+	var rows []*importRow
+	err := export.Read(r, format, importColumns, func(line int, record map[string]string) error {
+		row := &importRow{
+			Row:      line,
+			Name:     record["name"],
+			Email:    record["email"],
+			Language: strings.ToLower(record["language"]),
+			Status:   importRowValid,
+		}
+		for _, field := range []struct {
+			key string
+			id  *int
+		}{{"role_id", &row.RoleId}, {"divisi_id", &row.DivisiId}} {
+			if record[field.key] == "" {
+				continue
+			}
+			value, err := strconv.Atoi(record[field.key])
+			if err != nil {
+				invalid(row, fmt.Sprintf("%s %q is not a number", field.key, record[field.key]))
+				continue
+			}
+			*field.id = value
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the file has no rows")
+	}
+	return rows, nil
+}
+
+// runImport validates rows and, unless dryRun, creates every valid one in a single transaction,
+// so a failing row leaves none of them created. The credentials are generated before it in chunks
+// of importBatchSize, calling progress after each one. The credential emails go through the
+// outbox, so they are only sent once the users are committed.
+func (s *service) runImport(ctx *abstraction.Context, rows []*importRow, dryRun bool, progress func() error) error {
+	if err := s.validateImport(ctx, rows); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	var valid []*importRow
+	for _, row := range rows {
+		if row.Status == importRowValid {
+			valid = append(valid, row)
+		}
+	}
+
+	credentials := make([]*credential, 0, len(valid))
+	for start := 0; start < len(valid); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+		batch, err := newCredentials(end - start)
+		if err != nil {
+			return err
+		}
+		credentials = append(credentials, batch...)
+		if progress != nil {
+			if err = progress(); err != nil {
+				return err
+			}
+		}
+	}
+
+	var created []*importRow
+	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		// an email may have been taken since the validation
+		taken, err := s.takenEmails(ctx, valid)
+		if err != nil {
+			return err
+		}
+		created = nil
+		for i, row := range valid {
+			if taken[strings.ToLower(row.Email)] {
+				invalid(row, "email already exist")
+				continue
+			}
+			payload := &dto.UserCreateRequest{
+				Name:     row.Name,
+				Email:    row.Email,
+				RoleId:   row.RoleId,
+				DivisiId: row.DivisiId,
+				Language: row.Language,
+			}
+			if err = s.create(ctx, payload, credentials[i]); err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+			created = append(created, row)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	divisiIds := make(map[int]bool)
+	for _, row := range created {
+		row.Status = importRowCreated
+		divisiIds[row.DivisiId] = true
+	}
+	for divisiId := range divisiIds {
+		s.DriveSync.SyncAsync(divisiId)
+	}
+	return nil
+}
+
+// validateImport marks the rows that cannot be created, with every reason why.
+func (s *service) validateImport(ctx *abstraction.Context, rows []*importRow) error {
+	roles, err := s.RoleRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	roleIds := make(map[int]bool, len(roles))
+	for _, v := range roles {
+		roleIds[v.ID] = true
+	}
+	divisi, err := s.DivisiRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	divisiIds := make(map[int]bool, len(divisi))
+	for _, v := range divisi {
+		divisiIds[v.ID] = true
+	}
+
+	seen := make(map[string]int, len(rows))
+	var candidates []*importRow
+	for _, row := range rows {
+		if row.Name == "" {
+			invalid(row, "name is required")
+		}
+		email := strings.ToLower(row.Email)
+		switch {
+		case row.Email == "":
+			invalid(row, "email is required")
+		case !general.IsValidEmail(row.Email):
+			invalid(row, "email is not valid")
+		case seen[email] != 0:
+			invalid(row, fmt.Sprintf("email is already used on row %d", seen[email]))
+		default:
+			seen[email] = row.Row
+			candidates = append(candidates, row)
+		}
+		if row.RoleId == 0 {
+			if !hasError(row, "role_id") {
+				invalid(row, "role_id is required")
+			}
+		} else if !roleIds[row.RoleId] {
+			invalid(row, fmt.Sprintf("role %d not found", row.RoleId))
+		}
+		if row.DivisiId != 0 && !divisiIds[row.DivisiId] {
+			invalid(row, fmt.Sprintf("divisi %d not found", row.DivisiId))
+		}
+		if row.Language != "" && mailtemplate.Language(row.Language) != row.Language {
+			invalid(row, fmt.Sprintf("language must be one of %s", strings.Join(mailtemplate.Languages, ", ")))
+		}
+	}
+
+	taken, err := s.takenEmails(ctx, candidates)
+	if err != nil {
+		return err
+	}
+	for _, row := range candidates {
+		if taken[strings.ToLower(row.Email)] {
+			invalid(row, "email already exist")
+		}
+	}
+	return nil
+}
+
+// takenEmails returns the lowercased emails of rows that already belong to a user.
+func (s *service) takenEmails(ctx *abstraction.Context, rows []*importRow) (map[string]bool, error) {
+	emails := make([]string, len(rows))
+	for i, row := range rows {
+		emails[i] = row.Email
+	}
+	existing, err := s.UserRepository.FindEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, email := range existing {
+		taken[strings.ToLower(email)] = true
+	}
+	return taken, nil
+}
+
+// newCredentials generates n credentials using every cpu, bcrypt is slow on purpose and a large
+// import would otherwise spend minutes hashing inside its transaction.
+func newCredentials(n int) ([]*credential, error) {
+	credentials := make([]*credential, n)
+	errs := make([]error, n)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				credentials[i], errs[i] = newCredential()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return credentials, nil
+}
+
+func hasError(row *importRow, prefix string) bool {
+	for _, message := range row.Errors {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// countRows counts the rows per status.
+func countRows(rows []*importRow) map[string]int {
+	count := map[string]int{}
+	for _, row := range rows {
+		count[row.Status]++
+	}
+	return count
+}
+
+func importReport(dryRun bool, rows []*importRow) *dto.UserImportReportResponse {
+	count := countRows(rows)
+	return &dto.UserImportReportResponse{
+		DryRun:  dryRun,
+		Total:   len(rows),
+		Valid:   count[importRowValid],
+		Invalid: count[importRowInvalid],
+		Created: count[importRowCreated],
+		Rows:    rows,
+	}
+}
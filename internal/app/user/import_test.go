@@ -0,0 +1,207 @@
+package contact
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/app/divisi"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/migrate"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newImportService returns a service on a SQLite database with the roles 1 and 2, the divisi 1
+// and the user ahmad@example.com.
+func newImportService(t *testing.T) *service {
+	t.Helper()
+	if err := mailtemplate.Init(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := migrations.Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.NewMigrator(db, list).Up(0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Admin", "Anggota"} {
+		if err = db.Create(&model.RoleEntityModel{RoleEntity: model.RoleEntity{Name: name, Version: 1}}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = db.Create(&model.DivisiEntityModel{DivisiEntity: model.DivisiEntity{Name: "Humas", Version: 1}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	existing := &model.UserEntityModel{UserEntity: model.UserEntity{Name: "Ahmad", Email: "ahmad@example.com", Password: "-", RoleId: 2, DivisiId: 1, Version: 1}}
+	if err = db.Create(existing).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	userRepository := repository.NewUser(db)
+	divisiRepository := repository.NewDivisi(db)
+	return &service{
+		UserRepository:        userRepository,
+		RoleRepository:        repository.NewRole(db),
+		DivisiRepository:      divisiRepository,
+		EmailOutboxRepository: repository.NewEmailOutbox(db),
+		DriveSync:             &divisi.DriveSync{DivisiRepository: divisiRepository, UserRepository: userRepository},
+		DB:                    db,
+	}
+}
+
+func countTable(t *testing.T, db *gorm.DB, table string) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Table(table).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestValidateImport(t *testing.T) {
+	s := newImportService(t)
+	rows := []*importRow{
+		{Row: 2, Name: "Budi", Email: "budi@example.com", RoleId: 2, DivisiId: 1, Language: "id"},
+		{Row: 3, Name: "", Email: "", RoleId: 0},
+		{Row: 4, Name: "Citra", Email: "citra(at)example.com", RoleId: 2},
+		{Row: 5, Name: "Budi Lagi", Email: "BUDI@example.com", RoleId: 2},
+		{Row: 6, Name: "Dewi", Email: "dewi@example.com", RoleId: 9, DivisiId: 9},
+		{Row: 7, Name: "Eka", Email: "eka@example.com", RoleId: 2, Language: "fr"},
+		{Row: 8, Name: "Ahmad", Email: "Ahmad@Example.com", RoleId: 2},
+		{Row: 9, Name: "Fajar", Email: "fajar@example.com", RoleId: 1},
+	}
+	for _, row := range rows {
+		row.Status = importRowValid
+	}
+	if err := s.validateImport(&abstraction.Context{}, rows); err != nil {
+		t.Fatalf("validateImport() error = %v", err)
+	}
+
+	want := map[int][]string{
+		3: {"name is required", "email is required", "role_id is required"},
+		4: {"email is not valid"},
+		5: {"email is already used on row 2"},
+		6: {"role 9 not found", "divisi 9 not found"},
+		7: {"language must be one of " + mailtemplate.Languages[0] + ", " + mailtemplate.Languages[1]},
+		8: {"email already exist"},
+	}
+	for _, row := range rows {
+		status := importRowValid
+		if want[row.Row] != nil {
+			status = importRowInvalid
+		}
+		if row.Status != status {
+			t.Errorf("row %d: Status = %s, want %s", row.Row, row.Status, status)
+		}
+		if !reflect.DeepEqual(row.Errors, want[row.Row]) {
+			t.Errorf("row %d: Errors = %q, want %q", row.Row, row.Errors, want[row.Row])
+		}
+	}
+}
+
+func TestRunImport(t *testing.T) {
+	s := newImportService(t)
+	rows := []*importRow{
+		{Row: 2, Name: "Budi", Email: "budi@example.com", RoleId: 2, DivisiId: 1},
+		{Row: 3, Name: "Citra", Email: "citra@example.com", RoleId: 9},
+		{Row: 4, Name: "Dewi", Email: "dewi@example.com", RoleId: 2, DivisiId: 1, Language: "en"},
+	}
+	for _, row := range rows {
+		row.Status = importRowValid
+	}
+	progress := 0
+	if err := s.runImport(&abstraction.Context{}, rows, false, func() error {
+		progress++
+		return nil
+	}); err != nil {
+		t.Fatalf("runImport() error = %v", err)
+	}
+
+	var statuses []string
+	for _, row := range rows {
+		statuses = append(statuses, row.Status)
+	}
+	if want := []string{importRowCreated, importRowInvalid, importRowCreated}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if progress != 1 {
+		t.Errorf("progress called %d times, want 1", progress)
+	}
+	if got := countTable(t, s.DB, "user"); got != 3 {
+		t.Errorf("users = %d, want 3", got)
+	}
+	if got := countTable(t, s.DB, "email_outbox"); got != 2 {
+		t.Errorf("mails = %d, want 2", got)
+	}
+}
+
+func TestRunImportDryRun(t *testing.T) {
+	s := newImportService(t)
+	rows := []*importRow{{Row: 2, Name: "Budi", Email: "budi@example.com", RoleId: 2, Status: importRowValid}}
+	if err := s.runImport(&abstraction.Context{}, rows, true, nil); err != nil {
+		t.Fatalf("runImport() error = %v", err)
+	}
+	if rows[0].Status != importRowValid {
+		t.Errorf("Status = %s, want %s", rows[0].Status, importRowValid)
+	}
+	if got := countTable(t, s.DB, "user"); got != 1 {
+		t.Errorf("users = %d, want 1", got)
+	}
+}
+
+func TestRunImportAllOrNothing(t *testing.T) {
+	s := newImportService(t)
+	created := 0
+	err := s.DB.Callback().Create().Before("gorm:create").Register("test:fail_second_user", func(tx *gorm.DB) {
+		if tx.Statement.Table != "user" {
+			return
+		}
+		if created++; created == 2 {
+			tx.AddError(errors.New("disk is full"))
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []*importRow{
+		{Row: 2, Name: "Budi", Email: "budi@example.com", RoleId: 2},
+		{Row: 3, Name: "Citra", Email: "citra@example.com", RoleId: 2},
+		{Row: 4, Name: "Dewi", Email: "dewi@example.com", RoleId: 2},
+	}
+	for _, row := range rows {
+		row.Status = importRowValid
+	}
+	err = s.runImport(&abstraction.Context{}, rows, false, nil)
+	if err == nil || err.Error() != "row 3: disk is full" {
+		t.Fatalf("runImport() error = %v, want row 3: disk is full", err)
+	}
+	for _, row := range rows {
+		if row.Status != importRowValid {
+			t.Errorf("row %d: Status = %s, want %s", row.Row, row.Status, importRowValid)
+		}
+	}
+	if got := countTable(t, s.DB, "user"); got != 1 {
+		t.Errorf("users = %d, want 1", got)
+	}
+	if got := countTable(t, s.DB, "email_outbox"); got != 0 {
+		t.Errorf("mails = %d, want 0", got)
+	}
+}
@@ -9,9 +9,18 @@ import (
 func (h *handler) Route(v *echo.Group) {
 	v.POST("", h.Create, middleware.Authentication)
 	v.GET("", h.Find, middleware.Authentication)
+	v.POST("/import", h.Import, middleware.Authentication)
+	v.GET("/import/:id", h.ImportJob, middleware.Authentication)
+	v.POST("/bulk", h.Bulk, middleware.Authentication)
 	v.GET("/:id", h.FindById, middleware.Authentication)
 	v.PUT("/:id", h.Update, middleware.Authentication)
 	v.DELETE("/:id", h.Delete, middleware.Authentication)
 	v.POST("/change-password/:id", h.ChangePassword, middleware.Authentication)
 	v.POST("/reset-password/:id", h.ResetPassword, middleware.Authentication)
+	v.PUT("/:id/avatar", h.UpdateAvatar, middleware.Authentication)
+	v.GET("/:id/avatar", h.Avatar)
+}
+
+func (h *handler) RouteMe(v *echo.Group) {
+	v.PUT("/avatar", h.UpdateMyAvatar, middleware.Authentication)
 }
@@ -2,15 +2,24 @@ package contact
 
 import (
 	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/app/divisi"
 	"daarul_mukhtarin/internal/dto"
 	"daarul_mukhtarin/internal/factory"
 	"daarul_mukhtarin/internal/model"
 	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/constant"
-	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/export"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"daarul_mukhtarin/pkg/storage"
+	"daarul_mukhtarin/pkg/upload"
+	"daarul_mukhtarin/pkg/util/etag"
+	"daarul_mukhtarin/pkg/util/fields"
 	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/util/query"
 	"daarul_mukhtarin/pkg/util/response"
 	"daarul_mukhtarin/pkg/util/trxmanager"
+	"io"
+	"mime/multipart"
 	"net/http"
 
 	"github.com/pkg/errors"
@@ -19,77 +28,79 @@ import (
 )
 
 type Service interface {
-	Create(ctx *abstraction.Context, payload *dto.UserCreateRequest) (map[string]interface{}, error)
-	Find(ctx *abstraction.Context) (map[string]interface{}, error)
-	FindById(ctx *abstraction.Context, payload *dto.UserFindByIDRequest) (map[string]interface{}, error)
-	Update(ctx *abstraction.Context, payload *dto.UserUpdateRequest) (map[string]interface{}, error)
-	Delete(ctx *abstraction.Context, payload *dto.UserDeleteByIDRequest) (map[string]interface{}, error)
-	ChangePassword(ctx *abstraction.Context, payload *dto.UserChangePasswordRequest) (map[string]interface{}, error)
-	ResetPassword(ctx *abstraction.Context, payload *dto.UserResetPasswordRequest) (map[string]interface{}, error)
+	Create(ctx *abstraction.Context, payload *dto.UserCreateRequest) (*dto.MessageResponse, error)
+	Find(ctx *abstraction.Context) ([]*dto.UserResponse, *response.Meta, error)
+	Export(ctx *abstraction.Context, format string) (*export.Export, error)
+	FindById(ctx *abstraction.Context, payload *dto.UserFindByIDRequest) (*dto.UserResponse, error)
+	Update(ctx *abstraction.Context, payload *dto.UserUpdateRequest) (*dto.MessageResponse, error)
+	Delete(ctx *abstraction.Context, payload *dto.UserDeleteByIDRequest) (*dto.MessageResponse, error)
+	ChangePassword(ctx *abstraction.Context, payload *dto.UserChangePasswordRequest) (*dto.MessageResponse, error)
+	ResetPassword(ctx *abstraction.Context, payload *dto.UserResetPasswordRequest) (*dto.MessageResponse, error)
+	UpdateAvatar(ctx *abstraction.Context, payload *dto.UserUpdateAvatarRequest, header *multipart.FileHeader) (*dto.UserAvatarResponse, error)
+	Avatar(ctx *abstraction.Context, payload *dto.UserAvatarRequest) (io.ReadCloser, error)
+	Import(ctx *abstraction.Context, payload *dto.UserImportRequest, header *multipart.FileHeader) (*dto.UserImportReportResponse, *dto.ImportJobResponse, error)
+	ImportJob(ctx *abstraction.Context, payload *dto.UserImportJobRequest) (*dto.ImportJobResponse, error)
+	Bulk(ctx *abstraction.Context, payload *dto.UserBulkRequest) (*dto.UserBulkResponse, error)
 }
 
 type service struct {
-	UserRepository repository.User
+	UserRepository        repository.User
+	RoleRepository        repository.Role
+	DivisiRepository      repository.Divisi
+	EmailOutboxRepository repository.EmailOutbox
+	ImportJobRepository   repository.ImportJob
+
+	Storage storage.Storage
+	Scanner upload.Scanner
+
+	DriveSync *divisi.DriveSync
 
 	DB *gorm.DB
 }
 
 func NewService(f *factory.Factory) Service {
+	return newService(f)
+}
+
+func newService(f *factory.Factory) *service {
 	return &service{
-		UserRepository: f.UserRepository,
+		UserRepository:        f.UserRepository,
+		RoleRepository:        f.RoleRepository,
+		DivisiRepository:      f.DivisiRepository,
+		EmailOutboxRepository: f.EmailOutboxRepository,
+		ImportJobRepository:   f.ImportJobRepository,
+
+		Storage: f.Storage,
+		Scanner: f.Scanner,
+
+		DriveSync: divisi.NewDriveSync(f),
 
 		DB: f.Db,
 	}
 }
 
-func (s *service) Create(ctx *abstraction.Context, payload *dto.UserCreateRequest) (map[string]interface{}, error) {
+func (s *service) Create(ctx *abstraction.Context, payload *dto.UserCreateRequest) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
 		}
 
-		userEmail, err := s.UserRepository.FindByEmail(ctx, payload.Email)
+		userEmail, err := s.UserRepository.FindByEmailWithDeleted(ctx, payload.Email)
 		if err != nil && err.Error() != "record not found" {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
+		if userEmail != nil && userEmail.IsDelete {
+			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email belongs to a deleted user, restore it from the trash")
+		}
 		if userEmail != nil {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email already exist")
 		}
 
-		passwordString := general.GeneratePassword(8, 1, 1, 1, 1)
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(passwordString), bcrypt.DefaultCost)
+		credential, err := newCredential()
 		if err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
-
-		modelUser := &model.UserEntityModel{
-			Context: ctx,
-			UserEntity: model.UserEntity{
-				Name:      payload.Name,
-				Email:     payload.Email,
-				Password:  string(hashedPassword),
-				RoleId:    payload.RoleId,
-				DivisiId:  payload.DivisiId,
-				IsDelete:  false,
-				IsLocked:  false,
-				LoginFrom: "",
-			},
-		}
-		if err = s.UserRepository.Create(ctx, modelUser).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
-		}
-
-		if err = gomail.SendMail(payload.Email, "Welcome to SelarasHomeId (Login Information)", general.ParseTemplateEmail("./assets/html/notif_create_user.html", struct {
-			NAME     string
-			EMAIL    string
-			PASSWORD string
-			LINK     string
-		}{
-			NAME:     payload.Name,
-			EMAIL:    payload.Email,
-			PASSWORD: passwordString,
-			LINK:     constant.BASE_URL,
-		})); err != nil {
+		if err = s.create(ctx, payload, credential); err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
 
@@ -97,83 +108,106 @@ func (s *service) Create(ctx *abstraction.Context, payload *dto.UserCreateReques
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success create!",
-	}, nil
+	s.DriveSync.SyncAsync(payload.DivisiId)
+	return &dto.MessageResponse{Message: "success create!"}, nil
+}
+
+// credential is a generated password and its hash.
+type credential struct {
+	password string
+	hash     string
 }
 
-func (s *service) Find(ctx *abstraction.Context) (map[string]interface{}, error) {
-	var res []map[string]interface{}
+func newCredential() (*credential, error) {
+	password := general.GeneratePassword(8, 1, 1, 1, 1)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &credential{password: password, hash: string(hashedPassword)}, nil
+}
+
+// create inserts the user and queues the email with its credential, it runs inside the
+// transaction of the caller so the email is only sent once the user is committed.
+func (s *service) create(ctx *abstraction.Context, payload *dto.UserCreateRequest, credential *credential) error {
+	modelUser := &model.UserEntityModel{
+		Context: ctx,
+		UserEntity: model.UserEntity{
+			Name:      payload.Name,
+			Email:     payload.Email,
+			Password:  credential.hash,
+			RoleId:    payload.RoleId,
+			DivisiId:  payload.DivisiId,
+			IsDelete:  false,
+			IsLocked:  false,
+			LoginFrom: "",
+			Language:  mailtemplate.Language(payload.Language),
+			Version:   1,
+		},
+	}
+	if err := s.UserRepository.Create(ctx, modelUser).Error; err != nil {
+		return err
+	}
+
+	message, err := mailtemplate.Message(mailtemplate.TEMPLATE_CREATE_USER, modelUser.Language, map[string]interface{}{
+		"NAME":     payload.Name,
+		"EMAIL":    payload.Email,
+		"PASSWORD": credential.password,
+		"LINK":     constant.BASE_URL,
+	}, payload.Email)
+	if err != nil {
+		return err
+	}
+	return s.EmailOutboxRepository.Enqueue(ctx, message)
+}
+
+func (s *service) Find(ctx *abstraction.Context) ([]*dto.UserResponse, *response.Meta, error) {
 	if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
-		return nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
 	}
-	data, err := s.UserRepository.Find(ctx)
-	if err != nil && err.Error() != "record not found" {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+	q, err := query.Parse(ctx.QueryParams(), repository.UserQuery)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
 	}
-	count, err := s.UserRepository.Count(ctx)
+	sel, err := fields.Parse(ctx.QueryParams(), repository.UserFields)
+	if err != nil {
+		return nil, nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.UserRepository.Find(ctx, q, sel)
 	if err != nil && err.Error() != "record not found" {
-		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
-	for _, v := range data {
-		res = append(res, map[string]interface{}{
-			"id":         v.ID,
-			"name":       v.Name,
-			"email":      v.Email,
-			"is_delete":  v.IsDelete,
-			"is_locked":  v.IsLocked,
-			"login_from": v.LoginFrom,
-			"created_at": v.CreatedAt,
-			"updated_at": v.UpdatedAt,
-			"role": map[string]interface{}{
-				"id":   v.Role.ID,
-				"name": v.Role.Name,
-			},
-			"divisi": map[string]interface{}{
-				"id":   v.Divisi.ID,
-				"name": v.Divisi.Name,
-			},
-		})
+	count, err := s.UserRepository.Count(ctx, q)
+	if err != nil && err.Error() != "record not found" {
+		return nil, nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
-	return map[string]interface{}{
-		"count": count,
-		"data":  res,
+	fields.Set(ctx, sel)
+	return dto.NewUserResponses(data), &response.Meta{
+		Pagination: q.Pagination(ctx.Request().URL, *count),
 	}, nil
 }
 
-func (s *service) FindById(ctx *abstraction.Context, payload *dto.UserFindByIDRequest) (map[string]interface{}, error) {
-	var res map[string]interface{} = nil
-	data, err := s.UserRepository.FindById(ctx, payload.ID)
+func (s *service) FindById(ctx *abstraction.Context, payload *dto.UserFindByIDRequest) (*dto.UserResponse, error) {
+	sel, err := fields.Parse(ctx.QueryParams(), repository.UserFields)
+	if err != nil {
+		return nil, response.ErrorBuilder(http.StatusBadRequest, err, "invalid query param")
+	}
+	data, err := s.UserRepository.FindByIdSelect(ctx, payload.ID, sel)
 	if err != nil && err.Error() != "record not found" {
 		return nil, response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 	}
 	if data != nil {
-		res = map[string]interface{}{
-			"id":         data.ID,
-			"name":       data.Name,
-			"email":      data.Email,
-			"is_delete":  data.IsDelete,
-			"is_locked":  data.IsLocked,
-			"login_from": data.LoginFrom,
-			"created_at": data.CreatedAt,
-			"updated_at": data.UpdatedAt,
-			"role": map[string]interface{}{
-				"id":   data.Role.ID,
-				"name": data.Role.Name,
-			},
-			"divisi": map[string]interface{}{
-				"id":   data.Divisi.ID,
-				"name": data.Divisi.Name,
-			},
-		}
-
+		etag.Set(ctx, data.Version)
 	}
-	return map[string]interface{}{
-		"data": res,
-	}, nil
+	fields.Set(ctx, sel)
+	return dto.NewUserResponse(data), nil
 }
 
-func (s *service) Update(ctx *abstraction.Context, payload *dto.UserUpdateRequest) (map[string]interface{}, error) {
+func (s *service) Update(ctx *abstraction.Context, payload *dto.UserUpdateRequest) (*dto.MessageResponse, error) {
+	var (
+		moved   []int
+		version int
+	)
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		userData, err := s.UserRepository.FindById(ctx, payload.ID)
 		if err != nil && err.Error() != "record not found" {
@@ -182,19 +216,23 @@ func (s *service) Update(ctx *abstraction.Context, payload *dto.UserUpdateReques
 		if userData == nil {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "user not found")
 		}
+		if !etag.IfMatch(ctx.Request(), userData.Version) {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "user was changed since it was read")
+		}
 
 		newUserData := new(model.UserEntityModel)
 		newUserData.Context = ctx
 		newUserData.ID = payload.ID
+		newUserData.Version = userData.Version
 		if payload.Name != nil {
 			newUserData.Name = *payload.Name
 		}
 		if payload.Email != nil {
-			userEmail, err := s.UserRepository.FindByEmail(ctx, *payload.Email)
+			userEmail, err := s.UserRepository.FindByEmailWithDeleted(ctx, *payload.Email)
 			if err != nil && err.Error() != "record not found" {
 				return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 			}
-			if userEmail != nil && userEmail.Email != *payload.Email {
+			if userEmail != nil && userEmail.ID != payload.ID {
 				return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "email already exist")
 			}
 			newUserData.Email = *payload.Email
@@ -205,26 +243,46 @@ func (s *service) Update(ctx *abstraction.Context, payload *dto.UserUpdateReques
 		if payload.DivisiId != nil {
 			newUserData.DivisiId = *payload.DivisiId
 		}
+		if payload.Language != nil {
+			newUserData.Language = *payload.Language
+		}
+
+		result := s.UserRepository.Update(ctx, newUserData)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "user was changed since it was read")
+		}
+		version = newUserData.Version
+
+		// false is a zero value Update skips, so the lock is written on its own
 		if payload.IsLocked != nil {
-			newUserData.IsLocked = *payload.IsLocked
-			if err = s.UserRepository.UpdateLocked(ctx, &newUserData.ID, newUserData.IsLocked).Error; err != nil {
+			if err = s.UserRepository.UpdateLocked(ctx, &newUserData.ID, *payload.IsLocked).Error; err != nil {
 				return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 			}
+			version++
 		}
 
-		if err = s.UserRepository.Update(ctx, newUserData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		if payload.DivisiId != nil && *payload.DivisiId != userData.DivisiId {
+			moved = []int{userData.DivisiId, *payload.DivisiId}
+		} else if payload.Email != nil && *payload.Email != userData.Email {
+			moved = []int{userData.DivisiId}
 		}
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success update!",
-	}, nil
+	if len(moved) > 0 {
+		// the drive folder permissions follow the divisi and email of the user
+		s.DriveSync.SyncAsync(moved...)
+	}
+	etag.Set(ctx, version)
+	return &dto.MessageResponse{Message: "success update!"}, nil
 }
 
-func (s *service) Delete(ctx *abstraction.Context, payload *dto.UserDeleteByIDRequest) (map[string]interface{}, error) {
+func (s *service) Delete(ctx *abstraction.Context, payload *dto.UserDeleteByIDRequest) (*dto.MessageResponse, error) {
+	var divisiId int
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
@@ -237,25 +295,31 @@ func (s *service) Delete(ctx *abstraction.Context, payload *dto.UserDeleteByIDRe
 		if userData == nil {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "user not found")
 		}
+		if !etag.IfMatch(ctx.Request(), userData.Version) {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "user was changed since it was read")
+		}
 
-		newUserData := new(model.UserEntityModel)
-		newUserData.Context = ctx
-		newUserData.ID = userData.ID
-		newUserData.IsDelete = true
-
-		if err = s.UserRepository.Update(ctx, newUserData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		version := 0
+		if etag.HasIfMatch(ctx.Request()) {
+			version = userData.Version
+		}
+		result := s.UserRepository.UpdateDelete(ctx, &userData.ID, true, version)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
 		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusPreconditionFailed, errors.New("precondition_failed"), "user was changed since it was read")
+		}
+		divisiId = userData.DivisiId
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success delete!",
-	}, nil
+	s.DriveSync.SyncAsync(divisiId)
+	return &dto.MessageResponse{Message: "success delete!"}, nil
 }
 
-func (s *service) ChangePassword(ctx *abstraction.Context, payload *dto.UserChangePasswordRequest) (map[string]interface{}, error) {
+func (s *service) ChangePassword(ctx *abstraction.Context, payload *dto.UserChangePasswordRequest) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.ID != payload.ID {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this user is not permitted")
@@ -286,21 +350,24 @@ func (s *service) ChangePassword(ctx *abstraction.Context, payload *dto.UserChan
 		newUserData.Context = ctx
 		newUserData.ID = userData.ID
 		newUserData.Password = string(hashedPassword)
+		newUserData.Version = userData.Version
 
-		if err = s.UserRepository.Update(ctx, newUserData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		result := s.UserRepository.Update(ctx, newUserData)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "user was changed meanwhile, try again")
 		}
 
 		return nil
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success change password!",
-	}, nil
+	return &dto.MessageResponse{Message: "success change password!"}, nil
 }
 
-func (s *service) ResetPassword(ctx *abstraction.Context, payload *dto.UserResetPasswordRequest) (map[string]interface{}, error) {
+func (s *service) ResetPassword(ctx *abstraction.Context, payload *dto.UserResetPasswordRequest) (*dto.MessageResponse, error) {
 	if err := trxmanager.New(s.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
 		if ctx.Auth.RoleID != constant.ROLE_ID_ADMIN {
 			return response.ErrorBuilder(http.StatusBadRequest, errors.New("bad_request"), "this role is not permitted")
@@ -329,24 +396,27 @@ func (s *service) ResetPassword(ctx *abstraction.Context, payload *dto.UserReset
 		newUserData.Context = ctx
 		newUserData.ID = userData.ID
 		newUserData.Password = string(hashedPassword)
+		newUserData.Version = userData.Version
 
-		if err = s.UserRepository.Update(ctx, newUserData).Error; err != nil {
-			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		result := s.UserRepository.Update(ctx, newUserData)
+		if result.Error != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, result.Error, "server_error")
+		}
+		if result.RowsAffected == 0 {
+			return response.ErrorBuilder(http.StatusConflict, errors.New("conflict"), "user was changed meanwhile, try again")
 		}
 
-		if err = gomail.SendMail(userData.Email, "Reset Password for SelarasHomeId", general.ParseTemplateEmail("./assets/html/reset_password_admin.html", struct {
-			NAME      string
-			RESETNAME string
-			EMAIL     string
-			PASSWORD  string
-			LINK      string
-		}{
-			NAME:      userData.Name,
-			RESETNAME: userLogin.Name,
-			EMAIL:     userData.Email,
-			PASSWORD:  passwordString,
-			LINK:      constant.BASE_URL,
-		})); err != nil {
+		message, err := mailtemplate.Message(mailtemplate.TEMPLATE_RESET_PASSWORD_ADMIN, userData.Language, map[string]interface{}{
+			"NAME":      userData.Name,
+			"RESETNAME": userLogin.Name,
+			"EMAIL":     userData.Email,
+			"PASSWORD":  passwordString,
+			"LINK":      constant.BASE_URL,
+		}, userData.Email)
+		if err != nil {
+			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
+		}
+		if err = s.EmailOutboxRepository.Enqueue(ctx, message); err != nil {
 			return response.ErrorBuilder(http.StatusInternalServerError, err, "server_error")
 		}
 
@@ -354,7 +424,5 @@ func (s *service) ResetPassword(ctx *abstraction.Context, payload *dto.UserReset
 	}); err != nil {
 		return nil, err
 	}
-	return map[string]interface{}{
-		"message": "success reset password!",
-	}, nil
+	return &dto.MessageResponse{Message: "success reset password!"}, nil
 }
@@ -0,0 +1,137 @@
+package contact
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/factory"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/util/trxmanager"
+	"encoding/json"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	importInterval = 5 * time.Second
+
+	// importLease is how long a running job may go untouched before another worker takes it
+	// over, its first worker is then assumed to have died. The worker renews it after hashing
+	// every batch of credentials, the single transaction creating the users takes far less.
+	importLease = 10 * time.Minute
+)
+
+// ImportWorker runs the imports too large to be handled within their request.
+type ImportWorker struct {
+	ImportJobRepository repository.ImportJob
+
+	DB *gorm.DB
+
+	service *service
+}
+
+func NewImportWorker(f *factory.Factory) *ImportWorker {
+	return &ImportWorker{
+		ImportJobRepository: f.ImportJobRepository,
+
+		DB: f.Db,
+
+		service: newService(f),
+	}
+}
+
+// Start runs pending imports until ctx is cancelled.
+func (w *ImportWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(importInterval)
+	defer ticker.Stop()
+
+	logrus.Info("User import worker started")
+	for {
+		select {
+		case <-ctx.Done():
+			logrus.Info("User import worker stopped")
+			return
+		case <-ticker.C:
+			if err := w.Run(); err != nil {
+				logrus.Error("failed run user import: ", err)
+			}
+		}
+	}
+}
+
+// Run imports the next pending job, if any. A job ends as completed or failed, a failed job
+// keeps the reason in its error and, when the file could be read, the report of its rows. No user
+// of a failed job is created.
+func (w *ImportWorker) Run() error {
+	jobData, err := w.claim()
+	if err != nil || jobData == nil {
+		return err
+	}
+
+	rows, err := w.run(jobData)
+	now := time.Now()
+	jobData.FinishedAt = &now
+	jobData.Status = constant.IMPORT_STATUS_COMPLETED
+	if err != nil {
+		jobData.Status = constant.IMPORT_STATUS_FAILED
+		jobData.Error = err.Error()
+	}
+	if rows != nil {
+		count := countRows(rows)
+		report, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		jobData.Total = len(rows)
+		jobData.Valid = count[importRowValid]
+		jobData.Invalid = count[importRowInvalid]
+		jobData.Created = count[importRowCreated]
+		jobData.Report = string(report)
+	}
+	if err = w.ImportJobRepository.UpdateResult(&abstraction.Context{}, jobData).Error; err != nil {
+		return err
+	}
+	if err = w.service.Storage.Delete(context.Background(), jobData.StorageKey); err != nil {
+		logrus.Error("failed remove import file ", jobData.StorageKey, ": ", err)
+	}
+	return nil
+}
+
+// run imports the file of jobData, the rows are returned with the error too once they are read.
+func (w *ImportWorker) run(jobData *model.ImportJobEntityModel) ([]*importRow, error) {
+	content, _, err := w.service.Storage.Get(context.Background(), jobData.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	rows, err := readImport(content, jobData.Format)
+	if err != nil {
+		return nil, err
+	}
+	// renew the lease, so the job is not taken over while it is still running
+	heartbeat := func() error {
+		return w.ImportJobRepository.UpdateStatus(&abstraction.Context{}, jobData.ID, constant.IMPORT_STATUS_RUNNING).Error
+	}
+	return rows, w.service.runImport(&abstraction.Context{}, rows, jobData.DryRun, heartbeat)
+}
+
+// claim marks the next due job as running in a short transaction, so several workers never
+// pick the same job.
+func (w *ImportWorker) claim() (jobData *model.ImportJobEntityModel, err error) {
+	ctx := &abstraction.Context{}
+	err = trxmanager.New(w.DB).WithTrx(ctx, func(ctx *abstraction.Context) error {
+		jobData, err = w.ImportJobRepository.FindDueForUpdate(ctx, importKind, time.Now().Add(-importLease))
+		if err != nil {
+			if err.Error() == "record not found" {
+				jobData, err = nil, nil
+			}
+			return err
+		}
+		return w.ImportJobRepository.UpdateStatus(ctx, jobData.ID, constant.IMPORT_STATUS_RUNNING).Error
+	})
+	return
+}
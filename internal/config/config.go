@@ -17,6 +17,14 @@ type Configuration struct {
 	JWT     JWT
 	Gomail  Gomail
 	Drive   Drive
+	Outbox  Outbox
+	Storage Storage
+	Upload  Upload
+	Signing Signing
+	Mail    Mail
+	Brand   Brand
+	Trash   Trash
+	Seed    Seed
 }
 
 type App struct {
@@ -26,11 +34,14 @@ type App struct {
 }
 
 type DB struct {
-	DbHost string
-	DbUser string
-	DbPass string
-	DbPort string
-	DbName string
+	// DbDriver is mysql, postgres or sqlite, for sqlite DbName is the path of the database file
+	DbDriver  string
+	DbHost    string
+	DbUser    string
+	DbPass    string
+	DbPort    string
+	DbName    string
+	DbSslMode string
 }
 
 type Redis struct {
@@ -54,13 +65,75 @@ type Gomail struct {
 	SmtpHost     string
 	SmtpPort     string
 	SenderName   string
+	SenderEmail  string
 	AuthEmail    string
 	AuthPassword string
+	Driver       string
+	FileDir      string
 }
 
 type Drive struct {
 	CredentialsDrive  string
+	TokenDrive        string
 	RefreshTokenDrive string
+	SubjectDrive      string
+	DivisiFolderId    string
+	// KeepEmails are accounts whose access to divisi folders is never removed, comma separated
+	KeepEmails string
+}
+
+type Outbox struct {
+	Interval      string
+	BatchSize     string
+	MaxAttempts   string
+	RetentionDays string
+}
+
+type Storage struct {
+	Driver        string
+	LocalDir      string
+	DriveFolderId string
+	S3Endpoint    string
+	S3AccessKey   string
+	S3SecretKey   string
+	S3Bucket      string
+	S3Region      string
+	S3UseSSL      string
+}
+
+type Upload struct {
+	ClamdAddress string
+	TmpDir       string
+	Expire       string
+}
+
+type Signing struct {
+	Keys string
+}
+
+type Mail struct {
+	TemplateDir     string
+	DefaultLanguage string
+}
+
+type Brand struct {
+	AppName      string
+	LogoUrl      string
+	Footer       string
+	SupportEmail string
+	SupportPhone string
+}
+
+type Trash struct {
+	RetentionDays string
+	PurgeInterval string
+}
+
+// Seed is the initial admin created by migrate up.
+type Seed struct {
+	AdminName     string
+	AdminEmail    string
+	AdminPassword string
 }
 
 var lock = &sync.Mutex{}
@@ -90,11 +163,13 @@ func Init() *Configuration {
 	defaultConfig.App.App = os.Getenv("APP")
 	defaultConfig.App.Port = os.Getenv("PORT")
 	defaultConfig.App.Version = os.Getenv("VERSION")
+	defaultConfig.DB.DbDriver = os.Getenv("DB_DRIVER")
 	defaultConfig.DB.DbHost = os.Getenv("DB_HOST")
 	defaultConfig.DB.DbUser = os.Getenv("DB_USER")
 	defaultConfig.DB.DbPass = os.Getenv("DB_PASS")
 	defaultConfig.DB.DbPort = os.Getenv("DB_PORT")
 	defaultConfig.DB.DbName = os.Getenv("DB_NAME")
+	defaultConfig.DB.DbSslMode = os.Getenv("DB_SSLMODE")
 	defaultConfig.Redis.RedisHost = os.Getenv("REDIS_HOST")
 	defaultConfig.Redis.RedisUser = os.Getenv("REDIS_USER")
 	defaultConfig.Redis.RedisPassword = os.Getenv("REDIS_PASS")
@@ -106,12 +181,51 @@ func Init() *Configuration {
 	defaultConfig.Gomail.SmtpHost = os.Getenv("SMTP_HOST")
 	defaultConfig.Gomail.SmtpPort = os.Getenv("SMTP_PORT")
 	defaultConfig.Gomail.SenderName = os.Getenv("SENDER_NAME")
+	defaultConfig.Gomail.SenderEmail = os.Getenv("SENDER_EMAIL")
 	defaultConfig.Gomail.AuthEmail = os.Getenv("AUTH_EMAIL")
 	defaultConfig.Gomail.AuthPassword = os.Getenv("AUTH_PASSWORD")
+	defaultConfig.Gomail.Driver = os.Getenv("MAIL_DRIVER")
+	defaultConfig.Gomail.FileDir = os.Getenv("MAIL_FILE_DIR")
+	defaultConfig.Outbox.Interval = os.Getenv("OUTBOX_INTERVAL")
+	defaultConfig.Outbox.BatchSize = os.Getenv("OUTBOX_BATCH_SIZE")
+	defaultConfig.Outbox.MaxAttempts = os.Getenv("OUTBOX_MAX_ATTEMPTS")
+	defaultConfig.Outbox.RetentionDays = os.Getenv("OUTBOX_RETENTION_DAYS")
+	defaultConfig.Storage.Driver = os.Getenv("STORAGE_DRIVER")
+	defaultConfig.Storage.LocalDir = os.Getenv("STORAGE_LOCAL_DIR")
+	defaultConfig.Storage.DriveFolderId = os.Getenv("STORAGE_DRIVE_FOLDER_ID")
+	defaultConfig.Storage.S3Endpoint = os.Getenv("STORAGE_S3_ENDPOINT")
+	defaultConfig.Storage.S3AccessKey = os.Getenv("STORAGE_S3_ACCESS_KEY")
+	defaultConfig.Storage.S3SecretKey = os.Getenv("STORAGE_S3_SECRET_KEY")
+	defaultConfig.Storage.S3Bucket = os.Getenv("STORAGE_S3_BUCKET")
+	defaultConfig.Storage.S3Region = os.Getenv("STORAGE_S3_REGION")
+	defaultConfig.Storage.S3UseSSL = os.Getenv("STORAGE_S3_USE_SSL")
+	defaultConfig.Upload.ClamdAddress = os.Getenv("CLAMD_ADDRESS")
+	defaultConfig.Upload.TmpDir = os.Getenv("UPLOAD_TMP_DIR")
+	defaultConfig.Upload.Expire = os.Getenv("UPLOAD_EXPIRE")
+	defaultConfig.Signing.Keys = os.Getenv("SIGNING_KEYS")
+	defaultConfig.Mail.TemplateDir = os.Getenv("MAIL_TEMPLATE_DIR")
+	defaultConfig.Mail.DefaultLanguage = os.Getenv("MAIL_DEFAULT_LANGUAGE")
+	defaultConfig.Brand.AppName = os.Getenv("BRAND_APP_NAME")
+	if defaultConfig.Brand.AppName == "" {
+		defaultConfig.Brand.AppName = "Daarul Mukhtarin"
+	}
+	defaultConfig.Brand.LogoUrl = os.Getenv("BRAND_LOGO_URL")
+	defaultConfig.Brand.Footer = os.Getenv("BRAND_FOOTER")
+	defaultConfig.Brand.SupportEmail = os.Getenv("BRAND_SUPPORT_EMAIL")
+	defaultConfig.Brand.SupportPhone = os.Getenv("BRAND_SUPPORT_PHONE")
+	defaultConfig.Trash.RetentionDays = os.Getenv("TRASH_RETENTION_DAYS")
+	defaultConfig.Trash.PurgeInterval = os.Getenv("TRASH_PURGE_INTERVAL")
+	defaultConfig.Seed.AdminName = os.Getenv("SEED_ADMIN_NAME")
+	defaultConfig.Seed.AdminEmail = os.Getenv("SEED_ADMIN_EMAIL")
+	defaultConfig.Seed.AdminPassword = os.Getenv("SEED_ADMIN_PASSWORD")
 
 	// on development
 	defaultConfig.Drive.CredentialsDrive = os.Getenv("CREDENTIALS_DRIVE")
+	defaultConfig.Drive.TokenDrive = os.Getenv("TOKEN_DRIVE")
 	defaultConfig.Drive.RefreshTokenDrive = os.Getenv("REFRESH_DRIVE")
+	defaultConfig.Drive.SubjectDrive = os.Getenv("SUBJECT_DRIVE")
+	defaultConfig.Drive.DivisiFolderId = os.Getenv("DRIVE_DIVISI_FOLDER_ID")
+	defaultConfig.Drive.KeepEmails = os.Getenv("DRIVE_KEEP_EMAILS")
 
 	return &defaultConfig
 }
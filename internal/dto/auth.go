@@ -55,3 +55,12 @@ type AuthSendEmailForgotPasswordRequest struct {
 type AuthValidationResetPasswordRequest struct {
 	Token string `param:"token" validate:"required"`
 }
+
+type AuthTokenResponse struct {
+	Token string `json:"token"`
+}
+
+type AuthLoginResponse struct {
+	Token string        `json:"token"`
+	User  *UserResponse `json:"user"`
+}
@@ -1,9 +1,18 @@
 package dto
 
+import (
+	"daarul_mukhtarin/internal/model"
+	"time"
+)
+
 type DivisiCreateRequest struct {
 	Name *string `json:"name" form:"name" validate:"required"`
 }
 
+type DivisiFindByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
 type DivisiUpdateRequest struct {
 	ID   int     `param:"id" validate:"required"`
 	Name *string `json:"name" form:"name"`
@@ -12,3 +21,36 @@ type DivisiUpdateRequest struct {
 type DivisiDeleteByIDRequest struct {
 	ID int `param:"id" validate:"required"`
 }
+
+type DivisiResponse struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	DriveFolderId string     `json:"drive_folder_id"`
+	IsDelete      bool       `json:"is_delete"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+	Version       int        `json:"version"`
+}
+
+func NewDivisiResponse(data *model.DivisiEntityModel) *DivisiResponse {
+	if data == nil {
+		return nil
+	}
+	return &DivisiResponse{
+		ID:            data.ID,
+		Name:          data.Name,
+		DriveFolderId: data.DriveFolderId,
+		IsDelete:      data.IsDelete,
+		CreatedAt:     data.CreatedAt,
+		UpdatedAt:     data.UpdatedAt,
+		Version:       data.Version,
+	}
+}
+
+func NewDivisiResponses(data []*model.DivisiEntityModel) []*DivisiResponse {
+	res := make([]*DivisiResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewDivisiResponse(v))
+	}
+	return res
+}
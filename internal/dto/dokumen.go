@@ -0,0 +1,191 @@
+package dto
+
+import (
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/upload"
+	"time"
+)
+
+type DokumenUploadRequest struct {
+	FolderId *int `json:"folder_id" form:"folder_id"`
+	DivisiId *int `json:"divisi_id" form:"divisi_id"`
+}
+
+type DokumenFindByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type DokumenDeleteByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type DokumenFolderCreateRequest struct {
+	Name     string `json:"name" form:"name" validate:"required,max=255"`
+	ParentId *int   `json:"parent_id" form:"parent_id"`
+	DivisiId *int   `json:"divisi_id" form:"divisi_id"`
+}
+
+type DokumenFolderFindRequest struct {
+	DivisiId *int `query:"divisi_id"`
+}
+
+type DokumenFolderDeleteByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type DokumenUploadInitiateRequest struct {
+	Name     string `json:"name" form:"name" validate:"required,max=255"`
+	Size     int64  `json:"size" form:"size" validate:"required,min=1"`
+	FolderId *int   `json:"folder_id" form:"folder_id"`
+	DivisiId *int   `json:"divisi_id" form:"divisi_id"`
+}
+
+type DokumenUploadSessionRequest struct {
+	ID string `param:"id" validate:"required"`
+}
+
+type DokumenUploadPartRequest struct {
+	ID   string `param:"id" validate:"required"`
+	Part int    `param:"part" validate:"required,min=1,max=10000"`
+}
+
+type DokumenCreateLinkRequest struct {
+	ID        int  `param:"id" validate:"required"`
+	ExpiresIn int  `json:"expires_in" form:"expires_in" validate:"omitempty,min=60,max=604800"`
+	SingleUse bool `json:"single_use" form:"single_use"`
+}
+
+type DokumenRevokeLinkRequest struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+type DokumenDownloadLinkRequest struct {
+	Token string `param:"token" validate:"required"`
+}
+
+type DokumenResponse struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Size      int64      `json:"size"`
+	MimeType  string     `json:"mime_type"`
+	Checksum  string     `json:"checksum"`
+	FolderId  *int       `json:"folder_id"`
+	DivisiId  int        `json:"divisi_id"`
+	UserId    int        `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func NewDokumenResponse(data *model.DokumenEntityModel) *DokumenResponse {
+	if data == nil {
+		return nil
+	}
+	return &DokumenResponse{
+		ID:        data.ID,
+		Name:      data.Name,
+		Size:      data.Size,
+		MimeType:  data.MimeType,
+		Checksum:  data.Checksum,
+		FolderId:  data.FolderId,
+		DivisiId:  data.DivisiId,
+		UserId:    data.UserId,
+		CreatedAt: data.CreatedAt,
+		UpdatedAt: data.UpdatedAt,
+	}
+}
+
+func NewDokumenResponses(data []*model.DokumenEntityModel) []*DokumenResponse {
+	res := make([]*DokumenResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewDokumenResponse(v))
+	}
+	return res
+}
+
+// DokumenFolderResponse is a folder with its subfolders.
+type DokumenFolderResponse struct {
+	ID        int                      `json:"id"`
+	Name      string                   `json:"name"`
+	ParentId  *int                     `json:"parent_id"`
+	DivisiId  int                      `json:"divisi_id"`
+	CreatedBy int                      `json:"created_by"`
+	CreatedAt time.Time                `json:"created_at"`
+	Children  []*DokumenFolderResponse `json:"children"`
+}
+
+// NewDokumenFolderTree nests folders under their parent, the ones without a parent are the roots.
+func NewDokumenFolderTree(data []*model.DokumenFolderEntityModel) []*DokumenFolderResponse {
+	children := make(map[int][]*model.DokumenFolderEntityModel)
+	var roots []*model.DokumenFolderEntityModel
+	for _, v := range data {
+		if v.ParentId == nil {
+			roots = append(roots, v)
+		} else {
+			children[*v.ParentId] = append(children[*v.ParentId], v)
+		}
+	}
+
+	var tree func(folders []*model.DokumenFolderEntityModel) []*DokumenFolderResponse
+	tree = func(folders []*model.DokumenFolderEntityModel) []*DokumenFolderResponse {
+		res := make([]*DokumenFolderResponse, 0, len(folders))
+		for _, v := range folders {
+			res = append(res, &DokumenFolderResponse{
+				ID:        v.ID,
+				Name:      v.Name,
+				ParentId:  v.ParentId,
+				DivisiId:  v.DivisiId,
+				CreatedBy: v.CreatedBy,
+				CreatedAt: v.CreatedAt,
+				Children:  tree(children[v.ID]),
+			})
+		}
+		return res
+	}
+	return tree(roots)
+}
+
+// DokumenUploadSessionResponse is the state of a resumable upload, received and parts only
+// count while it is pending.
+type DokumenUploadSessionResponse struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Size      int64         `json:"size"`
+	Received  int64         `json:"received"`
+	Parts     []upload.Part `json:"parts"`
+	PartSize  int64         `json:"part_size"`
+	Status    string        `json:"status"`
+	DokumenId *int          `json:"dokumen_id"`
+	ExpiredAt time.Time     `json:"expired_at"`
+}
+
+func NewDokumenUploadSessionResponse(data *model.UploadSessionEntityModel, parts []upload.Part) *DokumenUploadSessionResponse {
+	if parts == nil {
+		parts = []upload.Part{}
+	}
+	var received int64
+	for _, part := range parts {
+		received += part.Size
+	}
+	return &DokumenUploadSessionResponse{
+		ID:        data.Uid,
+		Name:      data.Name,
+		Size:      data.Size,
+		Received:  received,
+		Parts:     parts,
+		PartSize:  upload.PART_SIZE,
+		Status:    data.Status,
+		DokumenId: data.DokumenId,
+		ExpiredAt: data.ExpiredAt,
+	}
+}
+
+type DokumenUploadPartResponse struct {
+	Part int   `json:"part"`
+	Size int64 `json:"size"`
+}
+
+type DokumenLinkResponse struct {
+	Url       string    `json:"url"`
+	SingleUse bool      `json:"single_use"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
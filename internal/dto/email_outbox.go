@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"daarul_mukhtarin/internal/model"
+	"time"
+)
+
+type EmailOutboxFindRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending sent dead"`
+}
+
+type EmailOutboxResendRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type EmailOutboxResponse struct {
+	ID            int        `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+}
+
+func NewEmailOutboxResponse(data *model.EmailOutboxEntityModel) *EmailOutboxResponse {
+	if data == nil {
+		return nil
+	}
+	return &EmailOutboxResponse{
+		ID:            data.ID,
+		Recipient:     data.Recipient,
+		Subject:       data.Subject,
+		Status:        data.Status,
+		Attempts:      data.Attempts,
+		NextAttemptAt: data.NextAttemptAt,
+		LastError:     data.LastError,
+		SentAt:        data.SentAt,
+		CreatedAt:     data.CreatedAt,
+		UpdatedAt:     data.UpdatedAt,
+	}
+}
+
+func NewEmailOutboxResponses(data []*model.EmailOutboxEntityModel) []*EmailOutboxResponse {
+	res := make([]*EmailOutboxResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewEmailOutboxResponse(v))
+	}
+	return res
+}
@@ -0,0 +1,109 @@
+package dto
+
+import (
+	"daarul_mukhtarin/internal/model"
+	"time"
+)
+
+type EmailTemplateCreateRequest struct {
+	Name     string `json:"name" form:"name" validate:"required"`
+	Language string `json:"language" form:"language" validate:"required,oneof=id en"`
+	Subject  string `json:"subject" form:"subject" validate:"required"`
+	BodyHtml string `json:"body_html" form:"body_html" validate:"required"`
+}
+
+type EmailTemplateFindByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type EmailTemplateUpdateRequest struct {
+	ID       int     `param:"id" validate:"required"`
+	Subject  *string `json:"subject" form:"subject"`
+	BodyHtml *string `json:"body_html" form:"body_html"`
+}
+
+type EmailTemplateDeleteByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type EmailTemplateRestoreRequest struct {
+	ID      int `param:"id" validate:"required"`
+	Version int `param:"version" validate:"required"`
+}
+
+type EmailTemplatePreviewRequest struct {
+	Name     string                 `json:"name" form:"name" validate:"required"`
+	Language string                 `json:"language" form:"language" validate:"omitempty,oneof=id en"`
+	Subject  string                 `json:"subject" form:"subject"`
+	BodyHtml string                 `json:"body_html" form:"body_html"`
+	Data     map[string]interface{} `json:"data" form:"data"`
+}
+
+type EmailTemplateResponse struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Language  string     `json:"language"`
+	Subject   string     `json:"subject"`
+	Version   int        `json:"version"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// EmailTemplateDetailResponse is a template with its body, placeholders and every version.
+type EmailTemplateDetailResponse struct {
+	EmailTemplateResponse
+	BodyHtml     string                          `json:"body_html"`
+	Placeholders []string                        `json:"placeholders"`
+	Versions     []*EmailTemplateVersionResponse `json:"versions"`
+}
+
+type EmailTemplateVersionResponse struct {
+	Version   int       `json:"version"`
+	Subject   string    `json:"subject"`
+	BodyHtml  string    `json:"body_html"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type EmailTemplatePreviewResponse struct {
+	Subject      string   `json:"subject"`
+	BodyHtml     string   `json:"body_html"`
+	Placeholders []string `json:"placeholders"`
+}
+
+func NewEmailTemplateResponse(data *model.EmailTemplateEntityModel) *EmailTemplateResponse {
+	if data == nil {
+		return nil
+	}
+	return &EmailTemplateResponse{
+		ID:        data.ID,
+		Name:      data.Name,
+		Language:  data.Language,
+		Subject:   data.Subject,
+		Version:   data.Version,
+		CreatedAt: data.CreatedAt,
+		UpdatedAt: data.UpdatedAt,
+	}
+}
+
+func NewEmailTemplateResponses(data []*model.EmailTemplateEntityModel) []*EmailTemplateResponse {
+	res := make([]*EmailTemplateResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewEmailTemplateResponse(v))
+	}
+	return res
+}
+
+func NewEmailTemplateVersionResponses(data []*model.EmailTemplateVersionEntityModel) []*EmailTemplateVersionResponse {
+	res := make([]*EmailTemplateVersionResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, &EmailTemplateVersionResponse{
+			Version:   v.Version,
+			Subject:   v.Subject,
+			BodyHtml:  v.BodyHtml,
+			CreatedBy: v.CreatedBy,
+			CreatedAt: v.CreatedAt,
+		})
+	}
+	return res
+}
@@ -1,5 +1,52 @@
 package dto
 
+import (
+	"daarul_mukhtarin/internal/model"
+	"time"
+)
+
 type NotifikasiSetReadRequest struct {
 	ID int `param:"id" validate:"required"`
 }
+
+type NotifikasiResponse struct {
+	ID        int        `json:"id"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	IsRead    bool       `json:"is_read"`
+	UserId    int        `json:"user_id"`
+	Link      string     `json:"link"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// NotifikasiSummaryResponse is sent as the summary of the notifikasi list.
+type NotifikasiSummaryResponse struct {
+	CountTotal  int `json:"count_total"`
+	CountRead   int `json:"count_read"`
+	CountUnread int `json:"count_unread"`
+}
+
+func NewNotifikasiResponse(data *model.NotifikasiEntityModel) *NotifikasiResponse {
+	if data == nil {
+		return nil
+	}
+	return &NotifikasiResponse{
+		ID:        data.ID,
+		Title:     data.Title,
+		Message:   data.Message,
+		IsRead:    data.IsRead,
+		UserId:    data.UserId,
+		Link:      data.Link,
+		CreatedAt: data.CreatedAt,
+		UpdatedAt: data.UpdatedAt,
+	}
+}
+
+func NewNotifikasiResponses(data []*model.NotifikasiEntityModel) []*NotifikasiResponse {
+	res := make([]*NotifikasiResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewNotifikasiResponse(v))
+	}
+	return res
+}
@@ -0,0 +1,12 @@
+package dto
+
+// MessageResponse is the data of an endpoint that only tells it succeeded.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// RefResponse is a related record shown by its id and name.
+type RefResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
@@ -1,6 +1,39 @@
 package dto
 
+import "daarul_mukhtarin/internal/model"
+
+type RoleFindByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
 type RoleUpdateRequest struct {
 	ID   int     `param:"id" validate:"required"`
 	Name *string `json:"name" form:"name"`
 }
+
+type RoleResponse struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	IsDelete bool   `json:"is_delete"`
+	Version  int    `json:"version"`
+}
+
+func NewRoleResponse(data *model.RoleEntityModel) *RoleResponse {
+	if data == nil {
+		return nil
+	}
+	return &RoleResponse{
+		ID:       data.ID,
+		Name:     data.Name,
+		IsDelete: data.IsDelete,
+		Version:  data.Version,
+	}
+}
+
+func NewRoleResponses(data []*model.RoleEntityModel) []*RoleResponse {
+	res := make([]*RoleResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewRoleResponse(v))
+	}
+	return res
+}
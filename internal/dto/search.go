@@ -0,0 +1,17 @@
+package dto
+
+type SearchRequest struct {
+	Q     string `query:"q" validate:"required,max=100"`
+	Type  string `query:"type"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+type SearchResultResponse struct {
+	Type     string  `json:"type"`
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	DivisiId int     `json:"divisi_id"`
+	Score    float64 `json:"score"`
+	Url      string  `json:"url"`
+}
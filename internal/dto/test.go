@@ -7,7 +7,11 @@ type TestResponse struct {
 }
 
 type TestGomailRequest struct {
-	Recipient string `json:"recipient"`
+	Recipient   string   `json:"recipient"`
+	Cc          []string `json:"cc"`
+	Bcc         []string `json:"bcc"`
+	ReplyTo     string   `json:"reply_to"`
+	DriveFileId string   `json:"drive_file_id"`
 }
 
 type TestDriveRequest struct {
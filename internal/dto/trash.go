@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"daarul_mukhtarin/internal/model"
+	"time"
+)
+
+type TrashByIDRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type TrashUserResponse struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	DeletedAt *time.Time  `json:"deleted_at"`
+	Role      RefResponse `json:"role"`
+	Divisi    RefResponse `json:"divisi"`
+}
+
+type TrashDivisiResponse struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+func NewTrashUserResponses(data []*model.UserEntityModel) []*TrashUserResponse {
+	res := make([]*TrashUserResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, &TrashUserResponse{
+			ID:        v.ID,
+			Name:      v.Name,
+			Email:     v.Email,
+			DeletedAt: v.DeletedAt,
+			Role:      RefResponse{ID: v.Role.ID, Name: v.Role.Name},
+			Divisi:    RefResponse{ID: v.Divisi.ID, Name: v.Divisi.Name},
+		})
+	}
+	return res
+}
+
+func NewTrashDivisiResponses(data []*model.DivisiEntityModel) []*TrashDivisiResponse {
+	res := make([]*TrashDivisiResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, &TrashDivisiResponse{
+			ID:        v.ID,
+			Name:      v.Name,
+			DeletedAt: v.DeletedAt,
+		})
+	}
+	return res
+}
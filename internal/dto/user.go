@@ -1,10 +1,18 @@
 package dto
 
+import (
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/avatar"
+	"daarul_mukhtarin/pkg/constant"
+	"time"
+)
+
 type UserCreateRequest struct {
 	Name     string `json:"name" form:"name" validate:"required"`
 	Email    string `json:"email" form:"email" validate:"required"`
 	RoleId   int    `json:"role_id" form:"role_id"`
 	DivisiId int    `json:"divisi_id" form:"divisi_id"`
+	Language string `json:"language" form:"language" validate:"omitempty,oneof=id en"`
 }
 
 type UserFindByIDRequest struct {
@@ -18,6 +26,7 @@ type UserUpdateRequest struct {
 	RoleId   *int    `json:"role_id" form:"role_id"`
 	DivisiId *int    `json:"divisi_id" form:"divisi_id"`
 	IsLocked *bool   `json:"is_locked" form:"is_locked"`
+	Language *string `json:"language" form:"language" validate:"omitempty,oneof=id en"`
 }
 
 type UserDeleteByIDRequest struct {
@@ -33,3 +42,162 @@ type UserChangePasswordRequest struct {
 type UserResetPasswordRequest struct {
 	ID int `param:"id" validate:"required"`
 }
+
+type UserUpdateAvatarRequest struct {
+	ID int `param:"id" validate:"required"`
+}
+
+type UserAvatarRequest struct {
+	ID   int `param:"id" validate:"required"`
+	Size int `query:"size" validate:"omitempty,min=1"`
+}
+
+type UserImportRequest struct {
+	DryRun bool `form:"dry_run" query:"dry_run"`
+}
+
+type UserImportJobRequest struct {
+	ID string `param:"id" validate:"required"`
+}
+
+type UserBulkRequest struct {
+	Action   string            `json:"action" form:"action" validate:"required,oneof=lock unlock delete move_divisi change_role"`
+	Ids      []int             `json:"ids" form:"ids"`
+	Filter   map[string]string `json:"filter"`
+	DivisiId int               `json:"divisi_id" form:"divisi_id"`
+	RoleId   int               `json:"role_id" form:"role_id"`
+	Preview  bool              `json:"preview" form:"preview" query:"preview"`
+}
+
+type UserResponse struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Email     string      `json:"email"`
+	IsDelete  bool        `json:"is_delete"`
+	IsLocked  bool        `json:"is_locked"`
+	LoginFrom string      `json:"login_from"`
+	Language  string      `json:"language"`
+	AvatarUrl *string     `json:"avatar_url"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt *time.Time  `json:"updated_at"`
+	Version   int         `json:"version"`
+	RoleId    int         `json:"role_id"`
+	DivisiId  int         `json:"divisi_id"`
+	Role      RefResponse `json:"role"`
+	Divisi    RefResponse `json:"divisi"`
+}
+
+// NewUserResponse maps a user with its role and divisi preloaded, nil stays nil.
+func NewUserResponse(data *model.UserEntityModel) *UserResponse {
+	if data == nil {
+		return nil
+	}
+	return &UserResponse{
+		ID:        data.ID,
+		Name:      data.Name,
+		Email:     data.Email,
+		IsDelete:  data.IsDelete,
+		IsLocked:  data.IsLocked,
+		LoginFrom: data.LoginFrom,
+		Language:  data.Language,
+		AvatarUrl: avatar.URL(data.ID, data.Avatar),
+		CreatedAt: data.CreatedAt,
+		UpdatedAt: data.UpdatedAt,
+		Version:   data.Version,
+		RoleId:    data.RoleId,
+		DivisiId:  data.DivisiId,
+		Role:      RefResponse{ID: data.Role.ID, Name: data.Role.Name},
+		Divisi:    RefResponse{ID: data.Divisi.ID, Name: data.Divisi.Name},
+	}
+}
+
+func NewUserResponses(data []*model.UserEntityModel) []*UserResponse {
+	res := make([]*UserResponse, 0, len(data))
+	for _, v := range data {
+		res = append(res, NewUserResponse(v))
+	}
+	return res
+}
+
+type UserAvatarResponse struct {
+	Message   string  `json:"message"`
+	AvatarUrl *string `json:"avatar_url"`
+}
+
+// UserImportRow is one data row of an import file and its outcome.
+type UserImportRow struct {
+	Row      int      `json:"row"`
+	Name     string   `json:"name"`
+	Email    string   `json:"email"`
+	RoleId   int      `json:"role_id"`
+	DivisiId int      `json:"divisi_id"`
+	Language string   `json:"language"`
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+type UserImportReportResponse struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Invalid int              `json:"invalid"`
+	Created int              `json:"created"`
+	Rows    []*UserImportRow `json:"rows"`
+}
+
+type ImportJobResponse struct {
+	ID         string           `json:"id"`
+	Url        string           `json:"url"`
+	Status     string           `json:"status"`
+	FileName   string           `json:"file_name"`
+	DryRun     bool             `json:"dry_run"`
+	Total      int              `json:"total"`
+	Valid      int              `json:"valid"`
+	Invalid    int              `json:"invalid"`
+	Created    int              `json:"created"`
+	Error      string           `json:"error"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+	Rows       []*UserImportRow `json:"rows,omitempty"`
+}
+
+// NewImportJobResponse maps a job without its report, which the caller decodes.
+func NewImportJobResponse(data *model.ImportJobEntityModel) *ImportJobResponse {
+	if data == nil {
+		return nil
+	}
+	return &ImportJobResponse{
+		ID:         data.Uid,
+		Url:        constant.BASE_URL + "/" + data.Kind + "/import/" + data.Uid,
+		Status:     data.Status,
+		FileName:   data.FileName,
+		DryRun:     data.DryRun,
+		Total:      data.Total,
+		Valid:      data.Valid,
+		Invalid:    data.Invalid,
+		Created:    data.Created,
+		Error:      data.Error,
+		CreatedAt:  data.CreatedAt,
+		FinishedAt: data.FinishedAt,
+	}
+}
+
+// UserBulkItem is the outcome of a bulk action on one user, in preview what would happen.
+type UserBulkItem struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type UserBulkResponse struct {
+	Action       string          `json:"action"`
+	Preview      bool            `json:"preview"`
+	Total        int             `json:"total"`
+	Changed      int             `json:"changed"`
+	Unchanged    int             `json:"unchanged"`
+	NotFound     int             `json:"not_found"`
+	NotPermitted int             `json:"not_permitted"`
+	Items        []*UserBulkItem `json:"items"`
+}
@@ -1,8 +1,15 @@
 package factory
 
 import (
+	"crypto/sha256"
+	"daarul_mukhtarin/internal/config"
 	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/database"
+	"daarul_mukhtarin/pkg/gdrive"
+	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/signedurl"
+	"daarul_mukhtarin/pkg/storage"
+	"daarul_mukhtarin/pkg/upload"
 
 	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
@@ -13,28 +20,50 @@ type Factory struct {
 
 	DbRedis *redis.Client
 
+	Mailer gomail.Mailer
+
+	Storage storage.Storage
+
+	Scanner upload.Scanner
+
+	Chunks *upload.Chunks
+
+	Signer *signedurl.Signer
+
 	// repository
 	Repository_initiated
 }
 
 type Repository_initiated struct {
-	TestRepository       repository.Test
-	UserRepository       repository.User
-	DivisiRepository     repository.Divisi
-	RoleRepository       repository.Role
-	NotifikasiRepository repository.Notifikasi
+	TestRepository          repository.Test
+	UserRepository          repository.User
+	DivisiRepository        repository.Divisi
+	RoleRepository          repository.Role
+	NotifikasiRepository    repository.Notifikasi
+	EmailOutboxRepository   repository.EmailOutbox
+	EmailTemplateRepository repository.EmailTemplate
+	DokumenRepository       repository.Dokumen
+	UploadSessionRepository repository.UploadSession
+	SearchRepository        repository.Search
+	ImportJobRepository     repository.ImportJob
 }
 
 func NewFactory() *Factory {
 	f := &Factory{}
 	f.SetupDb()
 	f.SetupDbRedis()
+	f.SetupMailer()
+	f.SetupDrive()
+	f.SetupStorage()
+	f.SetupScanner()
+	f.SetupChunks()
+	f.SetupSigner()
 	f.SetupRepository()
 	return f
 }
 
 func (f *Factory) SetupDb() {
-	db, err := database.Connection("MYSQL")
+	db, err := database.Connection(database.Driver())
 	if err != nil {
 		panic("Failed setup db, connection is undefined")
 	}
@@ -46,6 +75,55 @@ func (f *Factory) SetupDbRedis() {
 	f.DbRedis = dbRedis
 }
 
+func (f *Factory) SetupMailer() {
+	mailer, err := gomail.NewMailer()
+	if err != nil {
+		panic("Failed setup mailer, " + err.Error())
+	}
+	f.Mailer = mailer
+}
+
+func (f *Factory) SetupDrive() {
+	if f.DbRedis == nil {
+		panic("Failed setup drive, redis is undefined")
+	}
+	gdrive.SetTokenStore(gdrive.NewRedisTokenStore(f.DbRedis))
+}
+
+func (f *Factory) SetupStorage() {
+	storage, err := storage.NewStorage()
+	if err != nil {
+		panic("Failed setup storage, " + err.Error())
+	}
+	f.Storage = storage
+}
+
+func (f *Factory) SetupScanner() {
+	f.Scanner = upload.NewScanner(config.Get().Upload.ClamdAddress)
+}
+
+func (f *Factory) SetupChunks() {
+	chunks, err := upload.NewChunks(config.Get().Upload.TmpDir)
+	if err != nil {
+		panic("Failed setup chunks, " + err.Error())
+	}
+	f.Chunks = chunks
+}
+
+func (f *Factory) SetupSigner() {
+	keys := signedurl.ParseKeys(config.Get().Signing.Keys)
+	if len(keys) == 0 && config.Get().JWT.SecretKey != "" {
+		// derive a key so signed links work before SIGNING_KEYS is configured
+		secret := sha256.Sum256([]byte("signedurl:" + config.Get().JWT.SecretKey))
+		keys = append(keys, signedurl.Key{Id: "default", Secret: secret[:]})
+	}
+	signer, err := signedurl.NewSigner(keys)
+	if err != nil {
+		panic("Failed setup signer, " + err.Error())
+	}
+	f.Signer = signer
+}
+
 func (f *Factory) SetupRepository() {
 	if f.Db == nil {
 		panic("Failed setup repository, db is undefined")
@@ -56,4 +134,10 @@ func (f *Factory) SetupRepository() {
 	f.DivisiRepository = repository.NewDivisi(f.Db)
 	f.RoleRepository = repository.NewRole(f.Db)
 	f.NotifikasiRepository = repository.NewNotifikasi(f.Db)
+	f.EmailOutboxRepository = repository.NewEmailOutbox(f.Db)
+	f.EmailTemplateRepository = repository.NewEmailTemplate(f.Db)
+	f.DokumenRepository = repository.NewDokumen(f.Db)
+	f.UploadSessionRepository = repository.NewUploadSession(f.Db)
+	f.SearchRepository = repository.NewSearch(f.Db)
+	f.ImportJobRepository = repository.NewImportJob(f.Db)
 }
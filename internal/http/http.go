@@ -7,9 +7,14 @@ import (
 	_ "daarul_mukhtarin/docs"
 	"daarul_mukhtarin/internal/app/auth"
 	"daarul_mukhtarin/internal/app/divisi"
+	"daarul_mukhtarin/internal/app/dokumen"
+	"daarul_mukhtarin/internal/app/emailtemplate"
 	"daarul_mukhtarin/internal/app/notifikasi"
+	"daarul_mukhtarin/internal/app/outbox"
 	"daarul_mukhtarin/internal/app/role"
+	"daarul_mukhtarin/internal/app/search"
 	"daarul_mukhtarin/internal/app/test"
+	"daarul_mukhtarin/internal/app/trash"
 	user "daarul_mukhtarin/internal/app/user"
 	"daarul_mukhtarin/internal/config"
 	"daarul_mukhtarin/internal/factory"
@@ -32,7 +37,14 @@ func Init(e *echo.Echo, f *factory.Factory) {
 	test.NewHandler(f).Route(e.Group("/test"))
 	auth.NewHandler(f).Route(e.Group("/auth"))
 	user.NewHandler(f).Route(e.Group("/user"))
+	user.NewHandler(f).RouteMe(e.Group("/me"))
 	role.NewHandler(f).Route(e.Group("/role"))
 	divisi.NewHandler(f).Route(e.Group("/divisi"))
 	notifikasi.NewHandler(f).Route(e.Group("/notifikasi"))
+	outbox.NewHandler(f).Route(e.Group("/outbox"))
+	emailtemplate.NewHandler(f).Route(e.Group("/email-template"))
+	dokumen.NewHandler(f).Route(e.Group("/dokumen"))
+	dokumen.NewHandler(f).RouteDownload(e.Group("/download"))
+	search.NewHandler(f).Route(e.Group("/search"))
+	trash.NewHandler(f).Route(e.Group("/trash"))
 }
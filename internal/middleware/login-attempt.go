@@ -13,13 +13,14 @@ import (
 
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
 	"daarul_mukhtarin/pkg/database"
-	"daarul_mukhtarin/pkg/gomail"
-	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/mailtemplate"
 	"daarul_mukhtarin/pkg/util/response"
 
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
+	"gorm.io/gorm"
 )
 
 type LoginAttemptStore interface {
@@ -355,14 +356,23 @@ func (store *LoginAttemptMemoryStore) IncreaseAttempt(c echo.Context, identifier
 			case 1 * time.Minute:
 				user.LockDuration = 15 * time.Minute
 			case 15 * time.Minute:
-				err = conn.Model(userEntityModel).Where("email = ?", email).Update("is_locked", true).Error
-				err = gomail.SendMail(email, "Account Locked for SelarasHomeId", general.ParseTemplateEmail("./assets/html/notification_locked_user.html", struct {
-					NAME  string
-					EMAIL string
-				}{
-					NAME:  userEntityModel.Name,
-					EMAIL: userEntityModel.Email,
-				}))
+				// the mail goes through the outbox with the lock, the login answers without waiting for smtp
+				err = conn.Transaction(func(tx *gorm.DB) error {
+					if err := tx.Model(userEntityModel).Where("email = ?", email).Updates(map[string]interface{}{
+						"is_locked": true,
+						"version":   gorm.Expr("version + 1"),
+					}).Error; err != nil {
+						return err
+					}
+					message, err := mailtemplate.Message(mailtemplate.TEMPLATE_LOCKED_USER, userEntityModel.Language, map[string]interface{}{
+						"NAME":  userEntityModel.Name,
+						"EMAIL": userEntityModel.Email,
+					}, email)
+					if err != nil {
+						return err
+					}
+					return repository.NewEmailOutbox(tx).Enqueue(&abstraction.Context{}, message)
+				})
 				user.Locked = true
 			default:
 				user.LockDuration = 1 * time.Minute
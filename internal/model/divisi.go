@@ -1,10 +1,17 @@
 package model
 
-import "daarul_mukhtarin/internal/abstraction"
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"time"
+)
 
 type DivisiEntity struct {
-	Name     string `json:"name"`
-	IsDelete bool   `json:"is_delete"`
+	Name          string     `json:"name"`
+	DriveFolderId string     `json:"drive_folder_id"`
+	IsDelete      bool       `json:"is_delete"`
+	DeletedAt     *time.Time `json:"deleted_at"`
+	// Version goes up on every change, it is the ETag of the record
+	Version int `json:"version"`
 }
 
 // DivisiEntityModel ...
@@ -0,0 +1,63 @@
+package model
+
+import "daarul_mukhtarin/internal/abstraction"
+
+type DokumenEntity struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	MimeType   string `json:"mime_type"`
+	Checksum   string `json:"checksum"`
+	StorageKey string `json:"storage_key"`
+	FolderId   *int   `json:"folder_id"`
+	DivisiId   int    `json:"divisi_id"`
+	UserId     int    `json:"user_id"`
+	IsDelete   bool   `json:"is_delete"`
+}
+
+// DokumenEntityModel ...
+type DokumenEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	DokumenEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (DokumenEntityModel) TableName() string {
+	return "dokumen"
+}
+
+type DokumenCountDataModel struct {
+	Count int `json:"count"`
+}
+
+type DokumenFolderEntity struct {
+	Name      string `json:"name"`
+	ParentId  *int   `json:"parent_id"`
+	DivisiId  int    `json:"divisi_id"`
+	CreatedBy int    `json:"created_by"`
+	IsDelete  bool   `json:"is_delete"`
+}
+
+// DokumenFolderEntityModel ...
+type DokumenFolderEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	DokumenFolderEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (DokumenFolderEntityModel) TableName() string {
+	return "dokumen_folder"
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"time"
+)
+
+type EmailOutboxEntity struct {
+	FromName      string     `json:"from_name"`
+	FromAddress   string     `json:"from_address"`
+	Recipient     string     `json:"recipient"`
+	Cc            string     `json:"cc"`
+	Bcc           string     `json:"bcc"`
+	ReplyTo       string     `json:"reply_to"`
+	Subject       string     `json:"subject"`
+	BodyHtml      string     `json:"body_html"`
+	BodyText      string     `json:"body_text"`
+	Attachments   string     `json:"attachments"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// EmailOutboxEntityModel ...
+type EmailOutboxEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	EmailOutboxEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (EmailOutboxEntityModel) TableName() string {
+	return "email_outbox"
+}
+
+type EmailOutboxCountDataModel struct {
+	Count int `json:"count"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import "daarul_mukhtarin/internal/abstraction"
+
+type EmailTemplateEntity struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Subject  string `json:"subject"`
+	BodyHtml string `json:"body_html"`
+	Version  int    `json:"version"`
+	IsDelete bool   `json:"is_delete"`
+}
+
+// EmailTemplateEntityModel ...
+type EmailTemplateEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	EmailTemplateEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (EmailTemplateEntityModel) TableName() string {
+	return "email_template"
+}
+
+type EmailTemplateCountDataModel struct {
+	Count int `json:"count"`
+}
+
+type EmailTemplateVersionEntity struct {
+	EmailTemplateId int    `json:"email_template_id"`
+	Version         int    `json:"version"`
+	Subject         string `json:"subject"`
+	BodyHtml        string `json:"body_html"`
+	CreatedBy       int    `json:"created_by"`
+}
+
+// EmailTemplateVersionEntityModel ...
+type EmailTemplateVersionEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	EmailTemplateVersionEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (EmailTemplateVersionEntityModel) TableName() string {
+	return "email_template_version"
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"time"
+)
+
+type ImportJobEntity struct {
+	Uid        string     `json:"uid"`
+	Kind       string     `json:"kind"`
+	UserId     int        `json:"user_id"`
+	FileName   string     `json:"file_name"`
+	StorageKey string     `json:"storage_key"`
+	Format     string     `json:"format"`
+	DryRun     bool       `json:"dry_run"`
+	Status     string     `json:"status"`
+	Total      int        `json:"total"`
+	Valid      int        `json:"valid"`
+	Invalid    int        `json:"invalid"`
+	Created    int        `json:"created"`
+	Report     string     `json:"report"`
+	Error      string     `json:"error"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// ImportJobEntityModel ...
+type ImportJobEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	ImportJobEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (ImportJobEntityModel) TableName() string {
+	return "import_job"
+}
@@ -5,6 +5,8 @@ import "daarul_mukhtarin/internal/abstraction"
 type RoleEntity struct {
 	Name     string `json:"name"`
 	IsDelete bool   `json:"is_delete"`
+	// Version goes up on every change, it is the ETag of the record
+	Version int `json:"version"`
 }
 
 // RoleEntityModel ...
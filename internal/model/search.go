@@ -0,0 +1,11 @@
+package model
+
+// SearchResultModel is one ranked hit of a full-text search, it is not a table.
+type SearchResultModel struct {
+	Type     string  `json:"type"`
+	ID       int     `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	DivisiId int     `json:"divisi_id"`
+	Score    float64 `json:"score"`
+}
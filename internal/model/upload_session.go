@@ -0,0 +1,36 @@
+package model
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"time"
+)
+
+type UploadSessionEntity struct {
+	Uid       string    `json:"uid"`
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	FolderId  *int      `json:"folder_id"`
+	DivisiId  int       `json:"divisi_id"`
+	UserId    int       `json:"user_id"`
+	Status    string    `json:"status"`
+	DokumenId *int      `json:"dokumen_id"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// UploadSessionEntityModel ...
+type UploadSessionEntityModel struct {
+	ID int `json:"id" param:"id" form:"id" validate:"number,min=1" gorm:"primaryKey;autoIncrement;"`
+
+	// entity
+	UploadSessionEntity
+
+	abstraction.Entity
+
+	// context
+	Context *abstraction.Context `json:"-" gorm:"-"`
+}
+
+// TableName ...
+func (UploadSessionEntityModel) TableName() string {
+	return "upload_session"
+}
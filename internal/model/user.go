@@ -2,17 +2,25 @@ package model
 
 import (
 	"daarul_mukhtarin/internal/abstraction"
+	"time"
 )
 
 type UserEntity struct {
-	Name      string `json:"name"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	RoleId    int    `json:"role_id"`
-	DivisiId  int    `json:"divisi_id"`
-	IsDelete  bool   `json:"is_delete"`
-	IsLocked  bool   `json:"is_locked"`
-	LoginFrom string `json:"login_from"`
+	Name      string     `json:"name"`
+	Email     string     `json:"email"`
+	Password  string     `json:"password"`
+	RoleId    int        `json:"role_id"`
+	DivisiId  int        `json:"divisi_id"`
+	IsDelete  bool       `json:"is_delete"`
+	IsLocked  bool       `json:"is_locked"`
+	LoginFrom string     `json:"login_from"`
+	Language  string     `json:"language"`
+	Avatar    string     `json:"avatar"`
+	DeletedAt *time.Time `json:"deleted_at"`
+	// PurgedAt is set once the personal data of a deleted user has been anonymized
+	PurgedAt *time.Time `json:"purged_at"`
+	// Version goes up on every change, it is the ETag of the record
+	Version int `json:"version"`
 }
 
 // UserEntityModel ...
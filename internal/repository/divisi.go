@@ -3,17 +3,75 @@ package repository
 import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/model"
-	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type Divisi interface {
 	FindById(ctx *abstraction.Context, id int) (*model.DivisiEntityModel, error)
+	FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.DivisiEntityModel, error)
 	Create(ctx *abstraction.Context, data *model.DivisiEntityModel) *gorm.DB
-	Find(ctx *abstraction.Context) (data []*model.DivisiEntityModel, err error)
-	Count(ctx *abstraction.Context) (data *int, err error)
+	Find(ctx *abstraction.Context, q *query.Query, s *fields.Selection) (data []*model.DivisiEntityModel, err error)
+	Count(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	Export(ctx *abstraction.Context, q *query.Query, fn func(*model.DivisiEntityModel) error) error
 	Update(ctx *abstraction.Context, data *model.DivisiEntityModel) *gorm.DB
+	FindAll(ctx *abstraction.Context) (data []*model.DivisiEntityModel, err error)
+	UpdateDriveFolderId(ctx *abstraction.Context, id int, folderId string) *gorm.DB
+	UpdateDelete(ctx *abstraction.Context, id int, delete bool, version int) *gorm.DB
+	FindDeleted(ctx *abstraction.Context, q *query.Query) (data []*model.DivisiEntityModel, err error)
+	CountDeleted(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	FindDeletedById(ctx *abstraction.Context, id int) (*model.DivisiEntityModel, error)
+	FindPurgeable(ctx *abstraction.Context, deletedBefore time.Time, limit int) (data []*model.DivisiEntityModel, err error)
+	Delete(ctx *abstraction.Context, id int) *gorm.DB
+}
+
+// DivisiQuery is what the divisi list can be filtered and sorted on.
+var DivisiQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"name":       query.Strings("name"),
+		"created_at": query.Dates("created_at"),
+	},
+	Search: []string{"name"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+	},
+	DefaultOrder: "id ASC",
+}
+
+// DivisiFields is what a divisi can be trimmed to with ?fields=.
+var DivisiFields = &fields.Spec{
+	Fields: map[string][]string{
+		"id":              {"id"},
+		"name":            {"name"},
+		"drive_folder_id": {"drive_folder_id"},
+		"is_delete":       {"is_delete"},
+		"created_at":      {"created_at"},
+		"updated_at":      {"updated_at"},
+		"version":         {"version"},
+	},
+	Required: []string{"id", "version"},
+}
+
+// DeletedDivisiQuery is what the list of deleted divisi can be filtered and sorted on.
+var DeletedDivisiQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"name":       query.Strings("name"),
+		"deleted_at": query.Dates("deleted_at"),
+	},
+	Search: []string{"name"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"deleted_at": "deleted_at",
+	},
+	DefaultOrder: "deleted_at DESC",
 }
 
 type divisi struct {
@@ -46,33 +104,152 @@ func (r *divisi) Create(ctx *abstraction.Context, data *model.DivisiEntityModel)
 	return r.CheckTrx(ctx).Create(data)
 }
 
-func (r *divisi) Find(ctx *abstraction.Context) (data []*model.DivisiEntityModel, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "divisi", "is_delete = @false")
-	limit, offset := general.ProcessLimitOffset(ctx)
-	order := general.ProcessOrder(ctx)
+// FindByIdSelect is FindById for a read endpoint, loading only what s selects.
+func (r *divisi) FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.DivisiEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.DivisiEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		Scopes(s.Select).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *divisi) Find(ctx *abstraction.Context, q *query.Query, s *fields.Selection) (data []*model.DivisiEntityModel, err error) {
 	err = r.CheckTrx(ctx).
-		Where(where, whereParam).
-		Order(order).
-		Limit(limit).
-		Offset(offset).
+		Where("is_delete = ?", false).
+		Scopes(q.Where, q.Order, q.Paginate, s.Select).
 		Find(&data).
 		Error
 	return
 }
 
-func (r *divisi) Count(ctx *abstraction.Context) (data *int, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "divisi", "is_delete = @false")
+func (r *divisi) Count(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
 	var count model.DivisiCountDataModel
 	err = r.CheckTrx(ctx).
 		Table("divisi").
 		Select("COUNT(*) AS count").
-		Where(where, whereParam).
+		Where("is_delete = ?", false).
+		Scopes(q.Where).
 		Find(&count).
 		Error
 	data = &count.Count
 	return
 }
 
+// Update writes the non zero fields of data. When data.Version is set the write only happens
+// while the row is still at that version and moves it to the next one, a RowsAffected of 0
+// means another write came first.
 func (r *divisi) Update(ctx *abstraction.Context, data *model.DivisiEntityModel) *gorm.DB {
-	return r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID).Updates(data)
+	conn := r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID)
+	if data.Version != 0 {
+		conn = conn.Where("version = ?", data.Version)
+		data.Version++
+	}
+	return conn.Updates(data)
+}
+
+func (r *divisi) FindAll(ctx *abstraction.Context) (data []*model.DivisiEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ?", false).
+		Order("id").
+		Find(&data).
+		Error
+	return
+}
+
+func (r *divisi) UpdateDriveFolderId(ctx *abstraction.Context, id int, folderId string) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.DivisiEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"drive_folder_id": folderId,
+		"version":         gorm.Expr("version + 1"),
+	})
+}
+
+// UpdateDelete moves the divisi to the trash or restores it, deleted_at starts the retention. When
+// version is set the write only happens while the row is still at it, a RowsAffected of 0 means
+// another write came first.
+func (r *divisi) UpdateDelete(ctx *abstraction.Context, id int, delete bool, version int) *gorm.DB {
+	var deletedAt *time.Time
+	if delete {
+		now := time.Now()
+		deletedAt = &now
+	}
+	conn := r.CheckTrx(ctx).Model(&model.DivisiEntityModel{}).Where("id = ?", id)
+	if version != 0 {
+		conn = conn.Where("version = ?", version)
+	}
+	return conn.Updates(map[string]interface{}{
+		"is_delete":  delete,
+		"deleted_at": deletedAt,
+		"version":    gorm.Expr("version + 1"),
+	})
+}
+
+func (r *divisi) FindDeleted(ctx *abstraction.Context, q *query.Query) (data []*model.DivisiEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ?", true).
+		Scopes(q.Where, q.Order, q.Paginate).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *divisi) CountDeleted(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
+	var count model.DivisiCountDataModel
+	err = r.CheckTrx(ctx).
+		Table("divisi").
+		Select("COUNT(*) AS count").
+		Where("is_delete = ?", true).
+		Scopes(q.Where).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+func (r *divisi) FindDeletedById(ctx *abstraction.Context, id int) (*model.DivisiEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.DivisiEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, true).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *divisi) FindPurgeable(ctx *abstraction.Context, deletedBefore time.Time, limit int) (data []*model.DivisiEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ? AND deleted_at < ?", true, deletedBefore).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&data).
+		Error
+	return
+}
+
+// Delete removes a deleted divisi for good.
+func (r *divisi) Delete(ctx *abstraction.Context, id int) *gorm.DB {
+	return r.CheckTrx(ctx).Where("id = ? AND is_delete = ?", id, true).Delete(&model.DivisiEntityModel{})
+}
+
+// Export streams every row matching q, in order, to fn.
+func (r *divisi) Export(ctx *abstraction.Context, q *query.Query, fn func(*model.DivisiEntityModel) error) error {
+	var data model.DivisiEntityModel
+	return each(
+		r.CheckTrx(ctx).
+			Model(&model.DivisiEntityModel{}).
+			Where("is_delete = ?", false).
+			Scopes(q.Where, q.Order),
+		&data,
+		func() error { return fn(&data) },
+	)
 }
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
+
+	"gorm.io/gorm"
+)
+
+type Dokumen interface {
+	FindById(ctx *abstraction.Context, id int) (*model.DokumenEntityModel, error)
+	FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.DokumenEntityModel, error)
+	Find(ctx *abstraction.Context, divisiId *int, q *query.Query, s *fields.Selection) (data []*model.DokumenEntityModel, err error)
+	Count(ctx *abstraction.Context, divisiId *int, q *query.Query) (data *int, err error)
+	FindByChecksum(ctx *abstraction.Context, divisiId int, checksum string) (*model.DokumenEntityModel, error)
+	Create(ctx *abstraction.Context, data *model.DokumenEntityModel) *gorm.DB
+	UpdateDelete(ctx *abstraction.Context, id int) *gorm.DB
+	CountByFolderId(ctx *abstraction.Context, folderId int) (data *int, err error)
+	FindFolderById(ctx *abstraction.Context, id int) (*model.DokumenFolderEntityModel, error)
+	FindFolders(ctx *abstraction.Context, divisiId *int) (data []*model.DokumenFolderEntityModel, err error)
+	CreateFolder(ctx *abstraction.Context, data *model.DokumenFolderEntityModel) *gorm.DB
+	UpdateFolderDelete(ctx *abstraction.Context, id int) *gorm.DB
+	CountFolderByParentId(ctx *abstraction.Context, parentId int) (data *int, err error)
+	CountByDivisiId(ctx *abstraction.Context, divisiId int) (int64, error)
+	FindDeletedByDivisiId(ctx *abstraction.Context, divisiId int) (data []*model.DokumenEntityModel, err error)
+	DeleteByDivisiId(ctx *abstraction.Context, divisiId int) *gorm.DB
+	DeleteFoldersByDivisiId(ctx *abstraction.Context, divisiId int) *gorm.DB
+}
+
+// DokumenQuery is what the dokumen list can be filtered and sorted on.
+var DokumenQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"name":       query.Strings("name"),
+		"mime_type":  query.Strings("mime_type"),
+		"folder_id":  query.Ints("folder_id"),
+		"divisi_id":  query.Ints("divisi_id"),
+		"user_id":    query.Ints("user_id"),
+		"size":       {Column: "size", Type: query.TYPE_INT, Operators: []string{query.OP_GTE, query.OP_LTE, query.OP_BETWEEN}},
+		"created_at": query.Dates("created_at"),
+	},
+	Search: []string{"name", "mime_type"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"size":       "size",
+		"created_at": "created_at",
+	},
+	DefaultOrder: "id ASC",
+}
+
+// DokumenFields is what a dokumen can be trimmed to with ?fields=.
+var DokumenFields = &fields.Spec{
+	Fields: map[string][]string{
+		"id":         {"id"},
+		"name":       {"name"},
+		"size":       {"size"},
+		"mime_type":  {"mime_type"},
+		"checksum":   {"checksum"},
+		"folder_id":  {"folder_id"},
+		"divisi_id":  {"divisi_id"},
+		"user_id":    {"user_id"},
+		"created_at": {"created_at"},
+		"updated_at": {"updated_at"},
+	},
+	Required: []string{"id", "divisi_id"},
+}
+
+type dokumen struct {
+	abstraction.Repository
+}
+
+func NewDokumen(db *gorm.DB) *dokumen {
+	return &dokumen{
+		Repository: abstraction.Repository{
+			Db: db,
+		},
+	}
+}
+
+func (r *dokumen) FindById(ctx *abstraction.Context, id int) (*model.DokumenEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.DokumenEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindByIdSelect is FindById for a read endpoint, loading only what s selects.
+func (r *dokumen) FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.DokumenEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.DokumenEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		Scopes(s.Select).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Find lists the documents of divisiId, or of every divisi when divisiId is nil.
+func (r *dokumen) Find(ctx *abstraction.Context, divisiId *int, q *query.Query, s *fields.Selection) (data []*model.DokumenEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Scopes(dokumenScope(divisiId), q.Where, q.Order, q.Paginate, s.Select).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *dokumen) Count(ctx *abstraction.Context, divisiId *int, q *query.Query) (data *int, err error) {
+	var count model.DokumenCountDataModel
+	err = r.CheckTrx(ctx).
+		Table("dokumen").
+		Select("COUNT(*) AS count").
+		Scopes(dokumenScope(divisiId), q.Where).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+// FindByChecksum finds any stored copy of the same content in divisiId, deleted ones included since
+// soft deleted documents keep their object.
+func (r *dokumen) FindByChecksum(ctx *abstraction.Context, divisiId int, checksum string) (*model.DokumenEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.DokumenEntityModel
+	err := conn.
+		Where("divisi_id = ? AND checksum = ?", divisiId, checksum).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// dokumenScope limits a statement to the live documents of divisiId, or of every divisi when nil.
+func dokumenScope(divisiId *int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("is_delete = ?", false)
+		if divisiId != nil {
+			db = db.Where("divisi_id = ?", *divisiId)
+		}
+		return db
+	}
+}
+
+func (r *dokumen) Create(ctx *abstraction.Context, data *model.DokumenEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+func (r *dokumen) UpdateDelete(ctx *abstraction.Context, id int) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.DokumenEntityModel{}).Where("id = ?", id).Update("is_delete", true)
+}
+
+func (r *dokumen) CountByFolderId(ctx *abstraction.Context, folderId int) (data *int, err error) {
+	var count model.DokumenCountDataModel
+	err = r.CheckTrx(ctx).
+		Table("dokumen").
+		Select("COUNT(*) AS count").
+		Where("folder_id = ? AND is_delete = ?", folderId, false).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+func (r *dokumen) FindFolderById(ctx *abstraction.Context, id int) (*model.DokumenFolderEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.DokumenFolderEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *dokumen) FindFolders(ctx *abstraction.Context, divisiId *int) (data []*model.DokumenFolderEntityModel, err error) {
+	conn := r.CheckTrx(ctx).Where("is_delete = ?", false)
+	if divisiId != nil {
+		conn = conn.Where("divisi_id = ?", *divisiId)
+	}
+	err = conn.Order("name ASC").Find(&data).Error
+	return
+}
+
+func (r *dokumen) CreateFolder(ctx *abstraction.Context, data *model.DokumenFolderEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+func (r *dokumen) UpdateFolderDelete(ctx *abstraction.Context, id int) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.DokumenFolderEntityModel{}).Where("id = ?", id).Update("is_delete", true)
+}
+
+func (r *dokumen) CountFolderByParentId(ctx *abstraction.Context, parentId int) (data *int, err error) {
+	var count model.DokumenCountDataModel
+	err = r.CheckTrx(ctx).
+		Table("dokumen_folder").
+		Select("COUNT(*) AS count").
+		Where("parent_id = ? AND is_delete = ?", parentId, false).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+// CountByDivisiId counts the live dokumen and folders of a divisi, the deleted ones go with it.
+func (r *dokumen) CountByDivisiId(ctx *abstraction.Context, divisiId int) (int64, error) {
+	var dokumen, folders int64
+	if err := r.CheckTrx(ctx).Model(&model.DokumenEntityModel{}).Where("divisi_id = ? AND is_delete = ?", divisiId, false).Count(&dokumen).Error; err != nil {
+		return 0, err
+	}
+	if err := r.CheckTrx(ctx).Model(&model.DokumenFolderEntityModel{}).Where("divisi_id = ? AND is_delete = ?", divisiId, false).Count(&folders).Error; err != nil {
+		return 0, err
+	}
+	return dokumen + folders, nil
+}
+
+func (r *dokumen) FindDeletedByDivisiId(ctx *abstraction.Context, divisiId int) (data []*model.DokumenEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("divisi_id = ? AND is_delete = ?", divisiId, true).
+		Find(&data).
+		Error
+	return
+}
+
+// DeleteByDivisiId removes the dokumen rows of a divisi for good, their objects are left to the caller.
+func (r *dokumen) DeleteByDivisiId(ctx *abstraction.Context, divisiId int) *gorm.DB {
+	return r.CheckTrx(ctx).Where("divisi_id = ?", divisiId).Delete(&model.DokumenEntityModel{})
+}
+
+func (r *dokumen) DeleteFoldersByDivisiId(ctx *abstraction.Context, divisiId int) *gorm.DB {
+	return r.CheckTrx(ctx).Where("divisi_id = ?", divisiId).Delete(&model.DokumenFolderEntityModel{})
+}
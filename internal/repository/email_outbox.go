@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/gomail"
+	"daarul_mukhtarin/pkg/util/query"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EmailOutbox interface {
+	Create(ctx *abstraction.Context, data *model.EmailOutboxEntityModel) *gorm.DB
+	Enqueue(ctx *abstraction.Context, message *gomail.Message) error
+	FindById(ctx *abstraction.Context, id int) (*model.EmailOutboxEntityModel, error)
+	Find(ctx *abstraction.Context, q *query.Query) (data []*model.EmailOutboxEntityModel, err error)
+	Count(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	FindDueForUpdate(ctx *abstraction.Context, now time.Time, limit int) (data []*model.EmailOutboxEntityModel, err error)
+	UpdateNextAttempt(ctx *abstraction.Context, id int, nextAttemptAt time.Time) *gorm.DB
+	UpdateSent(ctx *abstraction.Context, id int, attempts int, sentAt time.Time) *gorm.DB
+	UpdateFailed(ctx *abstraction.Context, id int, status string, attempts int, lastError string, nextAttemptAt time.Time) *gorm.DB
+	UpdateResend(ctx *abstraction.Context, id int, now time.Time) *gorm.DB
+	FindFinishedIds(ctx *abstraction.Context, before time.Time, limit int) (ids []int, err error)
+	DeleteByIds(ctx *abstraction.Context, ids []int) *gorm.DB
+	DeleteByRecipient(ctx *abstraction.Context, recipient string) *gorm.DB
+}
+
+// EmailOutboxQuery is what the outbox list can be filtered and sorted on.
+var EmailOutboxQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"recipient":  query.Strings("recipient"),
+		"subject":    query.Strings("subject"),
+		"status":     {Column: "status", Type: query.TYPE_STRING, Operators: []string{query.OP_EQ, query.OP_NE, query.OP_IN}},
+		"attempts":   {Column: "attempts", Type: query.TYPE_INT, Operators: []string{query.OP_EQ, query.OP_GTE, query.OP_LTE}},
+		"sent_at":    query.Dates("sent_at"),
+		"created_at": query.Dates("created_at"),
+	},
+	Search: []string{"recipient", "subject"},
+	Sorts: map[string]string{
+		"id":              "id",
+		"attempts":        "attempts",
+		"next_attempt_at": "next_attempt_at",
+		"sent_at":         "sent_at",
+		"created_at":      "created_at",
+	},
+	DefaultOrder: "id ASC",
+	Cursor:       "id",
+}
+
+type emailOutbox struct {
+	abstraction.Repository
+}
+
+func NewEmailOutbox(db *gorm.DB) *emailOutbox {
+	return &emailOutbox{
+		Repository: abstraction.Repository{
+			Db: db,
+		},
+	}
+}
+
+func (r *emailOutbox) Create(ctx *abstraction.Context, data *model.EmailOutboxEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+// Enqueue stores a pending message, when called inside trxmanager.WithTrx the message is only
+// dispatched if the surrounding transaction commits.
+func (r *emailOutbox) Enqueue(ctx *abstraction.Context, message *gomail.Message) error {
+	if message.BodyHtml == "" {
+		return errors.New("error parsing body html")
+	}
+
+	var attachments []byte
+	if len(message.Attachments) > 0 {
+		var err error
+		if attachments, err = json.Marshal(message.Attachments); err != nil {
+			return err
+		}
+	}
+	data := &model.EmailOutboxEntityModel{
+		Context: ctx,
+		EmailOutboxEntity: model.EmailOutboxEntity{
+			Recipient:     strings.Join(message.To, ","),
+			Cc:            strings.Join(message.Cc, ","),
+			Bcc:           strings.Join(message.Bcc, ","),
+			ReplyTo:       message.ReplyTo,
+			Subject:       message.Subject,
+			BodyHtml:      message.BodyHtml,
+			BodyText:      message.BodyText,
+			Attachments:   string(attachments),
+			Status:        constant.OUTBOX_STATUS_PENDING,
+			NextAttemptAt: time.Now(),
+		},
+	}
+	if message.From != nil {
+		data.FromName = message.From.Name
+		data.FromAddress = message.From.Address
+	}
+	return r.Create(ctx, data).Error
+}
+
+func (r *emailOutbox) FindById(ctx *abstraction.Context, id int) (*model.EmailOutboxEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.EmailOutboxEntityModel
+	err := conn.
+		Where("id = ?", id).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *emailOutbox) Find(ctx *abstraction.Context, q *query.Query) (data []*model.EmailOutboxEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Scopes(q.Where, q.Order, q.Paginate).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *emailOutbox) Count(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
+	var count model.EmailOutboxCountDataModel
+	err = r.CheckTrx(ctx).
+		Table("email_outbox").
+		Select("COUNT(*) AS count").
+		Scopes(q.Where).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+// FindDueForUpdate locks pending messages whose next attempt is due, rows already locked by
+// another dispatcher are skipped so several instances can run side by side.
+func (r *emailOutbox) FindDueForUpdate(ctx *abstraction.Context, now time.Time, limit int) (data []*model.EmailOutboxEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND next_attempt_at <= ?", constant.OUTBOX_STATUS_PENDING, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *emailOutbox) UpdateNextAttempt(ctx *abstraction.Context, id int, nextAttemptAt time.Time) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.EmailOutboxEntityModel{}).Where("id = ?", id).Update("next_attempt_at", nextAttemptAt)
+}
+
+// UpdateSent marks a message sent and clears its content, which may hold credentials such as a
+// generated password, only the envelope is kept for the list.
+func (r *emailOutbox) UpdateSent(ctx *abstraction.Context, id int, attempts int, sentAt time.Time) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.EmailOutboxEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":      constant.OUTBOX_STATUS_SENT,
+		"attempts":    attempts,
+		"last_error":  "",
+		"body_html":   gorm.Expr("NULL"),
+		"body_text":   gorm.Expr("NULL"),
+		"attachments": gorm.Expr("NULL"),
+		"sent_at":     sentAt,
+		"updated_at":  sentAt,
+	})
+}
+
+func (r *emailOutbox) UpdateFailed(ctx *abstraction.Context, id int, status string, attempts int, lastError string, nextAttemptAt time.Time) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.EmailOutboxEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"last_error":      lastError,
+		"next_attempt_at": nextAttemptAt,
+		"updated_at":      time.Now(),
+	})
+}
+
+func (r *emailOutbox) UpdateResend(ctx *abstraction.Context, id int, now time.Time) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.EmailOutboxEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          constant.OUTBOX_STATUS_PENDING,
+		"attempts":        0,
+		"last_error":      "",
+		"next_attempt_at": now,
+		"updated_at":      now,
+	})
+}
+
+// FindFinishedIds returns sent and dead messages last changed before before, dead ones are kept
+// until then so they can still be resent.
+func (r *emailOutbox) FindFinishedIds(ctx *abstraction.Context, before time.Time, limit int) (ids []int, err error) {
+	err = r.CheckTrx(ctx).
+		Model(&model.EmailOutboxEntityModel{}).
+		Where("status IN ? AND updated_at < ?", []string{constant.OUTBOX_STATUS_SENT, constant.OUTBOX_STATUS_DEAD}, before).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &ids).
+		Error
+	return
+}
+
+func (r *emailOutbox) DeleteByIds(ctx *abstraction.Context, ids []int) *gorm.DB {
+	return r.CheckTrx(ctx).Where("id IN ?", ids).Delete(&model.EmailOutboxEntityModel{})
+}
+
+// DeleteByRecipient removes every mail to recipient, in any case, sent or not.
+func (r *emailOutbox) DeleteByRecipient(ctx *abstraction.Context, recipient string) *gorm.DB {
+	return r.CheckTrx(ctx).Where("LOWER(recipient) = ?", strings.ToLower(recipient)).Delete(&model.EmailOutboxEntityModel{})
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/util/query"
+
+	"gorm.io/gorm"
+)
+
+type EmailTemplate interface {
+	FindById(ctx *abstraction.Context, id int) (*model.EmailTemplateEntityModel, error)
+	FindByNameLanguage(ctx *abstraction.Context, name string, language string, withDeleted bool) (*model.EmailTemplateEntityModel, error)
+	Find(ctx *abstraction.Context, q *query.Query) (data []*model.EmailTemplateEntityModel, err error)
+	Count(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	Create(ctx *abstraction.Context, data *model.EmailTemplateEntityModel) *gorm.DB
+	UpdateContent(ctx *abstraction.Context, data *model.EmailTemplateEntityModel) *gorm.DB
+	UpdateDelete(ctx *abstraction.Context, id *int, delete bool) *gorm.DB
+	CreateVersion(ctx *abstraction.Context, data *model.EmailTemplateVersionEntityModel) *gorm.DB
+	FindVersions(ctx *abstraction.Context, id int) (data []*model.EmailTemplateVersionEntityModel, err error)
+	FindVersion(ctx *abstraction.Context, id int, version int) (*model.EmailTemplateVersionEntityModel, error)
+}
+
+// EmailTemplateQuery is what the email template list can be filtered and sorted on.
+var EmailTemplateQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"name":       query.Strings("name"),
+		"language":   {Column: "language", Type: query.TYPE_STRING, Operators: []string{query.OP_EQ, query.OP_IN}},
+		"subject":    query.Strings("subject"),
+		"created_at": query.Dates("created_at"),
+	},
+	Search: []string{"name", "subject"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+	},
+	DefaultOrder: "id ASC",
+}
+
+type emailTemplate struct {
+	abstraction.Repository
+}
+
+func NewEmailTemplate(db *gorm.DB) *emailTemplate {
+	return &emailTemplate{
+		Repository: abstraction.Repository{
+			Db: db,
+		},
+	}
+}
+
+func (r *emailTemplate) FindById(ctx *abstraction.Context, id int) (*model.EmailTemplateEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.EmailTemplateEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *emailTemplate) FindByNameLanguage(ctx *abstraction.Context, name string, language string, withDeleted bool) (*model.EmailTemplateEntityModel, error) {
+	conn := r.CheckTrx(ctx).Where("name = ? AND language = ?", name, language)
+	if !withDeleted {
+		conn = conn.Where("is_delete = ?", false)
+	}
+
+	var data model.EmailTemplateEntityModel
+	err := conn.
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *emailTemplate) Find(ctx *abstraction.Context, q *query.Query) (data []*model.EmailTemplateEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ?", false).
+		Scopes(q.Where, q.Order, q.Paginate).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *emailTemplate) Count(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
+	var count model.EmailTemplateCountDataModel
+	err = r.CheckTrx(ctx).
+		Table("email_template").
+		Select("COUNT(*) AS count").
+		Where("is_delete = ?", false).
+		Scopes(q.Where).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+func (r *emailTemplate) Create(ctx *abstraction.Context, data *model.EmailTemplateEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+// UpdateContent writes subject, body and version together and brings a deleted template back.
+func (r *emailTemplate) UpdateContent(ctx *abstraction.Context, data *model.EmailTemplateEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.EmailTemplateEntityModel{}).Where("id = ?", data.ID).Updates(map[string]interface{}{
+		"subject":    data.Subject,
+		"body_html":  data.BodyHtml,
+		"version":    data.Version,
+		"is_delete":  false,
+		"updated_at": general.Now(),
+	})
+}
+
+func (r *emailTemplate) UpdateDelete(ctx *abstraction.Context, id *int, delete bool) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.EmailTemplateEntityModel{}).Where("id = ?", id).Update("is_delete", delete)
+}
+
+func (r *emailTemplate) CreateVersion(ctx *abstraction.Context, data *model.EmailTemplateVersionEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+func (r *emailTemplate) FindVersions(ctx *abstraction.Context, id int) (data []*model.EmailTemplateVersionEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("email_template_id = ?", id).
+		Order("version DESC").
+		Find(&data).
+		Error
+	return
+}
+
+func (r *emailTemplate) FindVersion(ctx *abstraction.Context, id int, version int) (*model.EmailTemplateVersionEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.EmailTemplateVersionEntityModel
+	err := conn.
+		Where("email_template_id = ? AND version = ?", id, version).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// each runs the statement conn and scans its rows into dest one at a time, calling fn after every
+// row, so an export never holds the whole table in memory.
+func each(conn *gorm.DB, dest interface{}, fn func() error) error {
+	rows, err := conn.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	value := reflect.ValueOf(dest).Elem()
+	for rows.Next() {
+		value.Set(reflect.Zero(value.Type()))
+		if err = conn.ScanRows(rows, dest); err != nil {
+			return err
+		}
+		if err = fn(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
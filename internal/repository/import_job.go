@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ImportJob interface {
+	Create(ctx *abstraction.Context, data *model.ImportJobEntityModel) *gorm.DB
+	FindByUid(ctx *abstraction.Context, uid string) (*model.ImportJobEntityModel, error)
+	FindDueForUpdate(ctx *abstraction.Context, kind string, staleBefore time.Time) (*model.ImportJobEntityModel, error)
+	UpdateStatus(ctx *abstraction.Context, id int, status string) *gorm.DB
+	UpdateResult(ctx *abstraction.Context, data *model.ImportJobEntityModel) *gorm.DB
+}
+
+type importJob struct {
+	abstraction.Repository
+}
+
+func NewImportJob(db *gorm.DB) *importJob {
+	return &importJob{
+		Repository: abstraction.Repository{
+			Db: db,
+		},
+	}
+}
+
+func (r *importJob) Create(ctx *abstraction.Context, data *model.ImportJobEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+func (r *importJob) FindByUid(ctx *abstraction.Context, uid string) (*model.ImportJobEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.ImportJobEntityModel
+	err := conn.
+		Where("uid = ?", uid).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindDueForUpdate locks the oldest pending job of kind, or a running one not touched since
+// staleBefore whose worker died. Jobs locked by another worker are skipped.
+func (r *importJob) FindDueForUpdate(ctx *abstraction.Context, kind string, staleBefore time.Time) (*model.ImportJobEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.ImportJobEntityModel
+	err := conn.
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("kind = ? AND (status = ? OR (status = ? AND updated_at < ?))", kind, constant.IMPORT_STATUS_PENDING, constant.IMPORT_STATUS_RUNNING, staleBefore).
+		Order("id ASC").
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *importJob) UpdateStatus(ctx *abstraction.Context, id int, status string) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.ImportJobEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	})
+}
+
+func (r *importJob) UpdateResult(ctx *abstraction.Context, data *model.ImportJobEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.ImportJobEntityModel{}).Where("id = ?", data.ID).Updates(map[string]interface{}{
+		"status":      data.Status,
+		"total":       data.Total,
+		"valid":       data.Valid,
+		"invalid":     data.Invalid,
+		"created":     data.Created,
+		"report":      data.Report,
+		"error":       data.Error,
+		"finished_at": data.FinishedAt,
+		"updated_at":  time.Now(),
+	})
+}
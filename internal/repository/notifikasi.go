@@ -3,20 +3,37 @@ package repository
 import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/model"
-	"daarul_mukhtarin/pkg/util/general"
-	"fmt"
+	"daarul_mukhtarin/pkg/util/query"
 
 	"gorm.io/gorm"
 )
 
 type Notifikasi interface {
 	Create(ctx *abstraction.Context, data *model.NotifikasiEntityModel) *gorm.DB
-	FindByUserId(ctx *abstraction.Context, userId *int) (data []*model.NotifikasiEntityModel, err error)
-	CountByUserId(ctx *abstraction.Context, userId *int) (countTotal *int, countRead *int, countUnread *int, err error)
+	FindByUserId(ctx *abstraction.Context, userId *int, q *query.Query) (data []*model.NotifikasiEntityModel, err error)
+	CountByUserId(ctx *abstraction.Context, userId *int, q *query.Query) (countTotal *int, countRead *int, countUnread *int, err error)
 	FindById(ctx *abstraction.Context, id int) (*model.NotifikasiEntityModel, error)
+	ExportByUserId(ctx *abstraction.Context, userId *int, q *query.Query, fn func(*model.NotifikasiEntityModel) error) error
 	Update(ctx *abstraction.Context, data *model.NotifikasiEntityModel) *gorm.DB
 }
 
+// NotifikasiQuery is what the notifikasi list can be filtered and sorted on.
+var NotifikasiQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"title":      query.Strings("title"),
+		"is_read":    query.Bools("is_read"),
+		"created_at": query.Dates("created_at"),
+	},
+	Search: []string{"title", "message"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"created_at": "created_at",
+	},
+	DefaultOrder: "id ASC",
+	Cursor:       "id",
+}
+
 type notifikasi struct {
 	abstraction.Repository
 }
@@ -33,28 +50,26 @@ func (r *notifikasi) Create(ctx *abstraction.Context, data *model.NotifikasiEnti
 	return r.CheckTrx(ctx).Create(data)
 }
 
-func (r *notifikasi) FindByUserId(ctx *abstraction.Context, userId *int) (data []*model.NotifikasiEntityModel, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "notifikasi", fmt.Sprintf("user_id = %d", *userId))
-	order := general.ProcessOrder(ctx)
+func (r *notifikasi) FindByUserId(ctx *abstraction.Context, userId *int, q *query.Query) (data []*model.NotifikasiEntityModel, err error) {
 	err = r.CheckTrx(ctx).
-		Where(where, whereParam).
-		Order(order).
+		Where("user_id = ?", *userId).
+		Scopes(q.Where, q.Order, q.Paginate).
 		Find(&data).
 		Error
 	return
 }
 
-func (r *notifikasi) CountByUserId(ctx *abstraction.Context, userId *int) (countTotal *int, countRead *int, countUnread *int, err error) {
+func (r *notifikasi) CountByUserId(ctx *abstraction.Context, userId *int, q *query.Query) (countTotal *int, countRead *int, countUnread *int, err error) {
 	var count model.NotifikasiCountDataModel
-	whereTotal, whereParamTotal := general.ProcessWhereParam(ctx, "notifikasi", fmt.Sprintf("user_id = %d", *userId))
 	err = r.CheckTrx(ctx).
 		Table("notifikasi").
 		Select(`
 			COUNT(*) AS count_total, 
-			COUNT(CASE WHEN is_read = TRUE THEN 1 END) AS count_read,
-			COUNT(CASE WHEN is_read = FALSE THEN 1 END) AS count_unread
-		`).
-		Where(whereTotal, whereParamTotal).
+			COUNT(CASE WHEN is_read = ? THEN 1 END) AS count_read,
+			COUNT(CASE WHEN is_read = ? THEN 1 END) AS count_unread
+		`, true, false).
+		Where("user_id = ?", *userId).
+		Scopes(q.Where).
 		Find(&count).
 		Error
 	countTotal = &count.CountTotal
@@ -80,3 +95,16 @@ func (r *notifikasi) FindById(ctx *abstraction.Context, id int) (*model.Notifika
 func (r *notifikasi) Update(ctx *abstraction.Context, data *model.NotifikasiEntityModel) *gorm.DB {
 	return r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID).Updates(data)
 }
+
+// ExportByUserId streams every notifikasi of userId matching q, in order, to fn.
+func (r *notifikasi) ExportByUserId(ctx *abstraction.Context, userId *int, q *query.Query, fn func(*model.NotifikasiEntityModel) error) error {
+	var data model.NotifikasiEntityModel
+	return each(
+		r.CheckTrx(ctx).
+			Model(&model.NotifikasiEntityModel{}).
+			Where("user_id = ?", *userId).
+			Scopes(q.Where, q.Order),
+		&data,
+		func() error { return fn(&data) },
+	)
+}
@@ -0,0 +1,279 @@
+package repository_test
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/migrations"
+	"daarul_mukhtarin/pkg/migrate"
+	"daarul_mukhtarin/pkg/util/query"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// newDB opens a SQLite database with every migration applied, the repositories run there the
+// queries they run on postgres, without FULLTEXT.
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := migrations.Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrate.NewMigrator(db, list).Up(0); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func parse(t *testing.T, spec *query.Spec, raw string) *query.Query {
+	t.Helper()
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := query.Parse(values, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestNotifikasiCountByUserId(t *testing.T) {
+	db := newDB(t)
+	ctx := &abstraction.Context{}
+	notifikasiRepository := repository.NewNotifikasi(db)
+	for _, data := range []model.NotifikasiEntity{
+		{UserId: 1, Title: "Rapat divisi", Message: "Rapat hari Senin", IsRead: true},
+		{UserId: 1, Title: "Rapat pengurus", Message: "Rapat bulanan", IsRead: false},
+		{UserId: 1, Title: "Diskon 50% kitab", Message: "Berlaku sepekan", IsRead: false},
+		{UserId: 1, Title: "Diskon 500 kitab", Message: "Berlaku sepekan", IsRead: true},
+		{UserId: 1, Title: "Dokumen_baru", Message: "Laporan diunggah", IsRead: true},
+		{UserId: 2, Title: "Rapat divisi", Message: "Rapat hari Senin", IsRead: false},
+	} {
+		if err := notifikasiRepository.Create(ctx, &model.NotifikasiEntityModel{NotifikasiEntity: data}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		userId int
+		query  string
+		total  int
+		read   int
+		unread int
+		titles []string
+	}{
+		{
+			name: "all of a user", userId: 1, query: "",
+			total: 5, read: 3, unread: 2,
+			titles: []string{"Rapat divisi", "Rapat pengurus", "Diskon 50% kitab", "Diskon 500 kitab", "Dokumen_baru"},
+		},
+		{
+			name: "only the unread", userId: 1, query: "is_read=false",
+			total: 2, read: 0, unread: 2,
+			titles: []string{"Rapat pengurus", "Diskon 50% kitab"},
+		},
+		{
+			name: "search matches title or message in any case", userId: 1, query: "search=RAPAT",
+			total: 2, read: 1, unread: 1,
+			titles: []string{"Rapat divisi", "Rapat pengurus"},
+		},
+		{
+			name: "percent in the search is not a wildcard", userId: 1, query: "search=50%25",
+			total: 1, read: 0, unread: 1,
+			titles: []string{"Diskon 50% kitab"},
+		},
+		{
+			name: "underscore in the search is not a wildcard", userId: 1, query: "title=n_b",
+			total: 1, read: 1, unread: 0,
+			titles: []string{"Dokumen_baru"},
+		},
+		{
+			name: "another user", userId: 2, query: "",
+			total: 1, read: 0, unread: 1,
+			titles: []string{"Rapat divisi"},
+		},
+		{
+			name: "no notifikasi", userId: 3, query: "",
+			total: 0, read: 0, unread: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := parse(t, repository.NotifikasiQuery, tt.query)
+			total, read, unread, err := notifikasiRepository.CountByUserId(ctx, &tt.userId, q)
+			if err != nil {
+				t.Fatalf("CountByUserId() error = %v", err)
+			}
+			if *total != tt.total || *read != tt.read || *unread != tt.unread {
+				t.Errorf("CountByUserId() = %d, %d, %d, want %d, %d, %d", *total, *read, *unread, tt.total, tt.read, tt.unread)
+			}
+
+			data, err := notifikasiRepository.FindByUserId(ctx, &tt.userId, q)
+			if err != nil {
+				t.Fatalf("FindByUserId() error = %v", err)
+			}
+			var titles []string
+			for _, item := range data {
+				titles = append(titles, item.Title)
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("FindByUserId() = %q, want %q", titles, tt.titles)
+			}
+		})
+	}
+}
+
+func TestNotifikasiFindByUserIdCursor(t *testing.T) {
+	db := newDB(t)
+	ctx := &abstraction.Context{}
+	notifikasiRepository := repository.NewNotifikasi(db)
+	for i := 0; i < 5; i++ {
+		data := &model.NotifikasiEntityModel{NotifikasiEntity: model.NotifikasiEntity{UserId: 1, Title: "Pengumuman", Message: "-"}}
+		if err := notifikasiRepository.Create(ctx, data).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userId := 1
+	raw := "cursor=&page_size=2"
+	var pages [][]int
+	for len(pages) < 5 {
+		q := parse(t, repository.NotifikasiQuery, raw)
+		data, err := notifikasiRepository.FindByUserId(ctx, &userId, q)
+		if err != nil {
+			t.Fatalf("FindByUserId() error = %v", err)
+		}
+		n, more := q.Trim(len(data))
+		var ids []int
+		for _, item := range data[:n] {
+			ids = append(ids, item.ID)
+		}
+		pages = append(pages, ids)
+		if !more {
+			break
+		}
+		u, _ := url.Parse("/notifikasi?" + raw)
+		next, _ := url.Parse(*q.CursorPagination(u, more, int64(ids[len(ids)-1])).Next)
+		raw = next.RawQuery
+	}
+	if want := [][]int{{1, 2}, {3, 4}, {5}}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+}
+
+func TestSearchLike(t *testing.T) {
+	db := newDB(t)
+	ctx := &abstraction.Context{}
+	for _, data := range []model.UserEntity{
+		{Name: "Ahmad Fauzi", Email: "ahmad@example.com", DivisiId: 1},
+		{Name: "AHMAD Rizki", Email: "rizki@example.com", DivisiId: 2},
+		{Name: "Budi Santoso", Email: "budi.fauzan@example.com", DivisiId: 1},
+		{Name: "Ahmad Dihapus", Email: "hapus@example.com", DivisiId: 1, IsDelete: true},
+	} {
+		data.Password = "-"
+		data.Version = 1
+		if err := db.Omit(clause.Associations).Create(&model.UserEntityModel{UserEntity: data}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	source := repository.SearchSources[0]
+	divisi := 1
+
+	tests := []struct {
+		name     string
+		q        string
+		divisiId *int
+		titles   []string
+	}{
+		{name: "any case", q: "ahmad", titles: []string{"AHMAD Rizki", "Ahmad Fauzi"}},
+		{name: "every word has to match", q: "Ahmad Fau", titles: []string{"Ahmad Fauzi"}},
+		{name: "words match name or email", q: "fau", titles: []string{"Ahmad Fauzi", "Budi Santoso"}},
+		{name: "visible divisi only", q: "ahmad", divisiId: &divisi, titles: []string{"Ahmad Fauzi"}},
+		{name: "operators are dropped", q: "+budi* -santoso", titles: []string{"Budi Santoso"}},
+		{name: "no match", q: "zainab", titles: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := repository.NewSearch(db).Search(ctx, source, repository.SearchTerms(tt.q), tt.divisiId, 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var titles []string
+			for _, item := range data {
+				titles = append(titles, item.Title)
+				if item.Type != "user" {
+					t.Errorf("Type = %s, want user", item.Type)
+				}
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("Search(%q) = %q, want %q", tt.q, titles, tt.titles)
+			}
+		})
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{q: "", want: ""},
+		{q: "Ahmad", want: "+ahmad*"},
+		{q: "  ahmad   fauzi ", want: "+ahmad* +fauzi*"},
+		{q: `+a -b "c" (d) e* @f`, want: "+a* +b* +c* +d* +e* +f*"},
+		{q: "a b c d e f g h i j k l", want: "+a* +b* +c* +d* +e* +f* +g* +h* +i* +j*"},
+	}
+	for _, tt := range tests {
+		if got := repository.SearchTerms(tt.q); got != tt.want {
+			t.Errorf("SearchTerms(%q) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestUserUpdatesBumpVersion(t *testing.T) {
+	db := newDB(t)
+	ctx := &abstraction.Context{}
+	userRepository := repository.NewUser(db)
+	data := &model.UserEntityModel{UserEntity: model.UserEntity{Name: "Ahmad", Email: "ahmad@example.com", Password: "-", Version: 1}}
+	if err := userRepository.Create(ctx, data).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	updates := []struct {
+		name   string
+		update func() *gorm.DB
+	}{
+		{name: "UpdateLocked", update: func() *gorm.DB { return userRepository.UpdateLocked(ctx, &data.ID, true) }},
+		{name: "UpdateLoginFrom", update: func() *gorm.DB { return userRepository.UpdateLoginFrom(ctx, &data.ID, "web") }},
+		{name: "UpdateAvatar", update: func() *gorm.DB { return userRepository.UpdateAvatar(ctx, &data.ID, "{}") }},
+	}
+	for i, tt := range updates {
+		if err := tt.update().Error; err != nil {
+			t.Fatalf("%s() error = %v", tt.name, err)
+		}
+		found, err := userRepository.FindById(ctx, data.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i + 2; found.Version != want {
+			t.Errorf("%s() version = %d, want %d", tt.name, found.Version, want)
+		}
+	}
+}
@@ -3,18 +3,47 @@ package repository
 import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/model"
-	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
 
 	"gorm.io/gorm"
 )
 
 type Role interface {
 	FindById(ctx *abstraction.Context, id int) (*model.RoleEntityModel, error)
-	Find(ctx *abstraction.Context) (data []*model.RoleEntityModel, err error)
-	Count(ctx *abstraction.Context) (data *int, err error)
+	FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.RoleEntityModel, error)
+	FindAll(ctx *abstraction.Context) (data []*model.RoleEntityModel, err error)
+	Find(ctx *abstraction.Context, q *query.Query, s *fields.Selection) (data []*model.RoleEntityModel, err error)
+	Count(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	Export(ctx *abstraction.Context, q *query.Query, fn func(*model.RoleEntityModel) error) error
 	Update(ctx *abstraction.Context, data *model.RoleEntityModel) *gorm.DB
 }
 
+// RoleQuery is what the role list can be filtered and sorted on.
+var RoleQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":   query.Ints("id"),
+		"name": query.Strings("name"),
+	},
+	Search: []string{"name"},
+	Sorts: map[string]string{
+		"id":   "id",
+		"name": "name",
+	},
+	DefaultOrder: "id ASC",
+}
+
+// RoleFields is what a role can be trimmed to with ?fields=.
+var RoleFields = &fields.Spec{
+	Fields: map[string][]string{
+		"id":        {"id"},
+		"name":      {"name"},
+		"is_delete": {"is_delete"},
+		"version":   {"version"},
+	},
+	Required: []string{"id", "version"},
+}
+
 type role struct {
 	abstraction.Repository
 }
@@ -41,33 +70,74 @@ func (r *role) FindById(ctx *abstraction.Context, id int) (*model.RoleEntityMode
 	return &data, nil
 }
 
-func (r *role) Find(ctx *abstraction.Context) (data []*model.RoleEntityModel, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "role", "is_delete = @false")
-	limit, offset := general.ProcessLimitOffset(ctx)
-	order := general.ProcessOrder(ctx)
+// FindByIdSelect is FindById for a read endpoint, loading only what s selects.
+func (r *role) FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.RoleEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.RoleEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		Scopes(s.Select).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *role) Find(ctx *abstraction.Context, q *query.Query, s *fields.Selection) (data []*model.RoleEntityModel, err error) {
 	err = r.CheckTrx(ctx).
-		Where(where, whereParam).
-		Order(order).
-		Limit(limit).
-		Offset(offset).
+		Where("is_delete = ?", false).
+		Scopes(q.Where, q.Order, q.Paginate, s.Select).
 		Find(&data).
 		Error
 	return
 }
 
-func (r *role) Count(ctx *abstraction.Context) (data *int, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "role", "is_delete = @false")
+func (r *role) Count(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
 	var count model.RoleCountDataModel
 	err = r.CheckTrx(ctx).
 		Table("role").
 		Select("COUNT(*) AS count").
-		Where(where, whereParam).
+		Where("is_delete = ?", false).
+		Scopes(q.Where).
 		Find(&count).
 		Error
 	data = &count.Count
 	return
 }
 
+// Update writes the non zero fields of data. When data.Version is set the write only happens
+// while the row is still at that version and moves it to the next one, a RowsAffected of 0
+// means another write came first.
 func (r *role) Update(ctx *abstraction.Context, data *model.RoleEntityModel) *gorm.DB {
-	return r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID).Updates(data)
+	conn := r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID)
+	if data.Version != 0 {
+		conn = conn.Where("version = ?", data.Version)
+		data.Version++
+	}
+	return conn.Updates(data)
+}
+
+// Export streams every row matching q, in order, to fn.
+func (r *role) Export(ctx *abstraction.Context, q *query.Query, fn func(*model.RoleEntityModel) error) error {
+	var data model.RoleEntityModel
+	return each(
+		r.CheckTrx(ctx).
+			Model(&model.RoleEntityModel{}).
+			Where("is_delete = ?", false).
+			Scopes(q.Where, q.Order),
+		&data,
+		func() error { return fn(&data) },
+	)
+}
+
+func (r *role) FindAll(ctx *abstraction.Context) (data []*model.RoleEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ?", false).
+		Order("id").
+		Find(&data).
+		Error
+	return
 }
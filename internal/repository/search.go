@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/database"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// SearchSource is a table searched through its FULLTEXT index on MySQL, and with LIKE on the other
+// databases. The column names are fixed here, only the search terms and ids come from the request.
+type SearchSource struct {
+	Type     string
+	Table    string
+	Index    string
+	Columns  string
+	Title    string
+	Subtitle string
+	// Divisi is the column holding the divisi a row belongs to, used for visibility.
+	Divisi string
+	Path   string
+}
+
+var SearchSources = []SearchSource{
+	{Type: "user", Table: "user", Index: "ft_user", Columns: "name, email", Title: "name", Subtitle: "email", Divisi: "divisi_id", Path: "/user/%d"},
+	{Type: "divisi", Table: "divisi", Index: "ft_divisi", Columns: "name", Title: "name", Subtitle: "''", Divisi: "id", Path: "/divisi/%d"},
+	{Type: "dokumen", Table: "dokumen", Index: "ft_dokumen", Columns: "name", Title: "name", Subtitle: "mime_type", Divisi: "divisi_id", Path: "/dokumen/%d"},
+	{Type: "folder", Table: "dokumen_folder", Index: "ft_dokumen_folder", Columns: "name", Title: "name", Subtitle: "''", Divisi: "divisi_id", Path: "/dokumen/folder?divisi_id=%d"},
+}
+
+const maxSearchTerms = 10
+
+type Search interface {
+	Search(ctx *abstraction.Context, source SearchSource, terms string, divisiId *int, limit int) (data []*model.SearchResultModel, err error)
+	EnsureIndexes(ctx *abstraction.Context) error
+}
+
+type search struct {
+	abstraction.Repository
+}
+
+func NewSearch(db *gorm.DB) *search {
+	return &search{
+		Repository: abstraction.Repository{
+			Db: db,
+		},
+	}
+}
+
+// Search ranks the rows of source matching terms, a boolean mode query built by SearchTerms.
+// Without MySQL there is no FULLTEXT index, every word then has to be found in one of the columns
+// and the rows come back by title with the same score.
+func (r *search) Search(ctx *abstraction.Context, source SearchSource, terms string, divisiId *int, limit int) (data []*model.SearchResultModel, err error) {
+	conn := r.CheckTrx(ctx).
+		Table(source.Table).
+		Where("is_delete = ?", false)
+	// source.Type is one of the fixed SearchSources, postgres can not tell the type of a bound
+	// parameter that is only selected
+	columns := "'" + source.Type + "' AS type, id, " + source.Title + " AS title, " + source.Subtitle + " AS subtitle, " + source.Divisi + " AS divisi_id"
+	if conn.Dialector.Name() == database.MYSQL {
+		match := "MATCH(" + source.Columns + ") AGAINST(? IN BOOLEAN MODE)"
+		conn = conn.
+			Select(columns+", "+match+" AS score", terms).
+			Where(match, terms).
+			Order("score DESC")
+	} else {
+		conn = conn.
+			Select(columns + ", 1 AS score").
+			Order(source.Title + " ASC")
+		for _, word := range strings.Fields(terms) {
+			word = "%" + strings.Trim(word, "+*") + "%"
+			var likes []string
+			var args []interface{}
+			for _, column := range strings.Split(source.Columns, ",") {
+				likes = append(likes, "LOWER("+strings.TrimSpace(column)+") LIKE ?")
+				args = append(args, word)
+			}
+			conn = conn.Where("("+strings.Join(likes, " OR ")+")", args...)
+		}
+	}
+	if divisiId != nil {
+		conn = conn.Where(source.Divisi+" = ?", *divisiId)
+	}
+	err = conn.
+		Limit(limit).
+		Find(&data).
+		Error
+	return
+}
+
+// EnsureIndexes creates the FULLTEXT index of every source that does not have one yet. Only MySQL
+// searches through them, on the other databases it does nothing.
+func (r *search) EnsureIndexes(ctx *abstraction.Context) error {
+	conn := r.CheckTrx(ctx)
+	if conn.Dialector.Name() != database.MYSQL {
+		return nil
+	}
+	for _, source := range SearchSources {
+		var count int64
+		err := conn.
+			Table("information_schema.statistics").
+			Where("table_schema = DATABASE() AND table_name = ? AND index_name = ?", source.Table, source.Index).
+			Count(&count).
+			Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err = conn.Exec("CREATE FULLTEXT INDEX " + source.Index + " ON `" + source.Table + "` (" + source.Columns + ")").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchTerms turns free text into a boolean mode query where every word must match as a prefix,
+// operators typed by the user are dropped so they cannot change the query.
+func SearchTerms(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+	for i, word := range words {
+		words[i] = "+" + word + "*"
+	}
+	return strings.Join(words, " ")
+}
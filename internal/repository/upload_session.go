@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/pkg/constant"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UploadSession interface {
+	FindByUid(ctx *abstraction.Context, uid string) (*model.UploadSessionEntityModel, error)
+	FindByUidForUpdate(ctx *abstraction.Context, uid string) (*model.UploadSessionEntityModel, error)
+	FindExpired(ctx *abstraction.Context, now time.Time, stuckBefore time.Time, afterId int, limit int) (data []*model.UploadSessionEntityModel, err error)
+	Create(ctx *abstraction.Context, data *model.UploadSessionEntityModel) *gorm.DB
+	UpdateStatus(ctx *abstraction.Context, id int, status string, dokumenId *int) *gorm.DB
+	UpdateStatusFrom(ctx *abstraction.Context, id int, current string, status string) *gorm.DB
+}
+
+type uploadSession struct {
+	abstraction.Repository
+}
+
+func NewUploadSession(db *gorm.DB) *uploadSession {
+	return &uploadSession{
+		Repository: abstraction.Repository{
+			Db: db,
+		},
+	}
+}
+
+func (r *uploadSession) FindByUid(ctx *abstraction.Context, uid string) (*model.UploadSessionEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.UploadSessionEntityModel
+	err := conn.
+		Where("uid = ?", uid).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindByUidForUpdate locks the session row so only one request can complete or abort it.
+func (r *uploadSession) FindByUidForUpdate(ctx *abstraction.Context, uid string) (*model.UploadSessionEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.UploadSessionEntityModel
+	err := conn.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("uid = ?", uid).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindExpired returns pending sessions past their expiry, and sessions left assembling since
+// stuckBefore by a request that died, with an id above afterId.
+func (r *uploadSession) FindExpired(ctx *abstraction.Context, now time.Time, stuckBefore time.Time, afterId int, limit int) (data []*model.UploadSessionEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("id > ?", afterId).
+		Where("(status = ? AND expired_at <= ?) OR (status = ? AND updated_at <= ?)", constant.UPLOAD_STATUS_PENDING, now, constant.UPLOAD_STATUS_ASSEMBLING, stuckBefore).
+		Order("id ASC").
+		Limit(limit).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *uploadSession) Create(ctx *abstraction.Context, data *model.UploadSessionEntityModel) *gorm.DB {
+	return r.CheckTrx(ctx).Create(data)
+}
+
+func (r *uploadSession) UpdateStatus(ctx *abstraction.Context, id int, status string, dokumenId *int) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.UploadSessionEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":     status,
+		"dokumen_id": dokumenId,
+		"updated_at": time.Now(),
+	})
+}
+
+// UpdateStatusFrom moves the session to status only while it is still at current.
+func (r *uploadSession) UpdateStatusFrom(ctx *abstraction.Context, id int, current string, status string) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.UploadSessionEntityModel{}).Where("id = ? AND status = ?", id, current).Updates(map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	})
+}
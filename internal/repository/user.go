@@ -3,7 +3,11 @@ package repository
 import (
 	"daarul_mukhtarin/internal/abstraction"
 	"daarul_mukhtarin/internal/model"
-	"daarul_mukhtarin/pkg/util/general"
+	"daarul_mukhtarin/pkg/util/fields"
+	"daarul_mukhtarin/pkg/util/query"
+	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,14 +15,97 @@ import (
 type User interface {
 	FindByEmail(ctx *abstraction.Context, email string) (*model.UserEntityModel, error)
 	Create(ctx *abstraction.Context, data *model.UserEntityModel) *gorm.DB
-	Find(ctx *abstraction.Context) (data []*model.UserEntityModel, err error)
-	Count(ctx *abstraction.Context) (data *int, err error)
+	Find(ctx *abstraction.Context, q *query.Query, s *fields.Selection) (data []*model.UserEntityModel, err error)
+	Count(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	Export(ctx *abstraction.Context, q *query.Query, fn func(*model.UserEntityModel) error) error
 	FindById(ctx *abstraction.Context, id int) (*model.UserEntityModel, error)
+	FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.UserEntityModel, error)
 	Update(ctx *abstraction.Context, data *model.UserEntityModel) *gorm.DB
-	UpdateDelete(ctx *abstraction.Context, id *int, delete bool) *gorm.DB
+	UpdateDelete(ctx *abstraction.Context, id *int, delete bool, version int) *gorm.DB
 	UpdateLocked(ctx *abstraction.Context, id *int, locked bool) *gorm.DB
 	UpdateLoginFrom(ctx *abstraction.Context, id *int, from string) *gorm.DB
+	UpdateAvatar(ctx *abstraction.Context, id *int, avatar string) *gorm.DB
 	FindByDivisiId(ctx *abstraction.Context, id *int) (*model.UserEntityModel, error)
+	FindAllByDivisiId(ctx *abstraction.Context, id int) (data []*model.UserEntityModel, err error)
+	FindAllByRoleId(ctx *abstraction.Context, id int) (data []*model.UserEntityModel, err error)
+	FindEmails(ctx *abstraction.Context, emails []string) (data []string, err error)
+	FindByIds(ctx *abstraction.Context, ids []int) (data []*model.UserEntityModel, err error)
+	FindAllByQuery(ctx *abstraction.Context, q *query.Query, limit int) (data []*model.UserEntityModel, err error)
+	FindByEmailWithDeleted(ctx *abstraction.Context, email string) (*model.UserEntityModel, error)
+	FindDeleted(ctx *abstraction.Context, q *query.Query) (data []*model.UserEntityModel, err error)
+	CountDeleted(ctx *abstraction.Context, q *query.Query) (data *int, err error)
+	FindDeletedById(ctx *abstraction.Context, id int) (*model.UserEntityModel, error)
+	FindPurgeable(ctx *abstraction.Context, deletedBefore time.Time, limit int) (data []*model.UserEntityModel, err error)
+	Anonymize(ctx *abstraction.Context, id int) *gorm.DB
+	DetachDivisi(ctx *abstraction.Context, divisiId int) *gorm.DB
+	CountByDivisiId(ctx *abstraction.Context, divisiId int) (int64, error)
+}
+
+// UserQuery is what the user list can be filtered and sorted on.
+var UserQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"name":       query.Strings("name"),
+		"email":      query.Strings("email"),
+		"role_id":    query.Ints("role_id"),
+		"divisi_id":  query.Ints("divisi_id"),
+		"is_locked":  query.Bools("is_locked"),
+		"language":   {Column: "language", Type: query.TYPE_STRING, Operators: []string{query.OP_EQ, query.OP_IN}},
+		"login_from": query.Strings("login_from"),
+		"created_at": query.Dates("created_at"),
+	},
+	Search: []string{"name", "email"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"email":      "email",
+		"created_at": "created_at",
+	},
+	DefaultOrder: "id ASC",
+}
+
+// UserFields is what a user can be trimmed to with ?fields= and expanded with ?expand=.
+var UserFields = &fields.Spec{
+	Fields: map[string][]string{
+		"id":         {"id"},
+		"name":       {"name"},
+		"email":      {"email"},
+		"is_delete":  {"is_delete"},
+		"is_locked":  {"is_locked"},
+		"login_from": {"login_from"},
+		"language":   {"language"},
+		"avatar_url": {"avatar"},
+		"created_at": {"created_at"},
+		"updated_at": {"updated_at"},
+		"version":    {"version"},
+		"role_id":    {"role_id"},
+		"divisi_id":  {"divisi_id"},
+	},
+	Relations: map[string]fields.Relation{
+		"role":   {Preload: "Role", Columns: []string{"role_id"}},
+		"divisi": {Preload: "Divisi", Columns: []string{"divisi_id"}},
+	},
+	Required: []string{"id", "version"},
+}
+
+// DeletedUserQuery is what the list of deleted users can be filtered and sorted on.
+var DeletedUserQuery = &query.Spec{
+	Filters: map[string]query.Field{
+		"id":         query.Ints("id"),
+		"name":       query.Strings("name"),
+		"email":      query.Strings("email"),
+		"role_id":    query.Ints("role_id"),
+		"divisi_id":  query.Ints("divisi_id"),
+		"deleted_at": query.Dates("deleted_at"),
+	},
+	Search: []string{"name", "email"},
+	Sorts: map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"email":      "email",
+		"deleted_at": "deleted_at",
+	},
+	DefaultOrder: "deleted_at DESC",
 }
 
 type user struct {
@@ -53,29 +140,22 @@ func (r *user) Create(ctx *abstraction.Context, data *model.UserEntityModel) *go
 	return r.CheckTrx(ctx).Create(data)
 }
 
-func (r *user) Find(ctx *abstraction.Context) (data []*model.UserEntityModel, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "user", "is_delete = @false")
-	limit, offset := general.ProcessLimitOffset(ctx)
-	order := general.ProcessOrder(ctx)
+func (r *user) Find(ctx *abstraction.Context, q *query.Query, s *fields.Selection) (data []*model.UserEntityModel, err error) {
 	err = r.CheckTrx(ctx).
-		Where(where, whereParam).
-		Order(order).
-		Limit(limit).
-		Offset(offset).
-		Preload("Role").
-		Preload("Divisi").
+		Where("is_delete = ?", false).
+		Scopes(q.Where, q.Order, q.Paginate, s.Select).
 		Find(&data).
 		Error
 	return
 }
 
-func (r *user) Count(ctx *abstraction.Context) (data *int, err error) {
-	where, whereParam := general.ProcessWhereParam(ctx, "user", "is_delete = @false")
+func (r *user) Count(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
 	var count model.UserCountDataModel
 	err = r.CheckTrx(ctx).
-		Table("user").
+		Model(&model.UserEntityModel{}).
 		Select("COUNT(*) AS count").
-		Where(where, whereParam).
+		Where("is_delete = ?", false).
+		Scopes(q.Where).
 		Find(&count).
 		Error
 	data = &count.Count
@@ -98,20 +178,73 @@ func (r *user) FindById(ctx *abstraction.Context, id int) (*model.UserEntityMode
 	return &data, nil
 }
 
+// FindByIdSelect is FindById for a read endpoint, loading only what s selects.
+func (r *user) FindByIdSelect(ctx *abstraction.Context, id int, s *fields.Selection) (*model.UserEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.UserEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ?", id, false).
+		Scopes(s.Select).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Update writes the non zero fields of data. When data.Version is set the write only happens
+// while the row is still at that version and moves it to the next one, a RowsAffected of 0
+// means another write came first.
 func (r *user) Update(ctx *abstraction.Context, data *model.UserEntityModel) *gorm.DB {
-	return r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID).Updates(data)
+	conn := r.CheckTrx(ctx).Model(data).Where("id = ?", data.ID)
+	if data.Version != 0 {
+		conn = conn.Where("version = ?", data.Version)
+		data.Version++
+	}
+	return conn.Updates(data)
 }
 
-func (r *user) UpdateDelete(ctx *abstraction.Context, id *int, delete bool) *gorm.DB {
-	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id).Update("is_delete", delete)
+// UpdateDelete moves the user to the trash or restores it, deleted_at starts the retention. When
+// version is set the write only happens while the row is still at it, a RowsAffected of 0 means
+// another write came first.
+func (r *user) UpdateDelete(ctx *abstraction.Context, id *int, delete bool, version int) *gorm.DB {
+	var deletedAt *time.Time
+	if delete {
+		now := time.Now()
+		deletedAt = &now
+	}
+	conn := r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id)
+	if version != 0 {
+		conn = conn.Where("version = ?", version)
+	}
+	return conn.Updates(map[string]interface{}{
+		"is_delete":  delete,
+		"deleted_at": deletedAt,
+		"version":    gorm.Expr("version + 1"),
+	})
 }
 
 func (r *user) UpdateLocked(ctx *abstraction.Context, id *int, locked bool) *gorm.DB {
-	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id).Update("is_locked", locked)
+	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"is_locked": locked,
+		"version":   gorm.Expr("version + 1"),
+	})
 }
 
 func (r *user) UpdateLoginFrom(ctx *abstraction.Context, id *int, from string) *gorm.DB {
-	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id).Update("login_from", from)
+	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"login_from": from,
+		"version":    gorm.Expr("version + 1"),
+	})
+}
+
+func (r *user) UpdateAvatar(ctx *abstraction.Context, id *int, avatar string) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"avatar":  avatar,
+		"version": gorm.Expr("version + 1"),
+	})
 }
 
 func (r *user) FindByDivisiId(ctx *abstraction.Context, id *int) (*model.UserEntityModel, error) {
@@ -127,3 +260,178 @@ func (r *user) FindByDivisiId(ctx *abstraction.Context, id *int) (*model.UserEnt
 	}
 	return &data, nil
 }
+
+func (r *user) FindAllByDivisiId(ctx *abstraction.Context, id int) (data []*model.UserEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("divisi_id = ? AND is_delete = ?", id, false).
+		Find(&data).
+		Error
+	return
+}
+
+func (r *user) FindAllByRoleId(ctx *abstraction.Context, id int) (data []*model.UserEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("role_id = ? AND is_delete = ?", id, false).
+		Find(&data).
+		Error
+	return
+}
+
+// FindEmails returns which of emails already belong to a user, deleted or not, in any case,
+// checked in batches so a long list stays within the placeholder limit.
+func (r *user) FindEmails(ctx *abstraction.Context, emails []string) (data []string, err error) {
+	lower := make([]string, len(emails))
+	for i, email := range emails {
+		lower[i] = strings.ToLower(email)
+	}
+	for start := 0; start < len(lower); start += 1000 {
+		end := start + 1000
+		if end > len(lower) {
+			end = len(lower)
+		}
+		var batch []string
+		err = r.CheckTrx(ctx).
+			Model(&model.UserEntityModel{}).
+			Where("LOWER(email) IN ?", lower[start:end]).
+			Pluck("email", &batch).
+			Error
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, batch...)
+	}
+	return
+}
+
+func (r *user) FindByIds(ctx *abstraction.Context, ids []int) (data []*model.UserEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("id IN ? AND is_delete = ?", ids, false).
+		Find(&data).
+		Error
+	return
+}
+
+// FindAllByQuery returns up to limit users matching the filters of q, ignoring its paging.
+func (r *user) FindAllByQuery(ctx *abstraction.Context, q *query.Query, limit int) (data []*model.UserEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ?", false).
+		Scopes(q.Where, q.Order).
+		Limit(limit).
+		Find(&data).
+		Error
+	return
+}
+
+// FindByEmailWithDeleted also finds a deleted user, an email stays taken while its user can
+// still be restored. An anonymized user no longer holds its email.
+func (r *user) FindByEmailWithDeleted(ctx *abstraction.Context, email string) (*model.UserEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.UserEntityModel
+	err := conn.
+		Where("email = ?", email).
+		Order("is_delete ASC").
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func (r *user) FindDeleted(ctx *abstraction.Context, q *query.Query) (data []*model.UserEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ? AND purged_at IS NULL", true).
+		Scopes(q.Where, q.Order, q.Paginate).
+		Preload("Role").
+		Preload("Divisi").
+		Find(&data).
+		Error
+	return
+}
+
+func (r *user) CountDeleted(ctx *abstraction.Context, q *query.Query) (data *int, err error) {
+	var count model.UserCountDataModel
+	err = r.CheckTrx(ctx).
+		Model(&model.UserEntityModel{}).
+		Select("COUNT(*) AS count").
+		Where("is_delete = ? AND purged_at IS NULL", true).
+		Scopes(q.Where).
+		Find(&count).
+		Error
+	data = &count.Count
+	return
+}
+
+func (r *user) FindDeletedById(ctx *abstraction.Context, id int) (*model.UserEntityModel, error) {
+	conn := r.CheckTrx(ctx)
+
+	var data model.UserEntityModel
+	err := conn.
+		Where("id = ? AND is_delete = ? AND purged_at IS NULL", id, true).
+		First(&data).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// FindPurgeable returns deleted users not yet anonymized that were deleted before deletedBefore.
+func (r *user) FindPurgeable(ctx *abstraction.Context, deletedBefore time.Time, limit int) (data []*model.UserEntityModel, err error) {
+	err = r.CheckTrx(ctx).
+		Where("is_delete = ? AND purged_at IS NULL AND deleted_at < ?", true, deletedBefore).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&data).
+		Error
+	return
+}
+
+// Anonymize wipes the personal data of a deleted user. The row stays so the records pointing
+// to it keep a valid user, its email is replaced so the address can be used again.
+func (r *user) Anonymize(ctx *abstraction.Context, id int) *gorm.DB {
+	now := time.Now()
+	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("id = ? AND is_delete = ?", id, true).Updates(map[string]interface{}{
+		"name":       "Deleted user",
+		"email":      fmt.Sprintf("deleted-%d@invalid", id),
+		"password":   "",
+		"login_from": "",
+		"avatar":     "",
+		"is_locked":  true,
+		"purged_at":  now,
+		"updated_at": now,
+		"version":    gorm.Expr("version + 1"),
+	})
+}
+
+// DetachDivisi unlinks the anonymized users from a divisi about to be purged.
+func (r *user) DetachDivisi(ctx *abstraction.Context, divisiId int) *gorm.DB {
+	return r.CheckTrx(ctx).Model(&model.UserEntityModel{}).Where("divisi_id = ? AND purged_at IS NOT NULL", divisiId).Updates(map[string]interface{}{
+		"divisi_id": 0,
+		"version":   gorm.Expr("version + 1"),
+	})
+}
+
+// CountByDivisiId counts the users of a divisi, deleted ones included unless anonymized.
+func (r *user) CountByDivisiId(ctx *abstraction.Context, divisiId int) (count int64, err error) {
+	err = r.CheckTrx(ctx).
+		Model(&model.UserEntityModel{}).
+		Where("divisi_id = ? AND purged_at IS NULL", divisiId).
+		Count(&count).
+		Error
+	return
+}
+
+// Export streams every row matching q, in order, to fn.
+func (r *user) Export(ctx *abstraction.Context, q *query.Query, fn func(*model.UserEntityModel) error) error {
+	var data model.UserEntityModel
+	return each(
+		r.CheckTrx(ctx).
+			Model(&model.UserEntityModel{}).
+			Where("is_delete = ?", false).
+			Scopes(q.Where, q.Order),
+		&data,
+		func() error { return fn(&data) },
+	)
+}
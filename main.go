@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"daarul_mukhtarin/internal/app/dokumen"
+	"daarul_mukhtarin/internal/app/emailtemplate"
+	"daarul_mukhtarin/internal/app/outbox"
+	"daarul_mukhtarin/internal/app/trash"
+	user "daarul_mukhtarin/internal/app/user"
 	"daarul_mukhtarin/internal/config"
 	"daarul_mukhtarin/internal/factory"
 	httpdaarul_mukhtarin "daarul_mukhtarin/internal/http"
 	middlewareEcho "daarul_mukhtarin/internal/middleware"
 	db "daarul_mukhtarin/pkg/database"
 	"daarul_mukhtarin/pkg/log"
+	"daarul_mukhtarin/pkg/mailtemplate"
 	"daarul_mukhtarin/pkg/ngrok"
 	"net/http"
 	"os"
@@ -23,17 +29,44 @@ import (
 // @version 1.0.0
 // @description This is a doc for daarul_mukhtarin.
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
 func main() {
 	config.Init()
 
 	log.Init()
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
 	db.Init()
 
+	if err := mailtemplate.Init(); err != nil {
+		logrus.Fatal(err)
+	}
+
 	e := echo.New()
 
 	f := factory.NewFactory()
 
+	if len(os.Args) > 1 {
+		if err := runCommand(f, os.Args[1:]); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	if err := emailtemplate.Seed(f); err != nil {
+		logrus.Error("Error seeding email templates: ", err.Error())
+	}
+	mailtemplate.SetStore(emailtemplate.NewStore(f))
+
 	middlewareEcho.Init(e, f.DbRedis)
 
 	httpdaarul_mukhtarin.Init(e, f)
@@ -42,9 +75,14 @@ func main() {
 
 	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
 
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go outbox.NewDispatcher(f).Start(ctx)
+	go dokumen.NewCleaner(f).Start(ctx)
+	go user.NewImportWorker(f).Start(ctx)
+	go trash.NewPurger(f).Start(ctx)
+
 	go func() {
 		runNgrok := false
 		addr := ""
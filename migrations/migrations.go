@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// files holds the schema migrations of every supported database, each in a directory named after it.
+//
+//go:embed mysql postgres sqlite
+var files embed.FS
+
+// DRIVERS are the databases with migrations, a new migration is written for each of them.
+var DRIVERS = []string{"mysql", "postgres", "sqlite"}
+
+// Files returns the migrations for driver.
+func Files(driver string) (fs.FS, error) {
+	return fs.Sub(files, driver)
+}
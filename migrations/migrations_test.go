@@ -0,0 +1,73 @@
+package migrations
+
+import (
+	"daarul_mukhtarin/pkg/migrate"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestFiles(t *testing.T) {
+	var want []int64
+	for _, driver := range DRIVERS {
+		files, err := Files(driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list, err := migrate.Load(files)
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", driver, err)
+		}
+		var versions []int64
+		for _, migration := range list {
+			versions = append(versions, migration.Version)
+		}
+		if want == nil {
+			want = versions
+		}
+		if !reflect.DeepEqual(versions, want) {
+			t.Errorf("%s has migrations %v, want the same as %s, %v", driver, versions, DRIVERS[0], want)
+		}
+	}
+}
+
+func TestSqlite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Files("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := migrate.Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := migrate.NewMigrator(db, list)
+
+	applied, err := m.Up(0)
+	if err != nil || len(applied) != len(list) {
+		t.Fatalf("Up() = %d, %v, want %d applied", len(applied), err, len(list))
+	}
+	if applied, err = m.Up(0); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up() = %d, %v, want nothing to apply", len(applied), err)
+	}
+	for _, table := range []string{"role", "divisi", "user", "notifikasi", "dokumen", "upload_session", "import_job"} {
+		if !db.Migrator().HasTable(table) {
+			t.Errorf("table %s is missing", table)
+		}
+	}
+
+	// the first migrations take over tables that may hold data from before, they are not reverted
+	if _, err = m.Down(1); err == nil {
+		t.Error("Down() reverted a migration that has no down file")
+	}
+	if !db.Migrator().HasTable("user") {
+		t.Error("Down() dropped the user table")
+	}
+}
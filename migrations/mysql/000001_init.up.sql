@@ -0,0 +1,187 @@
+-- the schema as it was before migrations, every table is created only when missing so a database
+-- set up by hand can be brought under migrations with migrate up
+CREATE TABLE IF NOT EXISTS `role` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  `is_delete` TINYINT(1) NOT NULL DEFAULT 0,
+  `version` INT NOT NULL DEFAULT 1,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `divisi` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255) NOT NULL,
+  `drive_folder_id` VARCHAR(255) NOT NULL DEFAULT '',
+  `is_delete` TINYINT(1) NOT NULL DEFAULT 0,
+  `deleted_at` DATETIME(3) NULL,
+  `version` INT NOT NULL DEFAULT 1,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_divisi_deleted_at` (`is_delete`, `deleted_at`),
+  FULLTEXT KEY `ft_divisi` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255) NOT NULL,
+  `email` VARCHAR(255) NOT NULL,
+  `password` VARCHAR(255) NOT NULL,
+  `role_id` INT NOT NULL DEFAULT 0,
+  `divisi_id` INT NOT NULL DEFAULT 0,
+  `is_delete` TINYINT(1) NOT NULL DEFAULT 0,
+  `is_locked` TINYINT(1) NOT NULL DEFAULT 0,
+  `login_from` VARCHAR(50) NOT NULL DEFAULT '',
+  `language` VARCHAR(5) NOT NULL DEFAULT 'id',
+  `avatar` VARCHAR(255) NOT NULL DEFAULT '',
+  `deleted_at` DATETIME(3) NULL,
+  `purged_at` DATETIME(3) NULL,
+  `version` INT NOT NULL DEFAULT 1,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_user_email` (`email`),
+  KEY `idx_user_role_id` (`role_id`),
+  KEY `idx_user_divisi_id` (`divisi_id`),
+  KEY `idx_user_deleted_at` (`is_delete`, `deleted_at`),
+  FULLTEXT KEY `ft_user` (`name`, `email`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `notifikasi` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `title` VARCHAR(255) NOT NULL,
+  `message` TEXT NOT NULL,
+  `is_read` TINYINT(1) NOT NULL DEFAULT 0,
+  `user_id` INT NOT NULL,
+  `link` VARCHAR(255) NOT NULL DEFAULT '',
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_notifikasi_user_id` (`user_id`, `is_read`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `email_outbox` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `from_name` VARCHAR(255) NOT NULL DEFAULT '',
+  `from_address` VARCHAR(255) NOT NULL DEFAULT '',
+  `recipient` TEXT NOT NULL,
+  `cc` TEXT NULL,
+  `bcc` TEXT NULL,
+  `reply_to` VARCHAR(255) NOT NULL DEFAULT '',
+  `subject` VARCHAR(255) NOT NULL DEFAULT '',
+  `body_html` MEDIUMTEXT NULL,
+  `body_text` MEDIUMTEXT NULL,
+  `attachments` MEDIUMTEXT NULL,
+  `status` VARCHAR(20) NOT NULL DEFAULT 'pending',
+  `attempts` INT NOT NULL DEFAULT 0,
+  `next_attempt_at` DATETIME(3) NOT NULL,
+  `last_error` TEXT NULL,
+  `sent_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_email_outbox_status` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `email_template` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  `language` VARCHAR(5) NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `body_html` MEDIUMTEXT NOT NULL,
+  `version` INT NOT NULL DEFAULT 1,
+  `is_delete` TINYINT(1) NOT NULL DEFAULT 0,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_email_template_name` (`name`, `language`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `email_template_version` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `email_template_id` INT NOT NULL,
+  `version` INT NOT NULL,
+  `subject` VARCHAR(255) NOT NULL,
+  `body_html` MEDIUMTEXT NOT NULL,
+  `created_by` INT NOT NULL DEFAULT 0,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_email_template_version` (`email_template_id`, `version`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `dokumen_folder` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255) NOT NULL,
+  `parent_id` INT NULL,
+  `divisi_id` INT NOT NULL,
+  `created_by` INT NOT NULL DEFAULT 0,
+  `is_delete` TINYINT(1) NOT NULL DEFAULT 0,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_dokumen_folder_divisi_id` (`divisi_id`),
+  KEY `idx_dokumen_folder_parent_id` (`parent_id`),
+  FULLTEXT KEY `ft_dokumen_folder` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `dokumen` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(255) NOT NULL,
+  `size` BIGINT NOT NULL DEFAULT 0,
+  `mime_type` VARCHAR(255) NOT NULL DEFAULT '',
+  `checksum` CHAR(64) NOT NULL DEFAULT '',
+  `storage_key` VARCHAR(512) NOT NULL,
+  `folder_id` INT NULL,
+  `divisi_id` INT NOT NULL,
+  `user_id` INT NOT NULL,
+  `is_delete` TINYINT(1) NOT NULL DEFAULT 0,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_dokumen_divisi_id` (`divisi_id`, `checksum`),
+  KEY `idx_dokumen_folder_id` (`folder_id`),
+  FULLTEXT KEY `ft_dokumen` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `upload_session` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `uid` VARCHAR(64) NOT NULL,
+  `name` VARCHAR(255) NOT NULL,
+  `size` BIGINT NOT NULL,
+  `folder_id` INT NULL,
+  `divisi_id` INT NOT NULL,
+  `user_id` INT NOT NULL,
+  `status` VARCHAR(20) NOT NULL DEFAULT 'pending',
+  `dokumen_id` INT NULL,
+  `expired_at` DATETIME(3) NOT NULL,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_upload_session_uid` (`uid`),
+  KEY `idx_upload_session_status` (`status`, `expired_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `import_job` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `uid` VARCHAR(64) NOT NULL,
+  `kind` VARCHAR(50) NOT NULL,
+  `user_id` INT NOT NULL,
+  `file_name` VARCHAR(255) NOT NULL DEFAULT '',
+  `storage_key` VARCHAR(512) NOT NULL DEFAULT '',
+  `format` VARCHAR(10) NOT NULL,
+  `dry_run` TINYINT(1) NOT NULL DEFAULT 0,
+  `status` VARCHAR(20) NOT NULL DEFAULT 'pending',
+  `total` INT NOT NULL DEFAULT 0,
+  `valid` INT NOT NULL DEFAULT 0,
+  `invalid` INT NOT NULL DEFAULT 0,
+  `created` INT NOT NULL DEFAULT 0,
+  `report` LONGTEXT NULL,
+  `error` TEXT NULL,
+  `finished_at` DATETIME(3) NULL,
+  `created_at` DATETIME(3) NOT NULL,
+  `updated_at` DATETIME(3) NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uq_import_job_uid` (`uid`),
+  KEY `idx_import_job_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
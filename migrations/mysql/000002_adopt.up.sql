@@ -0,0 +1,98 @@
+-- brings tables created before migrations up to 000001, which skipped them as they already existed.
+-- MySQL has no ADD COLUMN IF NOT EXISTS, so each change is only prepared when information_schema
+-- does not have the column or index yet, otherwise the prepared statement does nothing
+
+-- role.version
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `role` ADD COLUMN `version` INT NOT NULL DEFAULT 1', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'role' AND column_name = 'version');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- divisi.drive_folder_id
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `divisi` ADD COLUMN `drive_folder_id` VARCHAR(255) NOT NULL DEFAULT ''''', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'divisi' AND column_name = 'drive_folder_id');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- divisi.deleted_at
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `divisi` ADD COLUMN `deleted_at` DATETIME(3) NULL', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'divisi' AND column_name = 'deleted_at');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- divisi.version
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `divisi` ADD COLUMN `version` INT NOT NULL DEFAULT 1', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'divisi' AND column_name = 'version');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.language
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD COLUMN `language` VARCHAR(5) NOT NULL DEFAULT ''id''', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'user' AND column_name = 'language');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.avatar
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD COLUMN `avatar` VARCHAR(255) NOT NULL DEFAULT ''''', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'user' AND column_name = 'avatar');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.deleted_at
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD COLUMN `deleted_at` DATETIME(3) NULL', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'user' AND column_name = 'deleted_at');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.purged_at
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD COLUMN `purged_at` DATETIME(3) NULL', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'user' AND column_name = 'purged_at');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.version
+SET @statement = (SELECT IF(COUNT(*) = 0, 'ALTER TABLE `user` ADD COLUMN `version` INT NOT NULL DEFAULT 1', 'DO 0') FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'user' AND column_name = 'version');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- divisi.idx_divisi_deleted_at
+SET @statement = (SELECT IF(COUNT(*) = 0, 'CREATE INDEX `idx_divisi_deleted_at` ON `divisi` (`is_delete`, `deleted_at`)', 'DO 0') FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'divisi' AND index_name = 'idx_divisi_deleted_at');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.idx_user_email
+SET @statement = (SELECT IF(COUNT(*) = 0, 'CREATE INDEX `idx_user_email` ON `user` (`email`)', 'DO 0') FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'user' AND index_name = 'idx_user_email');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.idx_user_role_id
+SET @statement = (SELECT IF(COUNT(*) = 0, 'CREATE INDEX `idx_user_role_id` ON `user` (`role_id`)', 'DO 0') FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'user' AND index_name = 'idx_user_role_id');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.idx_user_divisi_id
+SET @statement = (SELECT IF(COUNT(*) = 0, 'CREATE INDEX `idx_user_divisi_id` ON `user` (`divisi_id`)', 'DO 0') FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'user' AND index_name = 'idx_user_divisi_id');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- user.idx_user_deleted_at
+SET @statement = (SELECT IF(COUNT(*) = 0, 'CREATE INDEX `idx_user_deleted_at` ON `user` (`is_delete`, `deleted_at`)', 'DO 0') FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'user' AND index_name = 'idx_user_deleted_at');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- notifikasi.idx_notifikasi_user_id
+SET @statement = (SELECT IF(COUNT(*) = 0, 'CREATE INDEX `idx_notifikasi_user_id` ON `notifikasi` (`user_id`, `is_read`)', 'DO 0') FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'notifikasi' AND index_name = 'idx_notifikasi_user_id');
+PREPARE adopt FROM @statement;
+EXECUTE adopt;
+DEALLOCATE PREPARE adopt;
+
+-- records deleted before deleted_at existed are dated by their last change, so the trash does not
+-- show them without a date and purges them after the retention like any other
+UPDATE `user` SET `deleted_at` = COALESCE(`updated_at`, `created_at`) WHERE `is_delete` = 1 AND `deleted_at` IS NULL;
+UPDATE `divisi` SET `deleted_at` = COALESCE(`updated_at`, `created_at`) WHERE `is_delete` = 1 AND `deleted_at` IS NULL;
@@ -0,0 +1,4 @@
+-- databases set up before migrations were all MySQL, a postgres database was always created by
+-- 000001 and already has every column, only records deleted without a date are dated here as on MySQL
+UPDATE "user" SET deleted_at = COALESCE(updated_at, created_at) WHERE is_delete = TRUE AND deleted_at IS NULL;
+UPDATE divisi SET deleted_at = COALESCE(updated_at, created_at) WHERE is_delete = TRUE AND deleted_at IS NULL;
@@ -0,0 +1,83 @@
+package migrations
+
+import (
+	"daarul_mukhtarin/internal/abstraction"
+	"daarul_mukhtarin/internal/config"
+	"daarul_mukhtarin/internal/model"
+	"daarul_mukhtarin/internal/repository"
+	"daarul_mukhtarin/pkg/constant"
+	"daarul_mukhtarin/pkg/database"
+	"daarul_mukhtarin/pkg/mailtemplate"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// roles are the roles the code refers to by id, see constant.ROLE_ID_*.
+var roles = []model.RoleEntityModel{
+	{ID: constant.ROLE_ID_ADMIN, RoleEntity: model.RoleEntity{Name: "Admin", Version: 1}},
+	{ID: constant.ROLE_ID_KEPALA_DIVISI, RoleEntity: model.RoleEntity{Name: "Kepala Divisi", Version: 1}},
+	{ID: constant.ROLE_ID_STAF, RoleEntity: model.RoleEntity{Name: "Staf", Version: 1}},
+}
+
+// Seed inserts the default roles that are missing and the initial admin from SEED_ADMIN_NAME,
+// SEED_ADMIN_EMAIL and SEED_ADMIN_PASSWORD. The admin is left alone once a user has that email,
+// so changing the env later does not touch the account.
+func Seed(db *gorm.DB) error {
+	for _, role := range roles {
+		if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+			return err
+		}
+	}
+	if db.Dialector.Name() == database.POSTGRES {
+		// the roles are inserted with their id, which does not move the serial on
+		err := db.Exec("SELECT setval(pg_get_serial_sequence('role', 'id'), (SELECT MAX(id) FROM role))").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	seed := config.Get().Seed
+	if seed.AdminEmail == "" {
+		return nil
+	}
+	if seed.AdminPassword == "" {
+		return errors.New("SEED_ADMIN_PASSWORD is required with SEED_ADMIN_EMAIL")
+	}
+	ctx := &abstraction.Context{}
+	userRepository := repository.NewUser(db)
+	_, err := userRepository.FindByEmailWithDeleted(ctx, seed.AdminEmail)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(seed.AdminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	name := seed.AdminName
+	if name == "" {
+		name = "Administrator"
+	}
+	admin := &model.UserEntityModel{
+		UserEntity: model.UserEntity{
+			Name:     name,
+			Email:    seed.AdminEmail,
+			Password: string(hashedPassword),
+			RoleId:   constant.ROLE_ID_ADMIN,
+			Language: mailtemplate.Language(""),
+			Version:  1,
+		},
+	}
+	if err = userRepository.Create(ctx, admin).Error; err != nil {
+		return err
+	}
+	logrus.Info("admin " + admin.Email + " created")
+	return nil
+}
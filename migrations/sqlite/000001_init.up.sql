@@ -0,0 +1,173 @@
+-- the schema as it was before migrations, every table is created only when missing so a database
+-- set up by hand can be brought under migrations with migrate up, search uses LIKE here so
+-- there are no FULLTEXT indexes
+CREATE TABLE IF NOT EXISTS role (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(100) NOT NULL,
+  is_delete BOOLEAN NOT NULL DEFAULT 0,
+  version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS divisi (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  drive_folder_id VARCHAR(255) NOT NULL DEFAULT '',
+  is_delete BOOLEAN NOT NULL DEFAULT 0,
+  deleted_at DATETIME NULL,
+  version INTEGER NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_divisi_deleted_at ON divisi (is_delete, deleted_at);
+
+CREATE TABLE IF NOT EXISTS "user" (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  email VARCHAR(255) NOT NULL,
+  password VARCHAR(255) NOT NULL,
+  role_id INTEGER NOT NULL DEFAULT 0,
+  divisi_id INTEGER NOT NULL DEFAULT 0,
+  is_delete BOOLEAN NOT NULL DEFAULT 0,
+  is_locked BOOLEAN NOT NULL DEFAULT 0,
+  login_from VARCHAR(50) NOT NULL DEFAULT '',
+  language VARCHAR(5) NOT NULL DEFAULT 'id',
+  avatar VARCHAR(255) NOT NULL DEFAULT '',
+  deleted_at DATETIME NULL,
+  purged_at DATETIME NULL,
+  version INTEGER NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_user_email ON "user" (email);
+CREATE INDEX IF NOT EXISTS idx_user_role_id ON "user" (role_id);
+CREATE INDEX IF NOT EXISTS idx_user_divisi_id ON "user" (divisi_id);
+CREATE INDEX IF NOT EXISTS idx_user_deleted_at ON "user" (is_delete, deleted_at);
+
+CREATE TABLE IF NOT EXISTS notifikasi (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  title VARCHAR(255) NOT NULL,
+  message TEXT NOT NULL,
+  is_read BOOLEAN NOT NULL DEFAULT 0,
+  user_id INTEGER NOT NULL,
+  link VARCHAR(255) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_notifikasi_user_id ON notifikasi (user_id, is_read);
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  from_name VARCHAR(255) NOT NULL DEFAULT '',
+  from_address VARCHAR(255) NOT NULL DEFAULT '',
+  recipient TEXT NOT NULL,
+  cc TEXT NULL,
+  bcc TEXT NULL,
+  reply_to VARCHAR(255) NOT NULL DEFAULT '',
+  subject VARCHAR(255) NOT NULL DEFAULT '',
+  body_html TEXT NULL,
+  body_text TEXT NULL,
+  attachments TEXT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL,
+  last_error TEXT NULL,
+  sent_at DATETIME NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_status ON email_outbox (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS email_template (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(100) NOT NULL,
+  language VARCHAR(5) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  body_html TEXT NOT NULL,
+  version INTEGER NOT NULL DEFAULT 1,
+  is_delete BOOLEAN NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_email_template_name ON email_template (name, language);
+
+CREATE TABLE IF NOT EXISTS email_template_version (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  email_template_id INTEGER NOT NULL,
+  version INTEGER NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  body_html TEXT NOT NULL,
+  created_by INTEGER NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_email_template_version ON email_template_version (email_template_id, version);
+
+CREATE TABLE IF NOT EXISTS dokumen_folder (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  parent_id INTEGER NULL,
+  divisi_id INTEGER NOT NULL,
+  created_by INTEGER NOT NULL DEFAULT 0,
+  is_delete BOOLEAN NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_dokumen_folder_divisi_id ON dokumen_folder (divisi_id);
+CREATE INDEX IF NOT EXISTS idx_dokumen_folder_parent_id ON dokumen_folder (parent_id);
+
+CREATE TABLE IF NOT EXISTS dokumen (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR(255) NOT NULL,
+  size BIGINT NOT NULL DEFAULT 0,
+  mime_type VARCHAR(255) NOT NULL DEFAULT '',
+  checksum CHAR(64) NOT NULL DEFAULT '',
+  storage_key VARCHAR(512) NOT NULL,
+  folder_id INTEGER NULL,
+  divisi_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  is_delete BOOLEAN NOT NULL DEFAULT 0,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_dokumen_divisi_id ON dokumen (divisi_id, checksum);
+CREATE INDEX IF NOT EXISTS idx_dokumen_folder_id ON dokumen (folder_id);
+
+CREATE TABLE IF NOT EXISTS upload_session (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  uid VARCHAR(64) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  size BIGINT NOT NULL,
+  folder_id INTEGER NULL,
+  divisi_id INTEGER NOT NULL,
+  user_id INTEGER NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  dokumen_id INTEGER NULL,
+  expired_at DATETIME NOT NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_upload_session_uid ON upload_session (uid);
+CREATE INDEX IF NOT EXISTS idx_upload_session_status ON upload_session (status, expired_at);
+
+CREATE TABLE IF NOT EXISTS import_job (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  uid VARCHAR(64) NOT NULL,
+  kind VARCHAR(50) NOT NULL,
+  user_id INTEGER NOT NULL,
+  file_name VARCHAR(255) NOT NULL DEFAULT '',
+  storage_key VARCHAR(512) NOT NULL DEFAULT '',
+  format VARCHAR(10) NOT NULL,
+  dry_run BOOLEAN NOT NULL DEFAULT 0,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  total INTEGER NOT NULL DEFAULT 0,
+  valid INTEGER NOT NULL DEFAULT 0,
+  invalid INTEGER NOT NULL DEFAULT 0,
+  created INTEGER NOT NULL DEFAULT 0,
+  report TEXT NULL,
+  error TEXT NULL,
+  finished_at DATETIME NULL,
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_import_job_uid ON import_job (uid);
+CREATE INDEX IF NOT EXISTS idx_import_job_status ON import_job (status);
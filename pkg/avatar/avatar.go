@@ -0,0 +1,123 @@
+package avatar
+
+import (
+	"bytes"
+	"daarul_mukhtarin/pkg/constant"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"sort"
+
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/disintegration/imaging"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	DEFAULT_SIZE = 128
+	// maxPixels rejects images that would take too much memory to decode.
+	maxPixels = 40_000_000
+	quality   = 85
+)
+
+var (
+	SIZES = []int{64, 128, 256, 512}
+
+	ErrInvalidImage = errors.New("invalid image")
+	ErrImageTooBig  = errors.New("image dimensions are too large")
+)
+
+type Thumbnail struct {
+	Size    int
+	Content []byte
+}
+
+// Thumbnails decodes content and renders a square JPEG for every size in SIZES. The image is
+// rotated according to its EXIF orientation and re-encoded, so no metadata is carried over.
+func Thumbnails(content io.Reader) ([]Thumbnail, error) {
+	raw, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooBig
+	}
+	img, err := imaging.Decode(bytes.NewReader(raw), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	// flatten transparent images on white since jpeg has no alpha
+	bounds := img.Bounds()
+	flat := imaging.New(bounds.Dx(), bounds.Dy(), color.White)
+	flat = imaging.Overlay(flat, img, image.Pt(0, 0), 1)
+
+	var thumbnails []Thumbnail
+	for _, size := range SIZES {
+		thumb := imaging.Fill(flat, size, size, imaging.Center, imaging.Lanczos)
+		var buf bytes.Buffer
+		if err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, Thumbnail{Size: size, Content: buf.Bytes()})
+	}
+	return thumbnails, nil
+}
+
+// Avatar is what gets stored in the user avatar column: the storage key of every thumbnail and
+// a version that changes on every upload so clients can cache the url.
+type Avatar struct {
+	Version int64          `json:"version"`
+	Keys    map[int]string `json:"keys"`
+}
+
+func Parse(raw string) *Avatar {
+	if raw == "" {
+		return nil
+	}
+	var a Avatar
+	if err := json.Unmarshal([]byte(raw), &a); err != nil || len(a.Keys) == 0 {
+		return nil
+	}
+	return &a
+}
+
+func (a *Avatar) String() string {
+	b, _ := json.Marshal(a)
+	return string(b)
+}
+
+// Key returns the key of the smallest thumbnail not smaller than size, or the largest one.
+func (a *Avatar) Key(size int) string {
+	sizes := make([]int, 0, len(a.Keys))
+	for s := range a.Keys {
+		sizes = append(sizes, s)
+	}
+	sort.Ints(sizes)
+	for _, s := range sizes {
+		if s >= size {
+			return a.Keys[s]
+		}
+	}
+	return a.Keys[sizes[len(sizes)-1]]
+}
+
+// URL returns the public avatar url of userId, nil when the user has no avatar.
+func URL(userId int, raw string) *string {
+	a := Parse(raw)
+	if a == nil {
+		return nil
+	}
+	url := fmt.Sprintf("%s/user/%d/avatar?v=%d", constant.BASE_URL, userId, a.Version)
+	return &url
+}
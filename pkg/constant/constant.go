@@ -8,6 +8,24 @@ const (
 	REDIS_REQUEST_IP_KEYS      = "reset-password:ip:%s"
 	REDIS_REQUEST_MAX_ATTEMPTS = 5
 	REDIS_REQUEST_IP_EXPIRE    = 240
+
+	REDIS_DOWNLOAD_DENY_KEYS = "download:deny:%s"
+	REDIS_DOWNLOAD_USED_KEYS = "download:used:%s"
+
+	OUTBOX_STATUS_PENDING = "pending"
+	OUTBOX_STATUS_SENT    = "sent"
+	OUTBOX_STATUS_DEAD    = "dead"
+
+	UPLOAD_STATUS_PENDING    = "pending"
+	UPLOAD_STATUS_ASSEMBLING = "assembling"
+	UPLOAD_STATUS_COMPLETED  = "completed"
+	UPLOAD_STATUS_ABORTED    = "aborted"
+	UPLOAD_STATUS_EXPIRED    = "expired"
+
+	IMPORT_STATUS_PENDING   = "pending"
+	IMPORT_STATUS_RUNNING   = "running"
+	IMPORT_STATUS_COMPLETED = "completed"
+	IMPORT_STATUS_FAILED    = "failed"
 )
 
 var (
@@ -2,33 +2,76 @@ package database
 
 import (
 	"daarul_mukhtarin/internal/config"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
-	"gorm.io/driver/mysql"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-type Db interface {
-	Init() (*gorm.DB, error)
-}
+// The supported drivers, named as their gorm dialector so a *gorm.DB can be told apart with
+// db.Dialector.Name().
+const (
+	MYSQL    = "mysql"
+	POSTGRES = "postgres"
+	SQLITE   = "sqlite"
+)
 
-type db struct {
-	Host string
-	User string
-	Pass string
-	Port string
-	Name string
+var dbConnections map[string]*gorm.DB
+
+// Driver returns the configured DB_DRIVER, mysql when it is not set.
+func Driver() string {
+	driver := strings.ToLower(strings.TrimSpace(config.Get().DB.DbDriver))
+	if driver == "" {
+		return MYSQL
+	}
+	return driver
 }
 
-type dbMySQL struct {
-	db
+func Init() {
+
+	conf := db{
+		Host:    config.Get().DB.DbHost,
+		User:    config.Get().DB.DbUser,
+		Pass:    config.Get().DB.DbPass,
+		Port:    config.Get().DB.DbPort,
+		Name:    config.Get().DB.DbName,
+		SslMode: config.Get().DB.DbSslMode,
+	}
+	dbConfigurations := map[string]Db{
+		MYSQL:    &dbMySQL{db: conf},
+		POSTGRES: &dbPostgreSQL{db: conf},
+		SQLITE:   &dbSQLite{db: conf},
+	}
+
+	driver := Driver()
+	v, ok := dbConfigurations[driver]
+	if !ok {
+		panic(fmt.Sprintf("Unknown database driver %s, use mysql, postgres or sqlite", driver))
+	}
+
+	k := strings.ToUpper(driver)
+	dbConnections = make(map[string]*gorm.DB)
+	db, err := v.Init()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to connect to database %s: %s", k, err.Error()))
+	}
+	dbConnections[k] = db
+	logrus.Info(fmt.Sprintf("Successfully connected to %s", k))
 }
 
-func (c *dbMySQL) Init() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", c.User, c.Pass, c.Host, c.Port, c.Name)
+func Connection(name string) (*gorm.DB, error) {
+	if dbConnections[strings.ToUpper(name)] == nil {
+		return nil, errors.New("Connection is undefined")
+	}
+	return dbConnections[strings.ToUpper(name)], nil
+}
 
+// gormConfig is shared by every driver, the log level comes from GORM_LEVEL.
+func gormConfig() *gorm.Config {
 	var level logger.LogLevel = 4
 	if gormLevel, _ := strconv.Atoi(config.Get().Logging.GormLevel); gormLevel != 0 {
 		switch gormLevel {
@@ -42,12 +85,7 @@ func (c *dbMySQL) Init() (*gorm.DB, error) {
 			level = 4
 		}
 	}
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+	return &gorm.Config{
 		Logger: logger.Default.LogMode(level),
-	})
-	if err != nil {
-		return nil, err
 	}
-	return db, nil
 }
@@ -1,45 +1,35 @@
 package database
 
 import (
-	"daarul_mukhtarin/internal/config"
-	"errors"
 	"fmt"
-	"strings"
 
-	"github.com/sirupsen/logrus"
+	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-var dbConnections map[string]*gorm.DB
-
-func Init() {
+type Db interface {
+	Init() (*gorm.DB, error)
+}
 
-	dbConfigurations := map[string]Db{
-		"MYSQL": &dbMySQL{
-			db: db{
-				Host: config.Get().DB.DbHost,
-				User: config.Get().DB.DbUser,
-				Pass: config.Get().DB.DbPass,
-				Port: config.Get().DB.DbPort,
-				Name: config.Get().DB.DbName,
-			},
-		},
-	}
+type db struct {
+	Host    string
+	User    string
+	Pass    string
+	Port    string
+	Name    string
+	SslMode string
+}
 
-	dbConnections = make(map[string]*gorm.DB)
-	for k, v := range dbConfigurations {
-		db, err := v.Init()
-		if err != nil {
-			panic(fmt.Sprintf("Failed to connect to database %s", k))
-		}
-		dbConnections[k] = db
-		logrus.Info(fmt.Sprintf("Successfully connected to %s", k))
-	}
+type dbMySQL struct {
+	db
 }
 
-func Connection(name string) (*gorm.DB, error) {
-	if dbConnections[strings.ToUpper(name)] == nil {
-		return nil, errors.New("Connection is undefined")
+func (c *dbMySQL) Init() (*gorm.DB, error) {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", c.User, c.Pass, c.Host, c.Port, c.Name)
+
+	db, err := gorm.Open(mysql.Open(dsn), gormConfig())
+	if err != nil {
+		return nil, err
 	}
-	return dbConnections[strings.ToUpper(name)], nil
+	return db, nil
 }
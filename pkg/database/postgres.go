@@ -0,0 +1,26 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type dbPostgreSQL struct {
+	db
+}
+
+func (c *dbPostgreSQL) Init() (*gorm.DB, error) {
+	sslMode := c.SslMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s", c.Host, c.User, c.Pass, c.Name, c.Port, sslMode)
+
+	db, err := gorm.Open(postgres.Open(dsn), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
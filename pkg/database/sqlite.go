@@ -0,0 +1,33 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type dbSQLite struct {
+	db
+}
+
+// Init opens the database file named by DB_NAME, daarul_mukhtarin.db when it is empty. The
+// driver uses cgo, a binary built with CGO_ENABLED=0 fails here. WAL and a busy timeout let the
+// background workers write while requests are served, SQLite still allows one writer at a time.
+func (c *dbSQLite) Init() (*gorm.DB, error) {
+	name := c.Name
+	if name == "" {
+		name = "daarul_mukhtarin.db"
+	}
+	separator := "?"
+	if strings.Contains(name, "?") {
+		separator = "&"
+	}
+	dsn := name + separator + "_busy_timeout=5000&_journal_mode=WAL"
+
+	db, err := gorm.Open(sqlite.Open(dsn), gormConfig())
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
@@ -0,0 +1,250 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FORMAT_CSV  = "csv"
+	FORMAT_XLSX = "xlsx"
+
+	CONTENT_TYPE_CSV  = "text/csv"
+	CONTENT_TYPE_XLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	TIME_LAYOUT = "2006-01-02 15:04:05"
+
+	sheetName = "Sheet1"
+	flushRows = 500
+)
+
+// Column is an exportable field, Headers holds its title per language.
+type Column struct {
+	Key     string
+	Headers map[string]string
+}
+
+// Header is the title of c in lang, falling back to its key.
+func (c Column) Header(lang string) string {
+	if header, ok := c.Headers[lang]; ok {
+		return header
+	}
+	return c.Key
+}
+
+// Export is a list written as a file, Rows calls row once per record so the records never have
+// to be held in memory together.
+type Export struct {
+	Name     string
+	Format   string
+	Language string
+	Columns  []Column
+	Rows     func(row func(map[string]interface{}) error) error
+}
+
+// Format returns the export format asked for with ?format= or the Accept header, or "" when the
+// request wants the usual json.
+func Format(r *http.Request) (string, error) {
+	switch format := strings.ToLower(r.URL.Query().Get("format")); format {
+	case "", "json":
+	case FORMAT_CSV, FORMAT_XLSX:
+		return format, nil
+	default:
+		return "", fmt.Errorf("format %q is not supported, use csv or xlsx", format)
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		switch mediaType {
+		case CONTENT_TYPE_CSV:
+			return FORMAT_CSV, nil
+		case CONTENT_TYPE_XLSX:
+			return FORMAT_XLSX, nil
+		}
+	}
+	return "", nil
+}
+
+// Columns picks the columns named in param, a comma separated list, or all of them when empty.
+func Columns(all []Column, param string) ([]Column, error) {
+	if param == "" {
+		return all, nil
+	}
+	var res []Column
+	for _, key := range strings.Split(param, ",") {
+		key = strings.TrimSpace(key)
+		found := false
+		for _, column := range all {
+			if column.Key == key {
+				res = append(res, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", key)
+		}
+	}
+	return res, nil
+}
+
+func (e *Export) ContentType() string {
+	if e.Format == FORMAT_XLSX {
+		return CONTENT_TYPE_XLSX
+	}
+	return CONTENT_TYPE_CSV + "; charset=utf-8"
+}
+
+func (e *Export) Filename() string {
+	return fmt.Sprintf("%s_%s.%s", e.Name, time.Now().Format("20060102_150405"), e.Format)
+}
+
+// Send answers the request with the export as an attachment. Once the first row is written the
+// status can no longer change, so a failure after that is only logged.
+func (e *Export) Send(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, e.ContentType())
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": e.Filename()}))
+	c.Response().WriteHeader(http.StatusOK)
+	if err := e.Write(c.Response()); err != nil {
+		logrus.Error("failed write export ", e.Name, ": ", err)
+	}
+	return nil
+}
+
+// Write streams the export to w.
+func (e *Export) Write(w io.Writer) error {
+	if e.Format == FORMAT_XLSX {
+		return e.writeXLSX(w)
+	}
+	return e.writeCSV(w)
+}
+
+func (e *Export) headers() []string {
+	headers := make([]string, len(e.Columns))
+	for i, column := range e.Columns {
+		headers[i] = column.Header(e.Language)
+	}
+	return headers
+}
+
+func (e *Export) writeCSV(w io.Writer) error {
+	// the BOM makes Excel open the file as utf-8
+	if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(e.headers()); err != nil {
+		return err
+	}
+	record := make([]string, len(e.Columns))
+	count := 0
+	err := e.Rows(func(row map[string]interface{}) error {
+		for i, column := range e.Columns {
+			record[i] = text(row[column.Key])
+			if _, ok := row[column.Key].(string); ok {
+				record[i] = neutralize(record[i])
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		count++
+		if flusher, ok := w.(http.Flusher); ok && count%flushRows == 0 {
+			writer.Flush()
+			flusher.Flush()
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeXLSX uses the excelize stream writer, which keeps the rows in a temporary file rather
+// than in memory until the workbook is written out.
+func (e *Export) writeXLSX(w io.Writer) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	stream, err := file.NewStreamWriter(sheetName)
+	if err != nil {
+		return err
+	}
+	headers := make([]interface{}, len(e.Columns))
+	for i, header := range e.headers() {
+		headers[i] = excelize.Cell{Value: header}
+	}
+	if err = stream.SetRow("A1", headers); err != nil {
+		return err
+	}
+
+	line := 1
+	err = e.Rows(func(row map[string]interface{}) error {
+		line++
+		values := make([]interface{}, len(e.Columns))
+		for i, column := range e.Columns {
+			values[i] = cell(row[column.Key])
+		}
+		cellName, err := excelize.CoordinatesToCellName(1, line)
+		if err != nil {
+			return err
+		}
+		return stream.SetRow(cellName, values)
+	})
+	if err != nil {
+		return err
+	}
+	if err = stream.Flush(); err != nil {
+		return err
+	}
+	return file.Write(w)
+}
+
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(TIME_LAYOUT)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(TIME_LAYOUT)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// neutralize keeps a text value that starts like a formula from being run when the csv is
+// opened in a spreadsheet.
+func neutralize(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func cell(value interface{}) interface{} {
+	switch v := value.(type) {
+	case time.Time, *time.Time, *string, nil:
+		return text(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,205 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MAX_IMPORT_ROWS caps the data rows of an imported file.
+const MAX_IMPORT_ROWS = 10000
+
+var (
+	ErrNoHeader    = errors.New("the file has no header row")
+	ErrTooManyRows = fmt.Errorf("the file has more than %d rows", MAX_IMPORT_ROWS)
+)
+
+// ImportFormat tells the format of an uploaded file from its sniffed content type.
+func ImportFormat(contentType string) string {
+	if contentType == CONTENT_TYPE_XLSX || contentType == "application/zip" {
+		return FORMAT_XLSX
+	}
+	return FORMAT_CSV
+}
+
+// Read calls fn with every non empty data row of a csv or xlsx file, keyed by column key. The
+// header row may name a column by its key or by any of its headers, in any order, so a file
+// written by Export can be read back. line is the row number as shown in a spreadsheet.
+func Read(r io.Reader, format string, columns []Column, fn func(line int, record map[string]string) error) error {
+	if format == FORMAT_XLSX {
+		return readXLSX(r, columns, fn)
+	}
+	return readCSV(r, columns, fn)
+}
+
+func readCSV(r io.Reader, columns []Column, fn func(line int, record map[string]string) error) error {
+	br := bufio.NewReader(r)
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte("\xEF\xBB\xBF")) {
+		br.Discard(3)
+	}
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	// spreadsheets in a locale with a decimal comma save csv separated by semicolons
+	if head, _ := br.Peek(4096); !bytes.ContainsRune(firstLine(head), ',') && bytes.ContainsRune(firstLine(head), ';') {
+		reader.Comma = ';'
+	}
+
+	var keys []string
+	line, count := 0, 0
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		line++
+		if keys == nil {
+			if keys, err = headerKeys(values, columns); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = readRow(line, &count, keys, values, fn); err != nil {
+			return err
+		}
+	}
+	if keys == nil {
+		return ErrNoHeader
+	}
+	return nil
+}
+
+func readXLSX(r io.Reader, columns []Column, fn func(line int, record map[string]string) error) error {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return ErrNoHeader
+	}
+	rows, err := file.Rows(sheets[0])
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var keys []string
+	line, count := 0, 0
+	for rows.Next() {
+		line++
+		values, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		if keys == nil {
+			if isEmpty(values) {
+				continue
+			}
+			if keys, err = headerKeys(values, columns); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = readRow(line, &count, keys, values, fn); err != nil {
+			return err
+		}
+	}
+	if err = rows.Error(); err != nil {
+		return err
+	}
+	if keys == nil {
+		return ErrNoHeader
+	}
+	return nil
+}
+
+// readRow hands a data row to fn unless it is empty, count keeps the number of rows read.
+func readRow(line int, count *int, keys []string, values []string, fn func(line int, record map[string]string) error) error {
+	if isEmpty(values) {
+		return nil
+	}
+	if *count++; *count > MAX_IMPORT_ROWS {
+		return ErrTooManyRows
+	}
+	record := make(map[string]string, len(keys))
+	for i, key := range keys {
+		if key == "" || i >= len(values) {
+			continue
+		}
+		record[key] = unneutralize(strings.TrimSpace(values[i]))
+	}
+	return fn(line, record)
+}
+
+// headerKeys maps every header cell to a column key, an empty cell is skipped.
+func headerKeys(values []string, columns []Column) ([]string, error) {
+	keys := make([]string, len(values))
+	seen := make(map[string]bool, len(values))
+	for i, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		key := ""
+		for _, column := range columns {
+			if strings.EqualFold(value, column.Key) {
+				key = column.Key
+				break
+			}
+			for _, header := range column.Headers {
+				if strings.EqualFold(value, header) {
+					key = column.Key
+					break
+				}
+			}
+			if key != "" {
+				break
+			}
+		}
+		if key == "" {
+			return nil, fmt.Errorf("unknown column %q", value)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("column %q is given twice", value)
+		}
+		seen[key] = true
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+func isEmpty(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func firstLine(b []byte) []byte {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		return b[:i]
+	}
+	return b
+}
+
+// unneutralize undoes neutralize, so a value exported from a csv is imported unchanged.
+func unneutralize(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
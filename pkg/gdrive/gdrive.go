@@ -6,16 +6,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
-	"os"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
+const (
+	MIME_TYPE_FOLDER = "application/vnd.google-apps.folder"
+	DRIVE_CHUNK_SIZE = 8 << 20
+)
+
 func FileToDrive(service *drive.Service, name string, mimeType string, content io.Reader, parentId string) (*drive.File, error) {
 	f := &drive.File{
 		MimeType: mimeType,
@@ -32,10 +37,32 @@ func FileToDrive(service *drive.Service, name string, mimeType string, content i
 	return file, nil
 }
 
+// FileToDriveResumable uploads content in DRIVE_CHUNK_SIZE chunks using a Drive resumable session,
+// a failed chunk is retried on its own instead of restarting the whole upload.
+func FileToDriveResumable(ctx context.Context, service *drive.Service, name string, mimeType string, content io.Reader, parentId string, fields ...googleapi.Field) (*drive.File, error) {
+	f := &drive.File{
+		MimeType: mimeType,
+		Name:     name,
+		Parents:  []string{parentId},
+	}
+	file, err := service.Files.Create(f).
+		Media(content, googleapi.ChunkSize(DRIVE_CHUNK_SIZE), googleapi.ContentType(mimeType)).
+		Fields(fields...).
+		Context(ctx).
+		Do()
+
+	if err != nil {
+		logrus.Println("Could not create file: " + err.Error())
+		return nil, err
+	}
+
+	return file, nil
+}
+
 func FolderToDrive(service *drive.Service, name string, parentId string) (*drive.File, error) {
 	d := &drive.File{
 		Name:     name,
-		MimeType: "application/vnd.google-apps.folder",
+		MimeType: MIME_TYPE_FOLDER,
 		Parents:  []string{parentId},
 	}
 
@@ -49,72 +76,108 @@ func FolderToDrive(service *drive.Service, name string, parentId string) (*drive
 	return file, nil
 }
 
-func InitGoogleDrive() (*drive.Service, error) {
-	credentialsJson := config.Get().Drive.CredentialsDrive
-
-	config, err := google.ConfigFromJSON([]byte(credentialsJson), drive.DriveScope)
-
+func FileFromDrive(service *drive.Service, fileId string) (*drive.File, io.ReadCloser, error) {
+	file, err := service.Files.Get(fileId).Fields("id", "name", "mimeType", "size").Do()
 	if err != nil {
-		return nil, err
+		logrus.Println("Could not get file: " + err.Error())
+		return nil, nil, err
 	}
 
-	client := getClient(config)
-
-	service, err := drive.NewService(context.Background(), option.WithHTTPClient(client))
+	res, err := service.Files.Get(fileId).Download()
 	if err != nil {
-		logrus.Printf("Cannot create the Google Drive service: %v\n", err)
-		return nil, err
+		logrus.Println("Could not download file: " + err.Error())
+		return nil, nil, err
 	}
 
-	logrus.Info("Drive ready!")
-	return service, err
+	return file, res.Body, nil
 }
 
-func getClient(config *oauth2.Config) *http.Client {
-	tok, err := tokenFromEnv()
-	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveTokenToEnv(tok)
-		logrus.Info("Regenerate token!")
-	}
-	logrus.Info("Client found!")
-	return config.Client(context.Background(), tok)
+var (
+	lock       = &sync.Mutex{}
+	tokenStore = NewMemoryTokenStore()
+	service    *drive.Service
+)
+
+// SetTokenStore sets where the OAuth token is persisted, the shared service is recreated on next use.
+func SetTokenStore(store TokenStore) {
+	lock.Lock()
+	defer lock.Unlock()
+	tokenStore = store
+	service = nil
 }
 
-func tokenFromEnv() (*oauth2.Token, error) {
-	tokenJSON := os.Getenv("TOKEN_DRIVE")
-	if tokenJSON == "" {
-		return nil, fmt.Errorf("TOKEN_DRIVE environment variable is not set")
+// InitGoogleDrive returns the shared Drive service, creating it on first use. A failed attempt is
+// not cached, so the next call tries again.
+func InitGoogleDrive() (*drive.Service, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if service != nil {
+		return service, nil
+	}
+
+	credentialsJson := []byte(config.Get().Drive.CredentialsDrive)
+	if len(credentialsJson) == 0 {
+		return nil, ErrNoCredentials
 	}
-	tok := &oauth2.Token{}
-	err := json.Unmarshal([]byte(tokenJSON), tok)
-	return tok, err
-}
 
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	refreshToken := os.Getenv("REFRESH_DRIVE")
-	if refreshToken == "" {
-		logrus.Printf("REFRESH_TOKEN environment variable is not set")
-		return nil
+	var credentials struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(credentialsJson, &credentials); err != nil {
+		return nil, err
+	}
+
+	var ts oauth2.TokenSource
+	if credentials.Type == "service_account" {
+		jwtConfig, err := google.JWTConfigFromJSON(credentialsJson, drive.DriveScope)
+		if err != nil {
+			return nil, err
+		}
+		jwtConfig.Subject = config.Get().Drive.SubjectDrive
+		ts = jwtConfig.TokenSource(context.Background())
+	} else {
+		oauthConfig, err := google.ConfigFromJSON(credentialsJson, drive.DriveScope)
+		if err != nil {
+			return nil, err
+		}
+		ts = &tokenSource{
+			config: oauthConfig,
+			store:  tokenStore,
+			seed:   tokenFromEnv,
+		}
 	}
 
-	tok := &oauth2.Token{RefreshToken: refreshToken}
-	tokSource := config.TokenSource(context.Background(), tok)
+	// fail here rather than on the first api call when the credentials are wrong
+	if _, err := ts.Token(); err != nil {
+		if IsAuthError(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
 
-	newToken, err := tokSource.Token()
+	srv, err := drive.NewService(context.Background(), option.WithTokenSource(oauth2.ReuseTokenSource(nil, ts)))
 	if err != nil {
-		logrus.Printf("Unable to retrieve token from web: %v", err)
-		return nil
+		logrus.Printf("Cannot create the Google Drive service: %v\n", err)
+		return nil, err
 	}
 
-	return newToken
+	logrus.Info("Drive ready!")
+	service = srv
+	return service, nil
 }
 
-func saveTokenToEnv(token *oauth2.Token) {
-	tokenJSON, err := json.Marshal(token)
-	if err != nil {
-		logrus.Printf("Failed to marshal token: %v", err)
-		return
+// tokenFromEnv seeds the token store from TOKEN_DRIVE, or from REFRESH_DRIVE alone.
+func tokenFromEnv() (*oauth2.Token, error) {
+	if tokenJSON := config.Get().Drive.TokenDrive; tokenJSON != "" {
+		tok := &oauth2.Token{}
+		if err := json.Unmarshal([]byte(tokenJSON), tok); err != nil {
+			return nil, err
+		}
+		return tok, nil
+	}
+	if refreshToken := config.Get().Drive.RefreshTokenDrive; refreshToken != "" {
+		return &oauth2.Token{RefreshToken: refreshToken}, nil
 	}
-	os.Setenv("TOKEN_DRIVE", string(tokenJSON))
+	return nil, ErrNoToken
 }
@@ -0,0 +1,85 @@
+package gdrive
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+const (
+	ROLE_OWNER  = "owner"
+	ROLE_WRITER = "writer"
+	ROLE_READER = "reader"
+)
+
+// SyncPermissions makes the user permissions on fileId match want, a map of email to role.
+// Owners, the emails in keep and non user permissions (domain, anyone) are left alone.
+func SyncPermissions(ctx context.Context, service *drive.Service, fileId string, want map[string]string, keep []string) error {
+	kept := make(map[string]bool, len(keep))
+	for _, email := range keep {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			kept[email] = true
+		}
+	}
+	desired := make(map[string]string, len(want))
+	for email, role := range want {
+		desired[strings.ToLower(email)] = role
+	}
+
+	var current []*drive.Permission
+	err := service.Permissions.List(fileId).
+		Fields("nextPageToken", "permissions(id,type,emailAddress,role)").
+		SupportsAllDrives(true).
+		Pages(ctx, func(list *drive.PermissionList) error {
+			current = append(current, list.Permissions...)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	for _, permission := range current {
+		if permission.Type != "user" {
+			continue
+		}
+		email := strings.ToLower(permission.EmailAddress)
+		role, ok := desired[email]
+		delete(desired, email)
+		if permission.Role == ROLE_OWNER {
+			continue
+		}
+		if !ok {
+			if kept[email] {
+				continue
+			}
+			if err = service.Permissions.Delete(fileId, permission.Id).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+				return err
+			}
+			continue
+		}
+		if role != permission.Role {
+			if _, err = service.Permissions.Update(fileId, permission.Id, &drive.Permission{Role: role}).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for email, role := range desired {
+		permission := &drive.Permission{
+			Type:         "user",
+			Role:         role,
+			EmailAddress: email,
+		}
+		if _, err = service.Permissions.Create(fileId, permission).SendNotificationEmail(false).SupportsAllDrives(true).Context(ctx).Do(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenameFolder renames a file or folder in place.
+func RenameFolder(service *drive.Service, fileId string, name string) error {
+	_, err := service.Files.Update(fileId, &drive.File{Name: name}).Do()
+	return err
+}
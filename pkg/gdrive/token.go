@@ -0,0 +1,193 @@
+package gdrive
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/oauth2"
+)
+
+const (
+	REDIS_TOKEN_KEY      = "gdrive:token"
+	REDIS_TOKEN_LOCK_KEY = "gdrive:token:lock"
+	tokenLockExpire      = 30 * time.Second
+	tokenLockWait        = 10 * time.Second
+)
+
+var (
+	ErrNoCredentials = errors.New("google drive credentials are not configured")
+	ErrNoToken       = errors.New("google drive token is not configured, set TOKEN_DRIVE or REFRESH_DRIVE")
+	ErrUnauthorized  = errors.New("google drive authorization failed")
+	ErrTokenLocked   = errors.New("google drive token is being refreshed by another instance")
+)
+
+// IsAuthError reports whether err means Drive could not be authorized, as opposed to a failed call.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrNoCredentials) || errors.Is(err, ErrNoToken) ||
+		errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrTokenLocked)
+}
+
+// TokenStore persists the OAuth token so a refreshed token survives restarts and is shared
+// between instances. Load returns nil without error when nothing is stored yet.
+type TokenStore interface {
+	Load(ctx context.Context) (*oauth2.Token, error)
+	Save(ctx context.Context, token *oauth2.Token) error
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+type redisTokenStore struct {
+	client *redis.Client
+}
+
+func NewRedisTokenStore(client *redis.Client) TokenStore {
+	return &redisTokenStore{client}
+}
+
+func (s *redisTokenStore) Load(ctx context.Context) (*oauth2.Token, error) {
+	value, err := s.client.Get(ctx, REDIS_TOKEN_KEY).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{}
+	if err = json.Unmarshal([]byte(value), token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (s *redisTokenStore) Save(ctx context.Context, token *oauth2.Token) error {
+	value, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, REDIS_TOKEN_KEY, value, 0).Err()
+}
+
+// unlockScript only deletes the lock when it still belongs to the caller.
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+func (s *redisTokenStore) Lock(ctx context.Context) (func(), error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	owner := hex.EncodeToString(b)
+
+	deadline := time.Now().Add(tokenLockWait)
+	for {
+		ok, err := s.client.SetNX(ctx, REDIS_TOKEN_LOCK_KEY, owner, tokenLockExpire).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrTokenLocked
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return func() {
+		unlockScript.Run(context.Background(), s.client, []string{REDIS_TOKEN_LOCK_KEY}, owner)
+	}, nil
+}
+
+type memoryTokenStore struct {
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// NewMemoryTokenStore keeps the token in process, it is used until a persistent store is set.
+func NewMemoryTokenStore() TokenStore {
+	return &memoryTokenStore{}
+}
+
+func (s *memoryTokenStore) Load(ctx context.Context) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+func (s *memoryTokenStore) Save(ctx context.Context, token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+func (s *memoryTokenStore) Lock(ctx context.Context) (func(), error) {
+	return func() {}, nil
+}
+
+// tokenSource hands out the cached token and refreshes it through the store when it expires,
+// holding the store lock so only one instance talks to Google at a time.
+type tokenSource struct {
+	mu     sync.Mutex
+	config *oauth2.Config
+	store  TokenStore
+	seed   func() (*oauth2.Token, error)
+	token  *oauth2.Token
+}
+
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token.Valid() {
+		return s.token, nil
+	}
+
+	ctx := context.Background()
+	unlock, err := s.store.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// another instance may have refreshed the token while we were waiting for the lock
+	token, err := s.store.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		if token, err = s.seed(); err != nil {
+			return nil, err
+		}
+	}
+	if token.Valid() {
+		s.token = token
+		return token, nil
+	}
+	if token.RefreshToken == "" {
+		return nil, ErrNoToken
+	}
+
+	newToken, err := s.config.TokenSource(ctx, token).Token()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthorized, err)
+	}
+	if newToken.RefreshToken == "" {
+		newToken.RefreshToken = token.RefreshToken
+	}
+	if err = s.store.Save(ctx, newToken); err != nil {
+		return nil, err
+	}
+
+	s.token = newToken
+	return newToken, nil
+}
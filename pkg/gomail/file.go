@@ -0,0 +1,56 @@
+package gomail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type fileMailer struct {
+	dir string
+}
+
+// NewFileMailer writes every message as an .eml file inside dir instead of sending it.
+func NewFileMailer(dir string) (*fileMailer, error) {
+	if dir == "" {
+		dir = "./mail"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileMailer{dir: dir}, nil
+}
+
+func (m *fileMailer) Send(message *Message) error {
+	mailer, err := message.build()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), sanitizeFileName(message.Recipients()[0]))
+	f, err := os.Create(filepath.Join(m.dir, name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err = mailer.WriteTo(f); err != nil {
+		return err
+	}
+
+	logrus.Info("Mail written to ", f.Name())
+	return nil
+}
+
+func sanitizeFileName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '.' && c != '-' && c != '@' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
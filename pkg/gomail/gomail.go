@@ -2,37 +2,30 @@ package gomail
 
 import (
 	"daarul_mukhtarin/internal/config"
-	"errors"
-	"strconv"
-
-	"github.com/sirupsen/logrus"
-	"gopkg.in/gomail.v2"
+	"fmt"
+	"strings"
 )
 
-func SendMail(recipient, subject, bodyHtml string) error {
-	if bodyHtml == "" {
-		return errors.New("error parsing body html")
-	}
-
-	mailer := gomail.NewMessage()
-	mailer.SetHeader("From", config.Get().Gomail.SenderName)
-	mailer.SetHeader("To", recipient)
-	mailer.SetHeader("Subject", subject)
-	mailer.SetBody("text/html", bodyHtml)
+const (
+	DRIVER_SMTP   = "smtp"
+	DRIVER_FILE   = "file"
+	DRIVER_MEMORY = "memory"
+)
 
-	portMail, _ := strconv.Atoi(config.Get().Gomail.SmtpPort)
-	dialer := gomail.NewDialer(
-		config.Get().Gomail.SmtpHost,
-		portMail,
-		config.Get().Gomail.AuthEmail,
-		config.Get().Gomail.AuthPassword,
-	)
+// Mailer is implemented by every mail backend the application can be configured with.
+type Mailer interface {
+	Send(message *Message) error
+}
 
-	err := dialer.DialAndSend(mailer)
-	if err != nil {
-		return err
+// NewMailer builds the backend selected by MAIL_DRIVER, defaulting to smtp.
+func NewMailer() (Mailer, error) {
+	switch strings.ToLower(config.Get().Gomail.Driver) {
+	case "", DRIVER_SMTP:
+		return NewSmtpMailer(), nil
+	case DRIVER_FILE:
+		return NewFileMailer(config.Get().Gomail.FileDir)
+	case DRIVER_MEMORY:
+		return NewMemoryMailer(), nil
 	}
-
-	logrus.Info("Mail sent!")
-	return nil
+	return nil, fmt.Errorf("unknown mail driver %s", config.Get().Gomail.Driver)
 }
@@ -0,0 +1,202 @@
+package gomail
+
+import (
+	"bytes"
+	"daarul_mukhtarin/internal/config"
+	"errors"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/gomail.v2"
+)
+
+type Address struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+type Attachment struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+	// Inline attachments are embedded and can be referenced from the html body with cid:<Name>.
+	Inline bool `json:"inline"`
+}
+
+// Message is a mail that can be handed to any Mailer, build it with NewMessage and the chained setters.
+type Message struct {
+	From        *Address     `json:"from"`
+	To          []string     `json:"to"`
+	Cc          []string     `json:"cc"`
+	Bcc         []string     `json:"bcc"`
+	ReplyTo     string       `json:"reply_to"`
+	Subject     string       `json:"subject"`
+	BodyHtml    string       `json:"body_html"`
+	BodyText    string       `json:"body_text"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+func NewMessage(subject, bodyHtml string, to ...string) *Message {
+	return &Message{
+		To:       to,
+		Subject:  subject,
+		BodyHtml: bodyHtml,
+	}
+}
+
+func (m *Message) SetFrom(name, address string) *Message {
+	m.From = &Address{Name: name, Address: address}
+	return m
+}
+
+func (m *Message) AddTo(to ...string) *Message {
+	m.To = append(m.To, to...)
+	return m
+}
+
+func (m *Message) AddCc(cc ...string) *Message {
+	m.Cc = append(m.Cc, cc...)
+	return m
+}
+
+func (m *Message) AddBcc(bcc ...string) *Message {
+	m.Bcc = append(m.Bcc, bcc...)
+	return m
+}
+
+func (m *Message) SetReplyTo(replyTo string) *Message {
+	m.ReplyTo = replyTo
+	return m
+}
+
+// SetText overrides the plain-text part that is otherwise generated from the html body.
+func (m *Message) SetText(bodyText string) *Message {
+	m.BodyText = bodyText
+	return m
+}
+
+func (m *Message) Attach(name, contentType string, content []byte) *Message {
+	m.Attachments = append(m.Attachments, Attachment{
+		Name:        name,
+		ContentType: contentType,
+		Content:     content,
+	})
+	return m
+}
+
+func (m *Message) Embed(name, contentType string, content []byte) *Message {
+	m.Attachments = append(m.Attachments, Attachment{
+		Name:        name,
+		ContentType: contentType,
+		Content:     content,
+		Inline:      true,
+	})
+	return m
+}
+
+func (m *Message) AttachReader(name, contentType string, r io.Reader) error {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.Attach(name, contentType, content)
+	return nil
+}
+
+func (m *Message) AttachFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m.Attach(filepath.Base(path), mime.TypeByExtension(filepath.Ext(path)), content)
+	return nil
+}
+
+// Recipients returns every address the message will be delivered to.
+func (m *Message) Recipients() []string {
+	var recipients []string
+	recipients = append(recipients, m.To...)
+	recipients = append(recipients, m.Cc...)
+	recipients = append(recipients, m.Bcc...)
+	return recipients
+}
+
+func (m *Message) validate() error {
+	if m.BodyHtml == "" {
+		return errors.New("error parsing body html")
+	}
+	if len(m.Recipients()) == 0 {
+		return errors.New("message has no recipient")
+	}
+	return nil
+}
+
+func (m *Message) build() (*gomail.Message, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	from := m.From
+	if from == nil {
+		from = defaultFrom()
+	}
+
+	mailer := gomail.NewMessage()
+	if from.Address != "" {
+		mailer.SetAddressHeader("From", from.Address, from.Name)
+	} else {
+		mailer.SetHeader("From", from.Name)
+	}
+	if len(m.To) > 0 {
+		mailer.SetHeader("To", m.To...)
+	}
+	if len(m.Cc) > 0 {
+		mailer.SetHeader("Cc", m.Cc...)
+	}
+	if len(m.Bcc) > 0 {
+		mailer.SetHeader("Bcc", m.Bcc...)
+	}
+	if m.ReplyTo != "" {
+		mailer.SetHeader("Reply-To", m.ReplyTo)
+	}
+	mailer.SetHeader("Subject", m.Subject)
+
+	bodyText := m.BodyText
+	if bodyText == "" {
+		bodyText = HtmlToText(m.BodyHtml)
+	}
+	mailer.SetBody("text/plain", bodyText)
+	mailer.AddAlternative("text/html", m.BodyHtml)
+
+	for _, v := range m.Attachments {
+		content := v.Content
+		settings := []gomail.FileSetting{
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := io.Copy(w, bytes.NewReader(content))
+				return err
+			}),
+		}
+		if v.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{"Content-Type": {v.ContentType}}))
+		}
+		if v.Inline {
+			mailer.Embed(v.Name, settings...)
+		} else {
+			mailer.Attach(v.Name, settings...)
+		}
+	}
+	return mailer, nil
+}
+
+func defaultFrom() *Address {
+	address := config.Get().Gomail.SenderEmail
+	if address == "" {
+		address = config.Get().Gomail.AuthEmail
+	}
+	return &Address{
+		Name:    config.Get().Gomail.SenderName,
+		Address: address,
+	}
+}
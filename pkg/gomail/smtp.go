@@ -0,0 +1,86 @@
+package gomail
+
+import (
+	"daarul_mukhtarin/internal/config"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/gomail.v2"
+)
+
+// idleTimeout is how long an unused SMTP connection is kept open before it is closed.
+const idleTimeout = 30 * time.Second
+
+type smtpMailer struct {
+	dialer *gomail.Dialer
+
+	mu     sync.Mutex
+	sender gomail.SendCloser
+	timer  *time.Timer
+}
+
+func NewSmtpMailer() *smtpMailer {
+	portMail, _ := strconv.Atoi(config.Get().Gomail.SmtpPort)
+	return &smtpMailer{
+		dialer: gomail.NewDialer(
+			config.Get().Gomail.SmtpHost,
+			portMail,
+			config.Get().Gomail.AuthEmail,
+			config.Get().Gomail.AuthPassword,
+		),
+	}
+}
+
+func (m *smtpMailer) Send(message *Message) error {
+	mailer, err := message.build()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err = m.send(mailer); err != nil {
+		// the server may have dropped an idle connection, retry once on a fresh one
+		m.close()
+		if err = m.send(mailer); err != nil {
+			m.close()
+			return err
+		}
+	}
+
+	if m.timer != nil {
+		m.timer.Stop()
+	}
+	m.timer = time.AfterFunc(idleTimeout, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.close()
+	})
+
+	logrus.Info("Mail sent!")
+	return nil
+}
+
+func (m *smtpMailer) send(message *gomail.Message) error {
+	if m.sender == nil {
+		sender, err := m.dialer.Dial()
+		if err != nil {
+			return err
+		}
+		m.sender = sender
+	}
+	return gomail.Send(m.sender, message)
+}
+
+func (m *smtpMailer) close() {
+	if m.sender == nil {
+		return
+	}
+	if err := m.sender.Close(); err != nil {
+		logrus.Error("Error closing smtp connection: ", err.Error())
+	}
+	m.sender = nil
+}
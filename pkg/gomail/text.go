@@ -0,0 +1,78 @@
+package gomail
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	spaceRegex     = regexp.MustCompile(`[ \t\r\f]+`)
+	blankLineRegex = regexp.MustCompile(`\n[ \n]*\n`)
+)
+
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "tr": true, "table": true, "li": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "section": true,
+}
+
+// HtmlToText renders a readable plain-text version of an html mail body.
+func HtmlToText(bodyHtml string) string {
+	var (
+		buf   strings.Builder
+		skip  int
+		href  string
+		token = html.NewTokenizer(strings.NewReader(bodyHtml))
+	)
+	for {
+		switch token.Next() {
+		case html.ErrorToken:
+			text := spaceRegex.ReplaceAllString(buf.String(), " ")
+			lines := strings.Split(text, "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace(line)
+			}
+			text = blankLineRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+			return strings.TrimSpace(text)
+		case html.TextToken:
+			if skip == 0 {
+				buf.WriteString(strings.ReplaceAll(string(token.Text()), "\n", " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := token.TagName()
+			tag := string(name)
+			switch {
+			case tag == "style" || tag == "script" || tag == "head" || tag == "title":
+				skip++
+			case tag == "a":
+				href = ""
+				for hasAttr {
+					var key, val []byte
+					key, val, hasAttr = token.TagAttr()
+					if string(key) == "href" {
+						href = string(val)
+					}
+				}
+			case blockTags[tag]:
+				buf.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := token.TagName()
+			tag := string(name)
+			switch {
+			case tag == "style" || tag == "script" || tag == "head" || tag == "title":
+				if skip > 0 {
+					skip--
+				}
+			case tag == "a":
+				if href != "" && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "mailto:") {
+					buf.WriteString(" (" + href + ")")
+				}
+				href = ""
+			case blockTags[tag]:
+				buf.WriteString("\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package mailtemplate
+
+import (
+	"bytes"
+	"daarul_mukhtarin/assets"
+	"daarul_mukhtarin/internal/config"
+	"daarul_mukhtarin/pkg/gomail"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	textTemplate "text/template"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LANGUAGE_ID = "id"
+	LANGUAGE_EN = "en"
+
+	TEMPLATE_CREATE_USER            = "notif_create_user"
+	TEMPLATE_RESET_PASSWORD_ADMIN   = "reset_password_admin"
+	TEMPLATE_FORGOT_PASSWORD        = "forgot_password"
+	TEMPLATE_LOCKED_USER            = "notification_locked_user"
+	TEMPLATE_RESET_PASSWORD_SUCCESS = "reset_password_success"
+	TEMPLATE_RESET_PASSWORD_FAILED  = "reset_password_failed"
+)
+
+var (
+	Languages = []string{LANGUAGE_ID, LANGUAGE_EN}
+	Templates = []string{
+		TEMPLATE_CREATE_USER,
+		TEMPLATE_RESET_PASSWORD_ADMIN,
+		TEMPLATE_FORGOT_PASSWORD,
+		TEMPLATE_LOCKED_USER,
+		TEMPLATE_RESET_PASSWORD_SUCCESS,
+		TEMPLATE_RESET_PASSWORD_FAILED,
+	}
+)
+
+type entry struct {
+	subject *textTemplate.Template
+	body    *template.Template
+}
+
+// Override is a template stored outside the binary, e.g. edited by an admin.
+type Override struct {
+	Subject string
+	Body    string
+	Version int
+}
+
+// Store looks up an override for a template, it returns nil when the default must be used.
+type Store interface {
+	Find(name, lang string) (*Override, error)
+}
+
+type compiled struct {
+	version int
+	entry   *entry
+}
+
+var (
+	lock      = &sync.RWMutex{}
+	bases     map[string]*template.Template
+	defaults  map[string]*entry
+	overrides = map[string]*compiled{}
+	store     Store
+)
+
+// Init parses every template for every language, embedded files can be overridden by files
+// with the same relative path inside MAIL_TEMPLATE_DIR. A missing or broken template fails here
+// instead of producing an empty mail later.
+func Init() error {
+	source := overlay{
+		override: config.Get().Mail.TemplateDir,
+		embedded: assets.Html,
+	}
+
+	layout, err := source.read("layout.html")
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	parsedBases := make(map[string]*template.Template)
+	parsedDefaults := make(map[string]*entry)
+	for _, lang := range Languages {
+		for _, name := range Templates {
+			file := path.Join(lang, name+".html")
+			content, err := source.read(file)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", file, err.Error()))
+				continue
+			}
+			base, err := template.New(file).Parse(string(layout))
+			if err == nil {
+				base, err = base.Parse(string(content))
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", file, err.Error()))
+				continue
+			}
+			e, err := compile(base, string(content), "")
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", file, err.Error()))
+				continue
+			}
+			parsedBases[key(lang, name)] = base
+			parsedDefaults[key(lang, name)] = e
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid mail templates: " + strings.Join(errs, "; "))
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	bases = parsedBases
+	defaults = parsedDefaults
+	overrides = map[string]*compiled{}
+	return nil
+}
+
+// SetStore registers where admin edited templates are read from.
+func SetStore(s Store) {
+	lock.Lock()
+	defer lock.Unlock()
+	store = s
+	overrides = map[string]*compiled{}
+}
+
+// compile builds an entry from base, when content is empty the blocks of base are used as they are.
+// subject is parsed as plain text, it must not be html escaped.
+func compile(base *template.Template, subject string, content string) (*entry, error) {
+	body, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		if body, err = body.Parse(`{{define "content"}}` + content + `{{end}}`); err != nil {
+			return nil, err
+		}
+	}
+	for _, block := range []string{"layout", "content"} {
+		if body.Lookup(block) == nil {
+			return nil, fmt.Errorf("block %q is not defined", block)
+		}
+	}
+
+	if content != "" {
+		subject = `{{define "subject"}}` + subject + `{{end}}`
+	}
+	subjectTemplate, err := textTemplate.New("subject").Parse(subject)
+	if err != nil {
+		return nil, err
+	}
+	if subjectTemplate.Lookup("subject") == nil {
+		return nil, fmt.Errorf("block %q is not defined", "subject")
+	}
+
+	return &entry{subject: subjectTemplate, body: body}, nil
+}
+
+func lookup(name, lang string) (*entry, error) {
+	lock.RLock()
+	e := defaults[key(lang, name)]
+	s := store
+	c := overrides[key(lang, name)]
+	base := bases[key(lang, name)]
+	lock.RUnlock()
+	if e == nil {
+		return nil, fmt.Errorf("mail template %s/%s is not registered", lang, name)
+	}
+	if s == nil {
+		return e, nil
+	}
+
+	override, err := s.Find(name, lang)
+	if err != nil {
+		logrus.Error("Error finding mail template override, using default: ", err.Error())
+		return e, nil
+	}
+	if override == nil {
+		return e, nil
+	}
+	if c != nil && c.version == override.Version {
+		return c.entry, nil
+	}
+
+	overrideEntry, err := compile(base, override.Subject, override.Body)
+	if err != nil {
+		logrus.Error("Error compiling mail template override, using default: ", err.Error())
+		return e, nil
+	}
+	lock.Lock()
+	overrides[key(lang, name)] = &compiled{version: override.Version, entry: overrideEntry}
+	lock.Unlock()
+	return overrideEntry, nil
+}
+
+// Render executes the template name in lang, falling back to the configured default language.
+// data is extended with LANG and BRAND so templates can use {{.BRAND.AppName}}.
+func Render(name, lang string, data map[string]interface{}) (subject string, body string, err error) {
+	lang = Language(lang)
+	e, err := lookup(name, lang)
+	if err != nil {
+		return "", "", err
+	}
+	return execute(e, lang, data)
+}
+
+func execute(e *entry, lang string, data map[string]interface{}) (subject string, body string, err error) {
+	values := map[string]interface{}{}
+	for k, v := range data {
+		values[k] = v
+	}
+	values["LANG"] = lang
+	values["BRAND"] = config.Get().Brand
+
+	buf := new(bytes.Buffer)
+	if err = e.subject.ExecuteTemplate(buf, "subject", values); err != nil {
+		return "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err = e.body.ExecuteTemplate(buf, "layout", values); err != nil {
+		return "", "", err
+	}
+	body = buf.String()
+	return
+}
+
+// Message renders name into a message ready for a Mailer or the email outbox.
+func Message(name, lang string, data map[string]interface{}, to ...string) (*gomail.Message, error) {
+	subject, body, err := Render(name, lang, data)
+	if err != nil {
+		return nil, err
+	}
+	return gomail.NewMessage(subject, body, to...), nil
+}
+
+// Language returns lang when it is supported, otherwise the configured default language.
+// lang may also be an Accept-Language header value.
+func Language(lang string) string {
+	for _, part := range strings.Split(lang, ",") {
+		part = strings.ToLower(strings.TrimSpace(strings.Split(part, ";")[0]))
+		if len(part) > 2 {
+			part = part[:2]
+		}
+		for _, v := range Languages {
+			if v == part {
+				return v
+			}
+		}
+	}
+	if defaultLang := config.Get().Mail.DefaultLanguage; defaultLang != "" && defaultLang != lang {
+		return Language(defaultLang)
+	}
+	return LANGUAGE_ID
+}
+
+func key(lang, name string) string {
+	return lang + "/" + name
+}
+
+type overlay struct {
+	override string
+	embedded fs.FS
+}
+
+func (o overlay) read(name string) ([]byte, error) {
+	if o.override != "" {
+		content, err := os.ReadFile(path.Join(o.override, name))
+		if err == nil {
+			return content, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return fs.ReadFile(o.embedded, path.Join("html", name))
+}
@@ -0,0 +1,163 @@
+package mailtemplate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Placeholders lists the data each template is rendered with, LANG and BRAND are always available.
+var Placeholders = map[string][]string{
+	TEMPLATE_CREATE_USER:            {"NAME", "EMAIL", "PASSWORD", "LINK"},
+	TEMPLATE_RESET_PASSWORD_ADMIN:   {"NAME", "RESETNAME", "EMAIL", "PASSWORD", "LINK"},
+	TEMPLATE_FORGOT_PASSWORD:        {"NAME", "EMAIL", "LINK"},
+	TEMPLATE_LOCKED_USER:            {"NAME", "EMAIL"},
+	TEMPLATE_RESET_PASSWORD_SUCCESS: {"EMAIL"},
+	TEMPLATE_RESET_PASSWORD_FAILED:  {"ERROR"},
+}
+
+// Required lists the placeholders a template is useless without, e.g. the new password.
+var Required = map[string][]string{
+	TEMPLATE_CREATE_USER:          {"EMAIL", "PASSWORD"},
+	TEMPLATE_RESET_PASSWORD_ADMIN: {"PASSWORD"},
+	TEMPLATE_FORGOT_PASSWORD:      {"LINK"},
+}
+
+var globalPlaceholders = []string{"LANG", "BRAND"}
+
+// SampleData returns placeholder values used to preview and validate a template.
+func SampleData(name string) map[string]interface{} {
+	sample := map[string]interface{}{
+		"NAME":      "Ahmad Fauzi",
+		"RESETNAME": "Administrator",
+		"EMAIL":     "ahmad.fauzi@example.com",
+		"PASSWORD":  "Xy7#pQ2a",
+		"LINK":      "https://example.com",
+		"ERROR":     "your token is invalid",
+	}
+	data := map[string]interface{}{}
+	for _, v := range Placeholders[name] {
+		data[v] = sample[v]
+	}
+	return data
+}
+
+// Default returns the source of the built-in subject and content block of a template.
+func Default(name, lang string) (subject string, body string, err error) {
+	lock.RLock()
+	e := defaults[key(lang, name)]
+	base := bases[key(lang, name)]
+	lock.RUnlock()
+	if e == nil || base == nil {
+		return "", "", fmt.Errorf("mail template %s/%s is not registered", lang, name)
+	}
+	// base is never executed, so its tree has not been rewritten by the html escaper
+	return strings.TrimSpace(e.subject.Lookup("subject").Tree.Root.String()), strings.TrimSpace(base.Lookup("content").Tree.Root.String()), nil
+}
+
+// Validate checks that subject and body parse, only use known placeholders, contain the required
+// ones and render with sample data.
+func Validate(name, lang, subject, body string) error {
+	_, _, err := Preview(name, lang, subject, body, nil)
+	return err
+}
+
+// Preview renders subject and body as the content of template name without saving them, data
+// overrides the sample values.
+func Preview(name, lang, subject, body string, data map[string]interface{}) (string, string, error) {
+	lang = Language(lang)
+	if _, ok := Placeholders[name]; !ok {
+		return "", "", fmt.Errorf("unknown mail template %s", name)
+	}
+
+	lock.RLock()
+	base := bases[key(lang, name)]
+	lock.RUnlock()
+	if base == nil {
+		return "", "", fmt.Errorf("mail template %s/%s is not registered", lang, name)
+	}
+
+	e, err := compile(base, subject, body)
+	if err != nil {
+		return "", "", err
+	}
+
+	used := map[string]bool{}
+	collectFields(e.subject.Lookup("subject").Tree.Root, used)
+	collectFields(e.body.Lookup("content").Tree.Root, used)
+
+	allowed := map[string]bool{}
+	for _, v := range append(Placeholders[name], globalPlaceholders...) {
+		allowed[v] = true
+	}
+	var unknown []string
+	for v := range used {
+		if !allowed[v] {
+			unknown = append(unknown, "{{."+v+"}}")
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", "", fmt.Errorf("unknown placeholder %s, allowed: %s", strings.Join(unknown, ", "), strings.Join(Placeholders[name], ", "))
+	}
+	var missing []string
+	for _, v := range Required[name] {
+		if !used[v] {
+			missing = append(missing, "{{."+v+"}}")
+		}
+	}
+	if len(missing) > 0 {
+		return "", "", errors.New("missing required placeholder " + strings.Join(missing, ", "))
+	}
+
+	values := SampleData(name)
+	for k, v := range data {
+		values[k] = v
+	}
+	return execute(e, lang, values)
+}
+
+func collectFields(node parse.Node, used map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, v := range n.Nodes {
+			collectFields(v, used)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, used)
+	case *parse.IfNode:
+		collectFields(n.Pipe, used)
+		collectFields(n.List, used)
+		collectFields(n.ElseList, used)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, used)
+		collectFields(n.List, used)
+		collectFields(n.ElseList, used)
+	case *parse.WithNode:
+		collectFields(n.Pipe, used)
+		collectFields(n.List, used)
+		collectFields(n.ElseList, used)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, v := range n.Cmds {
+			collectFields(v, used)
+		}
+	case *parse.CommandNode:
+		for _, v := range n.Args {
+			collectFields(v, used)
+		}
+	case *parse.ChainNode:
+		collectFields(n.Node, used)
+	case *parse.FieldNode:
+		used[n.Ident[0]] = true
+	}
+}
@@ -0,0 +1,357 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	TABLE        = "schema_migration"
+	LOCK_NAME    = "daarul_mukhtarin:migrate"
+	LOCK_TIMEOUT = 30
+)
+
+// fileName is how a migration file is named, e.g. 000001_init.up.sql and 000001_init.down.sql.
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema, Up applies it and Down reverts it.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Applied is a row of the migration table.
+type Applied struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	Checksum  string    `gorm:"size:64;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (Applied) TableName() string {
+	return TABLE
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	// Changed is set when the file no longer matches the checksum it was applied with
+	Changed bool
+}
+
+// Load reads the migrations of fsys ordered by version. Every version needs an up file, one without
+// a down file cannot be reverted.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.(up|down).sql", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies migrations to db, keeping track of them in the migration table.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+func NewMigrator(db *gorm.DB, migrations []*Migration) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}
+}
+
+// Up applies the pending migrations in order, at most n of them when n is positive, and returns
+// the ones applied.
+func (m *Migrator) Up(n int) (applied []*Migration, err error) {
+	err = m.locked(func(db *gorm.DB) error {
+		done, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(done); err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if n > 0 && len(applied) == n {
+				break
+			}
+			if err := m.run(db, migration, migration.Up, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// Down reverts the last n applied migrations, the last one when n is not positive, and returns
+// the ones reverted.
+func (m *Migrator) Down(n int) (reverted []*Migration, err error) {
+	if n < 1 {
+		n = 1
+	}
+	err = m.locked(func(db *gorm.DB) error {
+		done, err := m.applied(db)
+		if err != nil {
+			return err
+		}
+		if err = m.verify(done); err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s has no down file, it cannot be reverted", migration.Version, migration.Name)
+			}
+			if err := m.run(db, migration, migration.Down, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// Status lists every known migration with when it was applied.
+func (m *Migrator) Status() ([]*Status, error) {
+	if err := m.db.AutoMigrate(&Applied{}); err != nil {
+		return nil, err
+	}
+	done, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &Status{Migration: *migration}
+		if row, ok := done[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			status.Changed = row.Checksum != migration.Checksum
+		}
+		res = append(res, status)
+	}
+	return res, nil
+}
+
+// run executes the statements of one direction of migration and records it. MySQL commits DDL
+// on its own, so there a failing statement can leave the earlier ones of the same file applied,
+// postgres and SQLite roll the whole file back.
+func (m *Migrator) run(db *gorm.DB, migration *Migration, script string, up bool) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range Statements(script) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		if !up {
+			return tx.Delete(&Applied{}, migration.Version).Error
+		}
+		return tx.Create(&Applied{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Checksum:  migration.Checksum,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int64]*Applied, error) {
+	var rows []*Applied
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int64]*Applied, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
+
+// verify refuses to go on when an applied migration was edited afterwards or is missing, the
+// schema would no longer be what the files describe.
+func (m *Migrator) verify(done map[int64]*Applied) error {
+	known := make(map[int64]*Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	for version, row := range done {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("migration %d_%s is applied but its files are missing", version, row.Name)
+		}
+		if migration.Checksum != row.Checksum {
+			return fmt.Errorf("migration %d_%s was changed after it was applied, add a new migration instead", version, row.Name)
+		}
+	}
+	return nil
+}
+
+// locked runs fn holding a database lock on a single connection, so two instances starting at
+// the same time do not apply the same migration twice. SQLite has no such lock, its file only
+// takes one writer at a time.
+func (m *Migrator) locked(fn func(db *gorm.DB) error) error {
+	return m.db.Connection(func(db *gorm.DB) error {
+		switch db.Dialector.Name() {
+		case "mysql":
+			var got *int
+			if err := db.Raw("SELECT GET_LOCK(?, ?)", LOCK_NAME, LOCK_TIMEOUT).Scan(&got).Error; err != nil {
+				return err
+			}
+			if got == nil || *got != 1 {
+				return errLocked
+			}
+			defer db.Exec("SELECT RELEASE_LOCK(?)", LOCK_NAME)
+		case "postgres":
+			key := lockKey()
+			deadline := time.Now().Add(LOCK_TIMEOUT * time.Second)
+			for {
+				var got bool
+				if err := db.Raw("SELECT pg_try_advisory_lock(?)", key).Scan(&got).Error; err != nil {
+					return err
+				}
+				if got {
+					break
+				}
+				if time.Now().After(deadline) {
+					return errLocked
+				}
+				time.Sleep(time.Second)
+			}
+			defer db.Exec("SELECT pg_advisory_unlock(?)", key)
+		}
+
+		if err := db.AutoMigrate(&Applied{}); err != nil {
+			return err
+		}
+		return fn(db)
+	})
+}
+
+var errLocked = errors.New("another migration is running, could not get the migration lock")
+
+// lockKey is LOCK_NAME as the number postgres advisory locks are keyed by.
+func lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(LOCK_NAME))
+	return int64(h.Sum64())
+}
+
+// Statements splits a script into its statements, each ending with a semicolon at the end of a
+// line. Lines starting with -- are comments.
+func Statements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// Create writes an empty up and down file for a new migration in dir, numbered after the last one.
+func Create(dir string, name string) ([]string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return nil, errors.New("migration name is empty")
+	}
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %06d_%s %s\n", version, name, direction)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
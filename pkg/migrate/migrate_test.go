@@ -0,0 +1,168 @@
+package migrate
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{name: "empty", script: "", want: nil},
+		{name: "only comments", script: "-- nothing\n  -- here\n\n", want: nil},
+		{
+			name:   "one per line",
+			script: "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT);\n",
+			want:   []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (id INT);"},
+		},
+		{
+			name:   "spanning lines",
+			script: "CREATE TABLE a (\n  id INT,\n  name TEXT\n);\n",
+			want:   []string{"CREATE TABLE a (\n  id INT,\n  name TEXT\n);"},
+		},
+		{
+			name:   "comments between lines are dropped",
+			script: "-- the table\nCREATE TABLE a (\n  -- its id\n  id INT\n);",
+			want:   []string{"CREATE TABLE a (\n  id INT\n);"},
+		},
+		{
+			name:   "semicolon inside a line does not split",
+			script: "INSERT INTO a (name) VALUES ('x;y');\n",
+			want:   []string{"INSERT INTO a (name) VALUES ('x;y');"},
+		},
+		{
+			name:   "trailing spaces after the semicolon",
+			script: "DROP TABLE a;   \r\nDROP TABLE b;",
+			want:   []string{"DROP TABLE a;", "DROP TABLE b;"},
+		},
+		{
+			name:   "last statement without semicolon",
+			script: "DROP TABLE a;\nDROP TABLE b",
+			want:   []string{"DROP TABLE a;", "DROP TABLE b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Statements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		hasDown  []bool
+		wantErr  bool
+	}{
+		{
+			name: "ordered by version",
+			files: fstest.MapFS{
+				"000010_late.up.sql":   {Data: []byte("SELECT 10;")},
+				"000002_next.up.sql":   {Data: []byte("SELECT 2;")},
+				"000002_next.down.sql": {Data: []byte("SELECT 2;")},
+				"000001_init.up.sql":   {Data: []byte("SELECT 1;")},
+				"README.md":            {Data: []byte("not a migration")},
+			},
+			versions: []int64{1, 2, 10},
+			hasDown:  []bool{false, true, false},
+		},
+		{
+			name:    "badly named",
+			files:   fstest.MapFS{"1-init.up.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: true,
+		},
+		{
+			name: "two names for a version",
+			files: fstest.MapFS{
+				"000001_init.up.sql":    {Data: []byte("SELECT 1;")},
+				"000001_other.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "down without up",
+			files:   fstest.MapFS{"000001_init.down.sql": {Data: []byte("SELECT 1;")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, migration := range migrations {
+				if migration.Version != tt.versions[i] {
+					t.Errorf("migration %d has version %d, want %d", i, migration.Version, tt.versions[i])
+				}
+				if (migration.Down != "") != tt.hasDown[i] {
+					t.Errorf("migration %d has a down file %v, want %v", migration.Version, migration.Down != "", tt.hasDown[i])
+				}
+			}
+			if !tt.wantErr && len(migrations) != len(tt.versions) {
+				t.Errorf("Load() gives %d migrations, want %d", len(migrations), len(tt.versions))
+			}
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := Load(fstest.MapFS{
+		"000001_init.up.sql":    {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"000002_b.up.sql":       {Data: []byte("CREATE TABLE b (id INTEGER);")},
+		"000002_b.down.sql":     {Data: []byte("DROP TABLE b;")},
+		"000003_fails.up.sql":   {Data: []byte("CREATE TABLE c (id INTEGER);\nINSERT INTO missing VALUES (1);")},
+		"000003_fails.down.sql": {Data: []byte("DROP TABLE c;")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewMigrator(db, migrations)
+
+	applied, err := m.Up(2)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("Up(2) = %d, %v, want 2 applied", len(applied), err)
+	}
+	if applied, err = m.Up(0); err == nil || len(applied) != 0 {
+		t.Fatalf("Up() = %d, %v, want the failing migration to stop it", len(applied), err)
+	}
+	if db.Migrator().HasTable("c") {
+		t.Error("a failed migration left table c behind")
+	}
+
+	reverted, err := m.Down(1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("Down(1) = %v, %v, want 2 reverted", reverted, err)
+	}
+	if db.Migrator().HasTable("b") {
+		t.Error("Down(1) left table b behind")
+	}
+	if _, err = m.Down(1); err == nil {
+		t.Error("Down(1) reverted a migration without a down file")
+	}
+	if !db.Migrator().HasTable("a") {
+		t.Error("table a of the irreversible migration was dropped")
+	}
+
+	migrations[0].Checksum = "changed"
+	if _, err = m.Up(0); err == nil {
+		t.Error("Up() went on with an applied migration that was changed")
+	}
+}
@@ -0,0 +1,131 @@
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoKey      = errors.New("no signing key configured")
+	ErrInvalid    = errors.New("invalid signed link")
+	ErrExpired    = errors.New("signed link has expired")
+	ErrUnknownKey = errors.New("signed link key is no longer valid")
+)
+
+// Claims is what a signed link grants: access to one resource until ExpiredAt.
+type Claims struct {
+	Jti        string `json:"jti"`
+	Kid        string `json:"kid"`
+	Resource   string `json:"res"`
+	ResourceId int    `json:"rid"`
+	SingleUse  bool   `json:"once,omitempty"`
+	ExpiredAt  int64  `json:"exp"`
+}
+
+func (c *Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiredAt, 0)
+}
+
+type Key struct {
+	Id     string
+	Secret []byte
+}
+
+// Signer signs with the first key and verifies with any of them. Rotating means putting a new key
+// first, links signed with a key that is removed stop working.
+type Signer struct {
+	keys []Key
+}
+
+// ParseKeys reads "kid:secret,kid:secret" as used by SIGNING_KEYS.
+func ParseKeys(raw string) []Key {
+	var keys []Key
+	for _, item := range strings.Split(raw, ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || kid == "" || secret == "" {
+			continue
+		}
+		keys = append(keys, Key{Id: kid, Secret: []byte(secret)})
+	}
+	return keys
+}
+
+func NewSigner(keys []Key) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	return &Signer{keys: keys}, nil
+}
+
+// Sign returns a url-safe token for a link to resource/id valid for expiry.
+func (s *Signer) Sign(resource string, id int, expiry time.Duration, singleUse bool) (string, *Claims, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	claims := &Claims{
+		Jti:        hex.EncodeToString(b),
+		Kid:        s.keys[0].Id,
+		Resource:   resource,
+		ResourceId: id,
+		SingleUse:  singleUse,
+		ExpiredAt:  time.Now().Add(expiry).Unix(),
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(s.keys[0].Secret, encoded)), claims, nil
+}
+
+// Verify checks the signature and expiry of token.
+func (s *Signer) Verify(token string) (*Claims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var claims Claims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalid
+	}
+
+	var key *Key
+	for i := range s.keys {
+		if s.keys[i].Id == claims.Kid {
+			key = &s.keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return nil, ErrUnknownKey
+	}
+	if !hmac.Equal(mac, sign(key.Secret, encoded)) {
+		return nil, ErrInvalid
+	}
+	if time.Now().After(claims.Expiry()) {
+		return nil, ErrExpired
+	}
+	return &claims, nil
+}
+
+func sign(secret []byte, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
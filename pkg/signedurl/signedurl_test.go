@@ -0,0 +1,101 @@
+package signedurl
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []Key
+	}{
+		{name: "empty", raw: "", want: nil},
+		{name: "one", raw: "k1:secret", want: []Key{{Id: "k1", Secret: []byte("secret")}}},
+		{
+			name: "several with spaces",
+			raw:  " k2:new , k1:old ",
+			want: []Key{{Id: "k2", Secret: []byte("new")}, {Id: "k1", Secret: []byte("old")}},
+		},
+		{name: "secret with a colon", raw: "k1:a:b", want: []Key{{Id: "k1", Secret: []byte("a:b")}}},
+		{name: "incomplete are skipped", raw: "k1,:secret,k2:,k3:ok", want: []Key{{Id: "k3", Secret: []byte("ok")}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKeys(tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeys(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSignerWithoutKeys(t *testing.T) {
+	if _, err := NewSigner(nil); !errors.Is(err, ErrNoKey) {
+		t.Errorf("NewSigner(nil) error = %v, want %v", err, ErrNoKey)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	current, _ := NewSigner(ParseKeys("k1:old"))
+	token, claims, err := current.Sign("dokumen", 7, time.Hour, true)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if claims.Kid != "k1" || claims.Resource != "dokumen" || claims.ResourceId != 7 || !claims.SingleUse {
+		t.Errorf("Sign() claims = %+v", claims)
+	}
+	expired, _, err := current.Sign("dokumen", 7, -time.Minute, false)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	otherPayload, _, _ := strings.Cut(expired, ".")
+
+	rotated, _ := NewSigner(ParseKeys("k2:new,k1:old"))
+	dropped, _ := NewSigner(ParseKeys("k2:new"))
+	forged, _ := NewSigner(ParseKeys("k1:guess"))
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		token   string
+		wantErr error
+	}{
+		{name: "valid", signer: current, token: token},
+		{name: "after rotating the old key still verifies", signer: rotated, token: token},
+		{name: "removed key", signer: dropped, token: token, wantErr: ErrUnknownKey},
+		{name: "other secret", signer: forged, token: token, wantErr: ErrInvalid},
+		{name: "expired", signer: current, token: expired, wantErr: ErrExpired},
+		{name: "payload of another token", signer: current, token: otherPayload + "." + signature, wantErr: ErrInvalid},
+		{name: "no signature", signer: current, token: payload, wantErr: ErrInvalid},
+		{name: "empty", signer: current, token: "", wantErr: ErrInvalid},
+		{name: "payload not base64", signer: current, token: "***." + signature, wantErr: ErrInvalid},
+		{name: "signature not base64", signer: current, token: payload + ".***", wantErr: ErrInvalid},
+		{name: "payload not json", signer: current, token: base64.RawURLEncoding.EncodeToString([]byte("x")) + "." + signature, wantErr: ErrInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.signer.Verify(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, claims) {
+				t.Errorf("Verify() = %+v, want %+v", got, claims)
+			}
+		})
+	}
+}
+
+func TestSignUniqueJti(t *testing.T) {
+	signer, _ := NewSigner(ParseKeys("k1:secret"))
+	_, a, _ := signer.Sign("dokumen", 1, time.Hour, false)
+	_, b, _ := signer.Sign("dokumen", 1, time.Hour, false)
+	if a.Jti == b.Jti {
+		t.Errorf("two links share jti %s", a.Jti)
+	}
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"context"
+	"daarul_mukhtarin/pkg/gdrive"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+const driveFileFields = "id, name, mimeType, size, modifiedTime"
+
+type driveStorage struct {
+	folderId string
+}
+
+// NewDriveStorage stores objects in Google Drive below folderId ("root" when empty).
+//
+// Drive addresses files by id rather than by path, so Put returns the Drive file id as Object.Key
+// and every other method expects that id.
+func NewDriveStorage(folderId string) *driveStorage {
+	if folderId == "" {
+		folderId = "root"
+	}
+	return &driveStorage{folderId: folderId}
+}
+
+// drive returns the shared Drive service, connecting on first use so the application can start
+// while Google is unreachable.
+func (s *driveStorage) drive() (*drive.Service, error) {
+	return gdrive.InitGoogleDrive()
+}
+
+func (s *driveStorage) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) (*Object, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	service, err := s.drive()
+	if err != nil {
+		return nil, err
+	}
+
+	parentId := s.folderId
+	if dir := path.Dir(key); dir != "." {
+		if parentId, err = s.ensureFolder(ctx, service, dir); err != nil {
+			return nil, err
+		}
+	}
+
+	var file *drive.File
+	if size < 0 || size > gdrive.DRIVE_CHUNK_SIZE {
+		file, err = gdrive.FileToDriveResumable(ctx, service, path.Base(key), contentType, content, parentId, driveFileFields)
+	} else {
+		file, err = service.Files.Create(&drive.File{
+			Name:     path.Base(key),
+			MimeType: contentType,
+			Parents:  []string{parentId},
+		}).Media(content).Fields(driveFileFields).Context(ctx).Do()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return driveObject(file), nil
+}
+
+func (s *driveStorage) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	object, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	service, err := s.drive()
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := service.Files.Get(object.Key).Context(ctx).Download()
+	if err != nil {
+		return nil, nil, convertDriveError(err)
+	}
+	return res.Body, object, nil
+}
+
+func (s *driveStorage) Delete(ctx context.Context, key string) error {
+	service, err := s.drive()
+	if err != nil {
+		return err
+	}
+	return convertDriveError(service.Files.Delete(key).Context(ctx).Do())
+}
+
+func (s *driveStorage) Stat(ctx context.Context, key string) (*Object, error) {
+	service, err := s.drive()
+	if err != nil {
+		return nil, err
+	}
+	file, err := service.Files.Get(key).Fields(driveFileFields).Context(ctx).Do()
+	if err != nil {
+		return nil, convertDriveError(err)
+	}
+	return driveObject(file), nil
+}
+
+// List returns the files directly inside the storage folder whose name starts with prefix.
+func (s *driveStorage) List(ctx context.Context, prefix string) ([]*Object, error) {
+	service, err := s.drive()
+	if err != nil {
+		return nil, err
+	}
+	var objects []*Object
+	q := fmt.Sprintf("'%s' in parents and trashed = false and mimeType != '%s'", s.folderId, gdrive.MIME_TYPE_FOLDER)
+	err = service.Files.List().Q(q).Fields(googleapi.Field("nextPageToken, files("+driveFileFields+")")).Pages(ctx, func(list *drive.FileList) error {
+		for _, file := range list.Files {
+			if strings.HasPrefix(file.Name, prefix) {
+				objects = append(objects, driveObject(file))
+			}
+		}
+		return nil
+	})
+	return objects, err
+}
+
+func (s *driveStorage) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return "", ErrNotSupported
+}
+
+// ensureFolder walks dir below the storage folder and creates the folders that are missing.
+func (s *driveStorage) ensureFolder(ctx context.Context, service *drive.Service, dir string) (string, error) {
+	parentId := s.folderId
+	for _, name := range strings.Split(dir, "/") {
+		q := fmt.Sprintf("'%s' in parents and name = '%s' and mimeType = '%s' and trashed = false",
+			parentId, strings.ReplaceAll(name, "'", "\\'"), gdrive.MIME_TYPE_FOLDER)
+		list, err := service.Files.List().Q(q).Fields("files(id)").PageSize(1).Context(ctx).Do()
+		if err != nil {
+			return "", err
+		}
+		if len(list.Files) > 0 {
+			parentId = list.Files[0].Id
+			continue
+		}
+		folder, err := gdrive.FolderToDrive(service, name, parentId)
+		if err != nil {
+			return "", err
+		}
+		parentId = folder.Id
+	}
+	return parentId, nil
+}
+
+func driveObject(file *drive.File) *Object {
+	modifiedAt, _ := time.Parse(time.RFC3339, file.ModifiedTime)
+	return &Object{
+		Key:         file.Id,
+		Name:        file.Name,
+		ContentType: file.MimeType,
+		Size:        file.Size,
+		ModifiedAt:  modifiedAt,
+	}
+}
+
+func convertDriveError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"daarul_mukhtarin/internal/config"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	DRIVER_LOCAL = "local"
+	DRIVER_DRIVE = "drive"
+	DRIVER_S3    = "s3"
+)
+
+var (
+	ErrNotFound     = errors.New("object not found")
+	ErrNotSupported = errors.New("operation not supported by this storage")
+	ErrInvalidKey   = errors.New("invalid object key")
+)
+
+type Object struct {
+	Key         string    `json:"key"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	ModifiedAt  time.Time `json:"modified_at"`
+}
+
+// Storage is implemented by every file backend the application can be configured with.
+//
+// Keys are slash separated paths such as "dokumen/2024/report.pdf". Backends that assign their own
+// identifiers (Google Drive) return them in Object.Key, callers must keep the returned key.
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) (*Object, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Delete(ctx context.Context, key string) error
+	Stat(ctx context.Context, key string) (*Object, error)
+	List(ctx context.Context, prefix string) ([]*Object, error)
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// NewStorage builds the backend selected by STORAGE_DRIVER, defaulting to local.
+func NewStorage() (Storage, error) {
+	switch strings.ToLower(config.Get().Storage.Driver) {
+	case "", DRIVER_LOCAL:
+		return NewLocalStorage(config.Get().Storage.LocalDir)
+	case DRIVER_DRIVE:
+		return NewDriveStorage(config.Get().Storage.DriveFolderId), nil
+	case DRIVER_S3:
+		return NewS3Storage(S3Config{
+			Endpoint:  config.Get().Storage.S3Endpoint,
+			AccessKey: config.Get().Storage.S3AccessKey,
+			SecretKey: config.Get().Storage.S3SecretKey,
+			Bucket:    config.Get().Storage.S3Bucket,
+			Region:    config.Get().Storage.S3Region,
+			UseSSL:    config.Get().Storage.S3UseSSL == "true",
+		})
+	}
+	return nil, fmt.Errorf("unknown storage driver %s", config.Get().Storage.Driver)
+}
+
+// CleanKey normalizes key and rejects keys escaping the storage root.
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(key, "\\", "/")), "/")
+	if key == "" || key == "." || strings.HasPrefix(key, "..") {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
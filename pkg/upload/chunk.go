@@ -0,0 +1,221 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const (
+	// PART_SIZE is the part size suggested to clients, parts may be smaller but not larger than MAX_PART_SIZE.
+	PART_SIZE     = 8 << 20
+	MAX_PART_SIZE = 64 << 20
+	MAX_PARTS     = 10000
+)
+
+var (
+	ErrPartTooLarge  = errors.New("part is too large")
+	ErrPartsMissing  = errors.New("upload has missing parts")
+	ErrSizeMismatch  = errors.New("uploaded size does not match the declared size")
+	ErrSizeExceeded  = errors.New("parts are larger than the declared size")
+	ErrInvalidUpload = errors.New("invalid upload id")
+)
+
+var uploadIdPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+type Part struct {
+	Number int   `json:"number"`
+	Size   int64 `json:"size"`
+}
+
+// Chunks keeps the parts of resumable uploads on local disk until they are assembled, so every
+// part of one upload has to reach the same instance.
+type Chunks struct {
+	dir string
+}
+
+func NewChunks(dir string) (*Chunks, error) {
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "daarul_mukhtarin-upload")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Chunks{dir: dir}, nil
+}
+
+func (c *Chunks) path(id string) (string, error) {
+	if !uploadIdPattern.MatchString(id) {
+		return "", ErrInvalidUpload
+	}
+	return filepath.Join(c.dir, id), nil
+}
+
+// PutPart stores part number of upload id, uploading the same part again replaces it. The parts
+// together may not grow past size, the size declared for the upload.
+func (c *Chunks) PutPart(id string, number int, size int64, content io.Reader) (int64, error) {
+	dir, err := c.path(id)
+	if err != nil {
+		return 0, err
+	}
+	if number < 1 || number > MAX_PARTS {
+		return 0, fmt.Errorf("part number must be between 1 and %d", MAX_PARTS)
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return 0, err
+	}
+	others, err := c.othersSize(id, number)
+	if err != nil {
+		return 0, err
+	}
+	limit := size - others
+	if limit > MAX_PART_SIZE {
+		limit = MAX_PART_SIZE
+	}
+	if limit < 0 {
+		limit = 0
+	}
+
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, io.LimitReader(content, limit+1))
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return 0, err
+	}
+	if n > MAX_PART_SIZE {
+		return 0, ErrPartTooLarge
+	}
+	if n > limit {
+		return 0, ErrSizeExceeded
+	}
+	name := filepath.Join(dir, strconv.Itoa(number))
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return 0, err
+	}
+
+	// parts sent at the same time only see each other once stored
+	if others, err = c.othersSize(id, number); err != nil {
+		return 0, err
+	}
+	if others+n > size {
+		os.Remove(name)
+		return 0, ErrSizeExceeded
+	}
+	return n, nil
+}
+
+// othersSize is the size of every stored part of upload id but number.
+func (c *Chunks) othersSize(id string, number int) (int64, error) {
+	parts, err := c.Parts(id)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, part := range parts {
+		if part.Number != number {
+			total += part.Size
+		}
+	}
+	return total, nil
+}
+
+// Parts lists the stored parts of upload id ordered by number.
+func (c *Chunks) Parts(id string) ([]Part, error) {
+	dir, err := c.path(id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Part{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parts := []Part{}
+	for _, entry := range entries {
+		number, err := strconv.Atoi(entry.Name())
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, Part{Number: number, Size: info.Size()})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+// Assemble joins the parts of upload id into one file and checks it against the declared size.
+// The returned file is removed together with the upload by Remove.
+func (c *Chunks) Assemble(id string, size int64) (*os.File, error) {
+	dir, err := c.path(id)
+	if err != nil {
+		return nil, err
+	}
+	parts, err := c.Parts(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for i, part := range parts {
+		if part.Number != i+1 {
+			return nil, ErrPartsMissing
+		}
+		total += part.Size
+	}
+	if len(parts) == 0 || total != size {
+		return nil, ErrSizeMismatch
+	}
+
+	file, err := os.Create(filepath.Join(dir, "assembled"))
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		if err = appendPart(file, filepath.Join(dir, strconv.Itoa(part.Number))); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func appendPart(dst *os.File, name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// Remove deletes everything stored for upload id.
+func (c *Chunks) Remove(id string) error {
+	dir, err := c.path(id)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,140 @@
+package upload
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type put struct {
+	number  int
+	content string
+	wantErr error
+}
+
+func TestPutPart(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int64
+		puts  []put
+		parts []Part
+	}{
+		{
+			name:  "parts up to the declared size",
+			size:  10,
+			puts:  []put{{number: 1, content: "hello"}, {number: 2, content: "world"}},
+			parts: []Part{{Number: 1, Size: 5}, {Number: 2, Size: 5}},
+		},
+		{
+			name:  "a part past the declared size",
+			size:  8,
+			puts:  []put{{number: 1, content: "hello"}, {number: 2, content: "world", wantErr: ErrSizeExceeded}},
+			parts: []Part{{Number: 1, Size: 5}},
+		},
+		{
+			name:  "a single part larger than the upload",
+			size:  4,
+			puts:  []put{{number: 1, content: "hello", wantErr: ErrSizeExceeded}},
+			parts: []Part{},
+		},
+		{
+			name:  "sending a part again replaces it",
+			size:  10,
+			puts:  []put{{number: 1, content: "hello"}, {number: 2, content: "world"}, {number: 2, content: "there"}},
+			parts: []Part{{Number: 1, Size: 5}, {Number: 2, Size: 5}},
+		},
+		{
+			name:  "a replaced part is not counted twice",
+			size:  6,
+			puts:  []put{{number: 1, content: "hello"}, {number: 1, content: "hello!"}},
+			parts: []Part{{Number: 1, Size: 6}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := NewChunks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range tt.puts {
+				n, err := chunks.PutPart("upload-1", p.number, tt.size, strings.NewReader(p.content))
+				if !errors.Is(err, p.wantErr) {
+					t.Fatalf("PutPart(%d) error = %v, want %v", p.number, err, p.wantErr)
+				}
+				if err == nil && n != int64(len(p.content)) {
+					t.Errorf("PutPart(%d) = %d, want %d", p.number, n, len(p.content))
+				}
+			}
+			parts, err := chunks.Parts("upload-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(parts, tt.parts) {
+				t.Errorf("Parts() = %v, want %v", parts, tt.parts)
+			}
+		})
+	}
+}
+
+func TestPutPartInvalid(t *testing.T) {
+	chunks, err := NewChunks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = chunks.PutPart("../escape", 1, 10, strings.NewReader("x")); !errors.Is(err, ErrInvalidUpload) {
+		t.Errorf("PutPart() with a path as id error = %v, want %v", err, ErrInvalidUpload)
+	}
+	for _, number := range []int{0, MAX_PARTS + 1} {
+		if _, err = chunks.PutPart("upload-1", number, 10, strings.NewReader("x")); err == nil {
+			t.Errorf("PutPart() accepted part number %d", number)
+		}
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int64
+		parts   map[int]string
+		want    string
+		wantErr error
+	}{
+		{name: "in order", size: 10, parts: map[int]string{2: "world", 1: "hello"}, want: "helloworld"},
+		{name: "missing part", size: 10, parts: map[int]string{1: "hello", 3: "world"}, wantErr: ErrPartsMissing},
+		{name: "short", size: 11, parts: map[int]string{1: "hello", 2: "world"}, wantErr: ErrSizeMismatch},
+		{name: "no parts", size: 0, parts: map[int]string{}, wantErr: ErrSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := NewChunks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for number, content := range tt.parts {
+				if _, err = chunks.PutPart("upload-1", number, 100, strings.NewReader(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			file, err := chunks.Assemble("upload-1", tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Assemble() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer file.Close()
+			got, _ := io.ReadAll(file)
+			if string(got) != tt.want {
+				t.Errorf("Assemble() = %q, want %q", got, tt.want)
+			}
+			if err = chunks.Remove("upload-1"); err != nil {
+				t.Fatal(err)
+			}
+			if parts, _ := chunks.Parts("upload-1"); len(parts) != 0 {
+				t.Errorf("Parts() after Remove() = %v", parts)
+			}
+		})
+	}
+}
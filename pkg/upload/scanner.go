@@ -0,0 +1,110 @@
+package upload
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+var ErrInfected = errors.New("file is infected")
+
+// Scanner inspects uploaded content for malware and returns ErrInfected when it finds some.
+type Scanner interface {
+	Scan(ctx context.Context, content io.Reader) error
+}
+
+// NopScanner accepts everything, it is used when no scanner is configured.
+type NopScanner struct{}
+
+func (NopScanner) Scan(ctx context.Context, content io.Reader) error {
+	return nil
+}
+
+const clamdChunkSize = 64 << 10
+
+type clamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// NewClamdScanner talks to clamd over address, written as "unix:/run/clamav/clamd.ctl" or
+// "tcp:127.0.0.1:3310" (tcp is assumed without a prefix).
+func NewClamdScanner(address string) Scanner {
+	network := "tcp"
+	if i := strings.Index(address, ":"); i > 0 && (address[:i] == "unix" || address[:i] == "tcp") {
+		network, address = address[:i], address[i+1:]
+	}
+	return &clamdScanner{network: network, address: address, timeout: 2 * time.Minute}
+}
+
+// NewScanner returns a clamd scanner for address, or a NopScanner when address is empty.
+func NewScanner(address string) Scanner {
+	if address == "" {
+		return NopScanner{}
+	}
+	return NewClamdScanner(address)
+}
+
+// Scan streams content using the clamd INSTREAM command.
+func (s *clamdScanner) Scan(ctx context.Context, content io.Reader) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err = conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	buf := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, errWrite := conn.Write(size); errWrite != nil {
+				return errWrite
+			}
+			if _, errWrite := conn.Write(buf[:n]); errWrite != nil {
+				return errWrite
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if _, err = conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return ErrInfected
+	}
+	return fmt.Errorf("clamd: %s", reply)
+}
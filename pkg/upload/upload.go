@@ -0,0 +1,270 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Policy limits what a single endpoint accepts.
+type Policy struct {
+	MaxSize  int64
+	MaxCount int
+	// Allowed lists accepted content types, a type also accepts its aliases but not its children
+	// (text/plain does not accept html, application/zip does not accept jar), so docx and csv are
+	// listed on their own. Empty allows everything.
+	Allowed []string
+}
+
+var (
+	// DokumenPolicy covers office documents, pdf, images, archives and media.
+	DokumenPolicy = Policy{
+		MaxSize:  50 << 20,
+		MaxCount: 10,
+		Allowed: []string{
+			"application/pdf",
+			"application/msword",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			"application/vnd.ms-excel",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			"application/vnd.ms-powerpoint",
+			"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+			"text/plain",
+			"text/csv",
+			"image/jpeg",
+			"image/png",
+			"image/webp",
+			"application/zip",
+			"audio/mpeg",
+			"video/mp4",
+		},
+	}
+
+	// LargePolicy covers chunked uploads such as scanned archives and recorded kajian.
+	LargePolicy = Policy{
+		MaxSize: 4 << 30,
+		Allowed: append([]string{
+			"video/webm",
+			"video/quicktime",
+			"video/x-matroska",
+			"audio/mp4",
+			"audio/ogg",
+			"audio/wav",
+			"application/x-7z-compressed",
+			"application/x-rar-compressed",
+			"application/gzip",
+			"application/x-tar",
+		}, DokumenPolicy.Allowed...),
+	}
+
+	// ImagePolicy covers pictures such as avatars.
+	ImagePolicy = Policy{
+		MaxSize:  5 << 20,
+		MaxCount: 1,
+		Allowed:  []string{"image/jpeg", "image/png", "image/webp", "image/gif"},
+	}
+
+	// SpreadsheetPolicy covers csv and xlsx files such as bulk imports.
+	SpreadsheetPolicy = Policy{
+		MaxSize:  20 << 20,
+		MaxCount: 1,
+		Allowed: []string{
+			"text/csv",
+			// a csv of a single column is sniffed as plain text, html and scripts are not
+			"text/plain",
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		},
+	}
+)
+
+const (
+	CODE_TOO_MANY     = "too_many_files"
+	CODE_TOO_LARGE    = "file_too_large"
+	CODE_NOT_ALLOWED  = "file_type_not_allowed"
+	CODE_INFECTED     = "file_infected"
+	CODE_EMPTY        = "file_empty"
+	CODE_SCAN_FAILED  = "file_scan_failed"
+	CODE_READ_FAILED  = "file_read_failed"
+	CODE_NO_FILE      = "file_required"
+	defaultSniffBytes = 3072
+)
+
+// Error describes why a file was rejected, Status is the http status to answer with.
+type Error struct {
+	Status  int
+	Code    string
+	File    string
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.File == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// File is an accepted upload, Content is rewound and must be closed by the caller.
+type File struct {
+	Name        string
+	Size        int64
+	ContentType string
+	Checksum    string
+	Content     multipart.File
+}
+
+func (f *File) Close() error {
+	return f.Content.Close()
+}
+
+// CheckCount rejects requests carrying no files or more files than the policy allows.
+func (p Policy) CheckCount(files []*multipart.FileHeader) error {
+	if len(files) == 0 {
+		return &Error{Status: http.StatusBadRequest, Code: CODE_NO_FILE, Message: "files is required"}
+	}
+	if p.MaxCount > 0 && len(files) > p.MaxCount {
+		return &Error{Status: http.StatusBadRequest, Code: CODE_TOO_MANY, Message: fmt.Sprintf("at most %d files are allowed", p.MaxCount)}
+	}
+	return nil
+}
+
+// Open validates header against the policy: size, sniffed content type, malware scan, and
+// computes the sha256 checksum on the way.
+func (p Policy) Open(ctx context.Context, scanner Scanner, header *multipart.FileHeader) (*File, error) {
+	name := SanitizeFilename(header.Filename)
+	if header.Size == 0 {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_EMPTY, File: name, Message: "file is empty"}
+	}
+	if p.MaxSize > 0 && header.Size > p.MaxSize {
+		return nil, &Error{Status: http.StatusRequestEntityTooLarge, Code: CODE_TOO_LARGE, File: name, Message: fmt.Sprintf("file is larger than %d bytes", p.MaxSize)}
+	}
+
+	content, err := header.Open()
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_READ_FAILED, File: name, Message: "file cannot be read", Err: err}
+	}
+
+	file, err := p.inspect(ctx, scanner, name, header.Size, content)
+	if err != nil {
+		content.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// OpenFile is Open for content that did not come from a multipart form, such as an assembled
+// chunked upload. content is closed when it is rejected.
+func (p Policy) OpenFile(ctx context.Context, scanner Scanner, name string, size int64, content multipart.File) (*File, error) {
+	name = SanitizeFilename(name)
+	if size == 0 {
+		content.Close()
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_EMPTY, File: name, Message: "file is empty"}
+	}
+	if p.MaxSize > 0 && size > p.MaxSize {
+		content.Close()
+		return nil, &Error{Status: http.StatusRequestEntityTooLarge, Code: CODE_TOO_LARGE, File: name, Message: fmt.Sprintf("file is larger than %d bytes", p.MaxSize)}
+	}
+
+	file, err := p.inspect(ctx, scanner, name, size, content)
+	if err != nil {
+		content.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func (p Policy) inspect(ctx context.Context, scanner Scanner, name string, size int64, content multipart.File) (*File, error) {
+	mtype, err := mimetype.DetectReader(io.LimitReader(content, defaultSniffBytes))
+	if err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_READ_FAILED, File: name, Message: "file cannot be read", Err: err}
+	}
+	if !p.allowed(mtype) {
+		return nil, &Error{Status: http.StatusUnsupportedMediaType, Code: CODE_NOT_ALLOWED, File: name, Message: fmt.Sprintf("content type %s is not allowed", mtype.String())}
+	}
+
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_READ_FAILED, File: name, Message: "file cannot be read", Err: err}
+	}
+	hash := sha256.New()
+	reader := io.TeeReader(content, hash)
+	if scanner == nil {
+		scanner = NopScanner{}
+	}
+	if err = scanner.Scan(ctx, reader); err != nil {
+		if err == ErrInfected {
+			return nil, &Error{Status: http.StatusUnprocessableEntity, Code: CODE_INFECTED, File: name, Message: "file is infected", Err: err}
+		}
+		return nil, &Error{Status: http.StatusServiceUnavailable, Code: CODE_SCAN_FAILED, File: name, Message: "file cannot be scanned", Err: err}
+	}
+	// the scanner may stop early, hash whatever it left behind
+	if _, err = io.Copy(io.Discard, reader); err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_READ_FAILED, File: name, Message: "file cannot be read", Err: err}
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return nil, &Error{Status: http.StatusBadRequest, Code: CODE_READ_FAILED, File: name, Message: "file cannot be read", Err: err}
+	}
+
+	contentType := mtype.String()
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return &File{
+		Name:        name,
+		Size:        size,
+		ContentType: contentType,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Content:     content,
+	}, nil
+}
+
+func (p Policy) allowed(mtype *mimetype.MIME) bool {
+	if len(p.Allowed) == 0 {
+		return true
+	}
+	for _, allowed := range p.Allowed {
+		if mtype.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeFilename drops any directory part, control and reserved characters from name.
+func SanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsControl(r):
+			return -1
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if len(name) > 200 {
+		ext := path.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		name = name[:200-len(ext)] + ext
+	}
+	if name == "" {
+		return "file"
+	}
+	return name
+}
@@ -0,0 +1,192 @@
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// memFile is content held in memory, as a multipart.File.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error {
+	return nil
+}
+
+func zipOf(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range names {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte("content of " + name))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func xlsx(t *testing.T) []byte {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetCellValue("Sheet1", "A1", "email")
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+type infected struct{}
+
+func (infected) Scan(ctx context.Context, content io.Reader) error {
+	return ErrInfected
+}
+
+func TestPolicyOpenFile(t *testing.T) {
+	var (
+		html = []byte("<!DOCTYPE html><html><body><script>alert(1)</script></body></html>")
+		svg  = []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+		php  = []byte("<?php echo shell_exec($_GET['cmd']); ?>")
+		csv  = []byte("name,email\nAhmad,ahmad@example.com\nBudi,budi@example.com\n")
+		list = []byte("email\nahmad@example.com\nbudi@example.com\n")
+		pdf  = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+		png  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x02\x00\x00\x00")
+		jar  = zipOf(t, "META-INF/MANIFEST.MF", "Main.class")
+		arch = zipOf(t, "notes.txt")
+	)
+
+	tests := []struct {
+		name        string
+		policy      Policy
+		content     []byte
+		contentType string
+		code        string
+	}{
+		{name: "pdf document", policy: DokumenPolicy, content: pdf, contentType: "application/pdf"},
+		{name: "csv document", policy: DokumenPolicy, content: csv, contentType: "text/csv"},
+		{name: "zip document", policy: DokumenPolicy, content: arch, contentType: "application/zip"},
+		{name: "xlsx document", policy: DokumenPolicy, content: xlsx(t), contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{name: "html is not plain text", policy: DokumenPolicy, content: html, code: CODE_NOT_ALLOWED},
+		{name: "php is not plain text", policy: DokumenPolicy, content: php, code: CODE_NOT_ALLOWED},
+		{name: "svg is not a document", policy: DokumenPolicy, content: svg, code: CODE_NOT_ALLOWED},
+		{name: "jar is not a zip", policy: DokumenPolicy, content: jar, code: CODE_NOT_ALLOWED},
+		{name: "large policy keeps the document rules", policy: LargePolicy, content: jar, code: CODE_NOT_ALLOWED},
+		{name: "png image", policy: ImagePolicy, content: png, contentType: "image/png"},
+		{name: "svg is not an image", policy: ImagePolicy, content: svg, code: CODE_NOT_ALLOWED},
+		{name: "pdf is not an image", policy: ImagePolicy, content: pdf, code: CODE_NOT_ALLOWED},
+		{name: "csv spreadsheet", policy: SpreadsheetPolicy, content: csv, contentType: "text/csv"},
+		{name: "single column csv spreadsheet", policy: SpreadsheetPolicy, content: list, contentType: "text/plain"},
+		{name: "xlsx spreadsheet", policy: SpreadsheetPolicy, content: xlsx(t), contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{name: "html is not a spreadsheet", policy: SpreadsheetPolicy, content: html, code: CODE_NOT_ALLOWED},
+		{name: "php is not a spreadsheet", policy: SpreadsheetPolicy, content: php, code: CODE_NOT_ALLOWED},
+		{name: "empty", policy: DokumenPolicy, content: []byte{}, code: CODE_EMPTY},
+		{name: "too large", policy: Policy{MaxSize: 10}, content: csv, code: CODE_TOO_LARGE},
+		{name: "no allowed list takes anything", policy: Policy{}, content: html, contentType: "text/html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := memFile{bytes.NewReader(tt.content)}
+			file, err := tt.policy.OpenFile(context.Background(), nil, "../upload.bin", int64(len(tt.content)), content)
+			if tt.code != "" {
+				var uploadErr *Error
+				if !errors.As(err, &uploadErr) || uploadErr.Code != tt.code {
+					t.Fatalf("OpenFile() error = %v, want code %s", err, tt.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenFile() error = %v", err)
+			}
+			if file.ContentType != tt.contentType {
+				t.Errorf("ContentType = %s, want %s", file.ContentType, tt.contentType)
+			}
+			if file.Name != "upload.bin" {
+				t.Errorf("Name = %s, want the directory dropped", file.Name)
+			}
+			if len(file.Checksum) != 64 {
+				t.Errorf("Checksum = %s, want a sha256", file.Checksum)
+			}
+			if offset, _ := content.Seek(0, io.SeekCurrent); offset != 0 {
+				t.Errorf("content is left at %d, want it rewound", offset)
+			}
+		})
+	}
+}
+
+func TestPolicyOpenFileInfected(t *testing.T) {
+	content := []byte("%PDF-1.4\n%%EOF\n")
+	_, err := DokumenPolicy.OpenFile(context.Background(), infected{}, "a.pdf", int64(len(content)), memFile{bytes.NewReader(content)})
+	var uploadErr *Error
+	if !errors.As(err, &uploadErr) || uploadErr.Code != CODE_INFECTED || uploadErr.Status != http.StatusUnprocessableEntity {
+		t.Fatalf("OpenFile() error = %v, want an infected file", err)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	long := strings.Repeat("a", 250)
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "laporan.pdf", want: "laporan.pdf"},
+		{name: "unix path", in: "../../etc/passwd", want: "passwd"},
+		{name: "windows path", in: `C:\Users\a\laporan.pdf`, want: "laporan.pdf"},
+		{name: "reserved characters", in: `a<b>c:"d|e?f*.pdf`, want: "a_b_c__d_e_f_.pdf"},
+		{name: "control characters", in: "a\x00b\nc.pdf", want: "abc.pdf"},
+		{name: "dots and spaces trimmed", in: " ..hidden. ", want: "hidden"},
+		{name: "empty", in: "", want: "file"},
+		{name: "only dots", in: "..", want: "file"},
+		{name: "too long keeps the extension", in: long + ".pdf", want: long[:196] + ".pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFilename(tt.in); got != tt.want {
+				t.Errorf("SanitizeFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		max   int
+		code  string
+	}{
+		{name: "none", count: 0, max: 1, code: CODE_NO_FILE},
+		{name: "within", count: 2, max: 2},
+		{name: "too many", count: 3, max: 2, code: CODE_TOO_MANY},
+		{name: "unlimited", count: 50, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Policy{MaxCount: tt.max}.CheckCount(make([]*multipart.FileHeader, tt.count))
+			var uploadErr *Error
+			if tt.code == "" {
+				if err != nil {
+					t.Errorf("CheckCount() error = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &uploadErr) || uploadErr.Code != tt.code {
+				t.Errorf("CheckCount() error = %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
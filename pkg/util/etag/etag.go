@@ -0,0 +1,74 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HEADER_ETAG          = "ETag"
+	HEADER_IF_MATCH      = "If-Match"
+	HEADER_IF_NONE_MATCH = "If-None-Match"
+)
+
+// Version is the ETag of a record at version, it is what If-Match is compared with.
+func Version(version int) string {
+	return fmt.Sprintf(`"v%d"`, version)
+}
+
+// Of is the ETag of a response body that has no version of its own, such as a list.
+func Of(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Set answers the request with the ETag of a record at version.
+func Set(c echo.Context, version int) {
+	c.Response().Header().Set(HEADER_ETAG, Version(version))
+}
+
+// Match reports whether header, an If-None-Match value, lists tag. "*" matches any tag and the
+// weak prefix is ignored on both sides, it is the weak comparison of RFC 7232.
+func Match(header string, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// StrongMatch reports whether header, an If-Match value, lists tag. "*" matches any tag, a weak
+// tag on either side never matches since If-Match needs the strong comparison of RFC 7232.
+func StrongMatch(header string, tag string) bool {
+	if strings.HasPrefix(tag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// HasIfMatch reports whether the request has an If-Match, its write then has to land on the
+// version that was matched.
+func HasIfMatch(r *http.Request) bool {
+	return r.Header.Get(HEADER_IF_MATCH) != ""
+}
+
+// IfMatch reports whether a write on a record at version may go ahead, which it may when the
+// request has no If-Match.
+func IfMatch(r *http.Request, version int) bool {
+	header := r.Header.Get(HEADER_IF_MATCH)
+	return header == "" || StrongMatch(header, Version(version))
+}
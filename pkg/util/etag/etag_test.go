@@ -0,0 +1,102 @@
+package etag
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		tag    string
+		want   bool
+	}{
+		{name: "same version", header: `"v3"`, tag: Version(3), want: true},
+		{name: "other version", header: `"v2"`, tag: Version(3), want: false},
+		{name: "any", header: "*", tag: Version(3), want: true},
+		{name: "one of a list", header: `"v1", "v3"`, tag: Version(3), want: true},
+		{name: "none of a list", header: `"v1","v2"`, tag: Version(3), want: false},
+		{name: "weak header", header: `W/"v3"`, tag: Version(3), want: true},
+		{name: "weak tag", header: Of([]byte("body"))[2:], tag: Of([]byte("body")), want: true},
+		{name: "unquoted", header: "v3", tag: Version(3), want: false},
+		{name: "prefix of a longer version", header: `"v3"`, tag: Version(33), want: false},
+		{name: "empty", header: "", tag: Version(3), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.header, tt.tag); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.header, tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrongMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		tag    string
+		want   bool
+	}{
+		{name: "same version", header: `"v3"`, tag: Version(3), want: true},
+		{name: "other version", header: `"v2"`, tag: Version(3), want: false},
+		{name: "any", header: "*", tag: Version(3), want: true},
+		{name: "one of a list", header: `"v1", "v3"`, tag: Version(3), want: true},
+		{name: "weak header", header: `W/"v3"`, tag: Version(3), want: false},
+		{name: "weak tag", header: Of([]byte("body"))[2:], tag: Of([]byte("body")), want: false},
+		{name: "weak on both sides", header: Of([]byte("body")), tag: Of([]byte("body")), want: false},
+		{name: "empty", header: "", tag: Version(3), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StrongMatch(tt.header, tt.tag); got != tt.want {
+				t.Errorf("StrongMatch(%q, %q) = %v, want %v", tt.header, tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		version    int
+		want       bool
+		hasIfMatch bool
+	}{
+		{name: "no header", header: "", version: 3, want: true, hasIfMatch: false},
+		{name: "current version", header: `"v3"`, version: 3, want: true, hasIfMatch: true},
+		{name: "stale version", header: `"v2"`, version: 3, want: false, hasIfMatch: true},
+		{name: "any", header: "*", version: 3, want: true, hasIfMatch: true},
+		{name: "weak current version", header: `W/"v3"`, version: 3, want: false, hasIfMatch: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/user/1", nil)
+			if tt.header != "" {
+				r.Header.Set(HEADER_IF_MATCH, tt.header)
+			}
+			if got := IfMatch(r, tt.version); got != tt.want {
+				t.Errorf("IfMatch() = %v, want %v", got, tt.want)
+			}
+			if got := HasIfMatch(r); got != tt.hasIfMatch {
+				t.Errorf("HasIfMatch() = %v, want %v", got, tt.hasIfMatch)
+			}
+		})
+	}
+}
+
+func TestOf(t *testing.T) {
+	a, b := Of([]byte(`{"id":1}`)), Of([]byte(`{"id":2}`))
+	if a == b {
+		t.Errorf("Of() gives %s for different bodies", a)
+	}
+	if a != Of([]byte(`{"id":1}`)) {
+		t.Errorf("Of() is not stable")
+	}
+	if !strings.HasPrefix(a, `W/"`) || !strings.HasSuffix(a, `"`) {
+		t.Errorf("Of() = %s, want a quoted weak tag", a)
+	}
+}
@@ -0,0 +1,229 @@
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+const CONTEXT_KEY = "fields"
+
+// Relation is an expandable field, loaded by preloading an association.
+type Relation struct {
+	Preload string
+	// Columns are the foreign keys the preload needs from the parent row
+	Columns []string
+}
+
+// Spec declares what a resource can be trimmed to with `?fields=id,name` and grown with
+// `?expand=role,divisi`. Without either param the resource is sent whole with every relation.
+type Spec struct {
+	// Fields maps each response field to the columns it is read from
+	Fields map[string][]string
+	// Relations are the response fields only sent when expanded
+	Relations map[string]Relation
+	// Required columns are always selected since the service relies on them, e.g. id and version
+	Required []string
+}
+
+// Error is an invalid fields or expand param, it should be answered with 400.
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid query param %s: %s", e.Param, e.Message)
+}
+
+// Selection is a parsed fields and expand request.
+type Selection struct {
+	spec *Spec
+	// keep is nil when the resource is sent whole
+	keep    map[string]bool
+	columns []string
+	expand  []string
+}
+
+// Parse validates the fields and expand params against spec.
+func Parse(values url.Values, spec *Spec) (*Selection, error) {
+	s := &Selection{spec: spec}
+
+	fieldsParam, hasFields := values["fields"]
+	expandParam, hasExpand := values["expand"]
+	if !hasFields && !hasExpand {
+		for name := range spec.Relations {
+			s.expand = append(s.expand, name)
+		}
+		sort.Strings(s.expand)
+		return s, nil
+	}
+
+	s.keep = make(map[string]bool)
+	columns := make(map[string]bool)
+	for _, column := range spec.Required {
+		columns[column] = true
+	}
+
+	for _, name := range split(expandParam) {
+		relation, ok := spec.Relations[name]
+		if !ok {
+			return nil, &Error{Param: "expand", Message: fmt.Sprintf("%s can not be expanded", name)}
+		}
+		if !s.keep[name] {
+			s.expand = append(s.expand, name)
+		}
+		s.keep[name] = true
+		for _, column := range relation.Columns {
+			columns[column] = true
+		}
+	}
+
+	if hasFields {
+		names := split(fieldsParam)
+		if len(names) == 0 {
+			return nil, &Error{Param: "fields", Message: "must list at least one field"}
+		}
+		for _, name := range names {
+			needs, ok := spec.Fields[name]
+			if !ok {
+				return nil, &Error{Param: "fields", Message: fmt.Sprintf("unknown field %s", name)}
+			}
+			s.keep[name] = true
+			for _, column := range needs {
+				columns[column] = true
+			}
+		}
+		for column := range columns {
+			s.columns = append(s.columns, column)
+		}
+		sort.Strings(s.columns)
+	} else {
+		for name := range spec.Fields {
+			s.keep[name] = true
+		}
+	}
+	return s, nil
+}
+
+// Select selects the columns the requested fields need and preloads the expanded relations.
+func (s *Selection) Select(db *gorm.DB) *gorm.DB {
+	if len(s.columns) > 0 {
+		db = db.Select(s.columns)
+	}
+	for _, name := range s.expand {
+		db = db.Preload(s.spec.Relations[name].Preload)
+	}
+	return db
+}
+
+// Expands reports whether relation name is loaded.
+func (s *Selection) Expands(name string) bool {
+	for _, expand := range s.expand {
+		if expand == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Set makes the response of c trimmed to s when it is sent.
+func Set(c echo.Context, s *Selection) {
+	c.Set(CONTEXT_KEY, s)
+}
+
+// Apply trims data, a record or a list of records, to the selection set on c, as json with the
+// kept fields in their original order. It reports false and returns data as is when there is none.
+func Apply(c echo.Context, data interface{}) (interface{}, bool, error) {
+	s, ok := c.Get(CONTEXT_KEY).(*Selection)
+	if !ok || s == nil || s.keep == nil || data == nil {
+		return data, false, nil
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, false, err
+	}
+	trimmed, err := s.trim(body)
+	if err != nil {
+		return nil, false, err
+	}
+	return trimmed, true, nil
+}
+
+func (s *Selection) trim(body json.RawMessage) (json.RawMessage, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return body, nil
+	}
+	switch body[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			trimmed, err := s.trim(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = trimmed
+		}
+		return json.Marshal(items)
+	case '{':
+		return s.object(body)
+	default:
+		return body, nil
+	}
+}
+
+// object keeps the selected keys of a json object, reading it key by key to keep their order.
+func (s *Selection) object(body json.RawMessage) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		key, _ := token.(string)
+		if !s.keep[key] {
+			continue
+		}
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// split reads comma separated names, also when the param is repeated.
+func split(params []string) []string {
+	var names []string
+	for _, param := range params {
+		for _, name := range strings.Split(param, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
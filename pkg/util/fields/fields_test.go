@@ -0,0 +1,203 @@
+package fields
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+var testSpec = &Spec{
+	Fields: map[string][]string{
+		"id":         {"id"},
+		"name":       {"name"},
+		"avatar_url": {"avatar"},
+		"version":    {"version"},
+		"role_id":    {"role_id"},
+	},
+	Relations: map[string]Relation{
+		"role":   {Preload: "Role", Columns: []string{"role_id"}},
+		"divisi": {Preload: "Divisi", Columns: []string{"divisi_id"}},
+	},
+	Required: []string{"id", "version"},
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		expand  []string
+		// keep is nil when the record is sent whole
+		keep []string
+	}{
+		{
+			name:   "neither param sends everything",
+			query:  "",
+			expand: []string{"divisi", "role"},
+		},
+		{
+			name:    "fields select their columns and the required ones",
+			query:   "fields=name,avatar_url",
+			columns: []string{"avatar", "id", "name", "version"},
+			keep:    []string{"avatar_url", "name"},
+		},
+		{
+			name:    "repeated fields and spaces",
+			query:   "fields=name&fields= id ,",
+			columns: []string{"id", "name", "version"},
+			keep:    []string{"id", "name"},
+		},
+		{
+			name:    "expand selects the foreign key",
+			query:   "fields=name&expand=role",
+			columns: []string{"id", "name", "role_id", "version"},
+			expand:  []string{"role"},
+			keep:    []string{"name", "role"},
+		},
+		{
+			name:   "expand alone keeps every field",
+			query:  "expand=divisi,divisi",
+			expand: []string{"divisi"},
+			keep:   []string{"avatar_url", "divisi", "id", "name", "role_id", "version"},
+		},
+		{
+			name:  "empty expand drops the relations",
+			query: "expand=",
+			keep:  []string{"avatar_url", "id", "name", "role_id", "version"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := Parse(values, testSpec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(s.columns, tt.columns) {
+				t.Errorf("columns = %v, want %v", s.columns, tt.columns)
+			}
+			if !reflect.DeepEqual(s.expand, tt.expand) {
+				t.Errorf("expand = %v, want %v", s.expand, tt.expand)
+			}
+			var keep []string
+			for _, name := range []string{"avatar_url", "divisi", "id", "name", "role", "role_id", "version"} {
+				if s.keep[name] {
+					keep = append(keep, name)
+				}
+			}
+			if !reflect.DeepEqual(keep, tt.keep) || (tt.keep == nil) != (s.keep == nil) {
+				t.Errorf("keep = %v, want %v", keep, tt.keep)
+			}
+			for _, name := range []string{"role", "divisi"} {
+				want := false
+				for _, expand := range tt.expand {
+					want = want || expand == name
+				}
+				if s.Expands(name) != want {
+					t.Errorf("Expands(%s) = %v, want %v", name, s.Expands(name), want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		param string
+	}{
+		{name: "unknown field", query: "fields=password", param: "fields"},
+		{name: "relation as a field", query: "fields=role", param: "fields"},
+		{name: "no field", query: "fields=,", param: "fields"},
+		{name: "unknown relation", query: "expand=password", param: "expand"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Parse(values, testSpec)
+			var fieldsErr *Error
+			if !errors.As(err, &fieldsErr) {
+				t.Fatalf("Parse() error = %v, want a *Error", err)
+			}
+			if fieldsErr.Param != tt.param {
+				t.Errorf("Param = %s, want %s", fieldsErr.Param, tt.param)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	type record struct {
+		ID        int             `json:"id"`
+		Name      string          `json:"name"`
+		AvatarUrl string          `json:"avatar_url"`
+		Version   int             `json:"version"`
+		Role      *map[string]int `json:"role"`
+	}
+	role := map[string]int{"id": 2}
+	one := record{ID: 1, Name: "Ahmad", AvatarUrl: "/a.png", Version: 3, Role: &role}
+
+	tests := []struct {
+		name    string
+		query   string
+		data    interface{}
+		want    string
+		trimmed bool
+	}{
+		{name: "whole", query: "", data: one, trimmed: false},
+		{name: "record keeps the field order", query: "fields=version,name", data: one, want: `{"name":"Ahmad","version":3}`, trimmed: true},
+		{name: "list", query: "fields=id", data: []record{one, {ID: 2}}, want: `[{"id":1},{"id":2}]`, trimmed: true},
+		{name: "expanded relation", query: "fields=id&expand=role", data: one, want: `{"id":1,"role":{"id":2}}`, trimmed: true},
+		{name: "nil data", query: "fields=id", data: nil, trimmed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			s, err := Parse(values, testSpec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			c := echo.New().NewContext(httptest.NewRequest("GET", "/?"+tt.query, nil), httptest.NewRecorder())
+			Set(c, s)
+
+			got, trimmed, err := Apply(c, tt.data)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if trimmed != tt.trimmed {
+				t.Fatalf("Apply() trimmed = %v, want %v", trimmed, tt.trimmed)
+			}
+			if !trimmed {
+				if !reflect.DeepEqual(got, tt.data) {
+					t.Errorf("Apply() = %v, want the data as is", got)
+				}
+				return
+			}
+			body, _ := json.Marshal(got)
+			if string(body) != tt.want {
+				t.Errorf("Apply() = %s, want %s", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyWithoutSelection(t *testing.T) {
+	c := echo.New().NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	data := map[string]int{"id": 1}
+	got, trimmed, err := Apply(c, data)
+	if err != nil || trimmed || !reflect.DeepEqual(got, data) {
+		t.Errorf("Apply() = %v, %v, %v, want the data as is", got, trimmed, err)
+	}
+}
@@ -1,17 +1,10 @@
 package general
 
 import (
-	"bytes"
-	"daarul_mukhtarin/internal/abstraction"
-	"io/ioutil"
 	"math/rand"
 	"regexp"
-	"strconv"
 	"strings"
-	"text/template"
 	"time"
-
-	"github.com/sirupsen/logrus"
 )
 
 func IsValidEmail(email string) bool {
@@ -196,153 +189,3 @@ func SanitizeStringDateBetween(input string) string {
 
 	return sanitized
 }
-
-func ProcessWhereParam(ctx *abstraction.Context, searchType string, whereStr string) (string, map[string]interface{}) {
-	var (
-		where      = "1=@where"
-		whereParam = map[string]interface{}{
-			"where": 1,
-			"false": false,
-			"true":  true,
-		}
-	)
-
-	if whereStr != "" {
-		where += " AND " + whereStr
-	}
-	if ctx.QueryParam("search") != "" {
-		val := "%" + SanitizeString(ctx.QueryParam("search")) + "%"
-		switch searchType {
-		case "user":
-			where += " AND (LOWER(name) LIKE @search_name OR LOWER(email) LIKE @search_email)"
-			whereParam["search_name"] = val
-			whereParam["search_email"] = val
-		case "role":
-			where += " AND (LOWER(name) LIKE @search_name)"
-			whereParam["search_name"] = val
-		case "divisi":
-			where += " AND (LOWER(name) LIKE @search_name)"
-			whereParam["search_name"] = val
-		}
-	}
-	if ctx.QueryParam("id") != "" {
-		val, _ := strconv.Atoi(SanitizeStringOfNumber(ctx.QueryParam("id")))
-		where += " AND id = @id"
-		whereParam["id"] = val
-	}
-	if ctx.QueryParam("name") != "" {
-		val := "%" + SanitizeString(ctx.QueryParam("name")) + "%"
-		where += " AND LOWER(name) LIKE @name"
-		whereParam["name"] = val
-	}
-	if ctx.QueryParam("email") != "" {
-		val := "%" + SanitizeString(ctx.QueryParam("email")) + "%"
-		where += " AND LOWER(email) LIKE @email"
-		whereParam["email"] = val
-	}
-	if ctx.QueryParam("role_id") != "" {
-		val, _ := strconv.Atoi(SanitizeStringOfNumber(ctx.QueryParam("role_id")))
-		where += " AND role_id = @role_id"
-		whereParam["role_id"] = val
-	}
-	if ctx.QueryParam("divisi_id") != "" {
-		val, _ := strconv.Atoi(SanitizeStringOfNumber(ctx.QueryParam("divisi_id")))
-		where += " AND divisi_id = @divisi_id"
-		whereParam["divisi_id"] = val
-	}
-	if ctx.QueryParam("is_locked") != "" {
-		where += " AND is_locked = @" + SanitizeStringOfAlphabet(ctx.QueryParam("is_locked"))
-	}
-	if ctx.QueryParam("is_read") != "" {
-		where += " AND is_read = @" + SanitizeStringOfAlphabet(ctx.QueryParam("is_read"))
-	}
-	if ctx.QueryParam("login_from") != "" {
-		val := "%" + SanitizeString(ctx.QueryParam("login_from")) + "%"
-		where += " AND LOWER(login_from) LIKE @login_from"
-		whereParam["login_from"] = val
-	}
-	if ctx.QueryParam("created_at") != "" {
-		val := SanitizeStringDateBetween(ctx.QueryParam("created_at"))
-		valDate := strings.Split(val, "_")
-		where += " AND created_at BETWEEN @start_created_at AND @end_created_at"
-		whereParam["start_created_at"] = valDate[0]
-		whereParam["end_created_at"] = valDate[1]
-	}
-
-	return where, whereParam
-}
-
-func ProcessLimitOffset(ctx *abstraction.Context) (int, int) {
-	var (
-		limit  = 10
-		offset = 1
-	)
-	if ctx.QueryParam("page_size") != "" {
-		ps, _ := strconv.Atoi(SanitizeStringOfNumber(ctx.QueryParam("page_size")))
-		limit = ps
-	}
-	if ctx.QueryParam("page") != "" {
-		p, _ := strconv.Atoi(SanitizeStringOfNumber(ctx.QueryParam("page")))
-		offset = p
-	}
-	return limit, (offset - 1) * limit
-}
-
-func ProcessOrder(ctx *abstraction.Context) string {
-	var (
-		order string
-		o     = "id"
-		ob    = "ASC"
-	)
-	if ctx.QueryParam("order") != "" {
-		o = ValidationOrder(ctx.QueryParam("order"))
-	}
-	if ctx.QueryParam("order_by") != "" {
-		ob = ValidationOrderBy(ctx.QueryParam("order_by"))
-	}
-	order = o + " " + ob
-	return order
-}
-
-func ValidationOrder(str string) string {
-	str = SanitizeString(str)
-	str = strings.ToLower(str)
-	orderStack := []string{"id", "name", "email"}
-	for _, item := range orderStack {
-		if item == str {
-			return str
-		}
-	}
-	return "id"
-}
-
-func ValidationOrderBy(str string) string {
-	str = SanitizeStringOfAlphabet(str)
-	str = strings.ToUpper(str)
-	orderStack := []string{"ASC", "DESC"}
-	for _, item := range orderStack {
-		if item == str {
-			return str
-		}
-	}
-	return "ASC"
-}
-
-func ParseTemplateEmail(templateFileName string, data interface{}) string {
-	t, err := template.ParseFiles(templateFileName)
-	if err != nil {
-		logrus.Error("Error paring template email: ", err.Error())
-		return ""
-	}
-	buf := new(bytes.Buffer)
-	if err = t.Execute(buf, data); err != nil {
-		logrus.Error("Error paring template email: ", err.Error())
-		return ""
-	}
-	return buf.String()
-}
-
-func ProcessHTMLResponseEmail(filePath, placeholder, value string) string {
-	content, _ := ioutil.ReadFile(filePath)
-	return strings.Replace(string(content), placeholder, value, -1)
-}
@@ -0,0 +1,101 @@
+package query
+
+import (
+	"daarul_mukhtarin/pkg/constant"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// Pagination is returned next to the data of every list endpoint.
+type Pagination struct {
+	Page       int     `json:"page,omitempty"`
+	PageSize   int     `json:"page_size"`
+	Total      *int    `json:"total,omitempty"`
+	TotalPages *int    `json:"total_pages,omitempty"`
+	NextCursor *string `json:"next_cursor,omitempty"`
+	Next       *string `json:"next"`
+	Prev       *string `json:"prev"`
+}
+
+type cursor struct {
+	After int64 `json:"after"`
+}
+
+// IsCursor reports whether the request pages with ?cursor= instead of ?page=.
+func (q *Query) IsCursor() bool {
+	return q.cursor != ""
+}
+
+// Trim returns how many of the n fetched rows belong to the page and whether there is a next
+// page, Paginate fetches one extra row in cursor mode to find out.
+func (q *Query) Trim(n int) (int, bool) {
+	if q.cursor != "" && n > q.PageSize {
+		return q.PageSize, true
+	}
+	return n, false
+}
+
+// Pagination builds the offset pagination of a page out of total rows, links keep the
+// other query params of u.
+func (q *Query) Pagination(u *url.URL, total int) *Pagination {
+	totalPages := (total + q.PageSize - 1) / q.PageSize
+	p := &Pagination{
+		Page:       q.Page,
+		PageSize:   q.PageSize,
+		Total:      &total,
+		TotalPages: &totalPages,
+	}
+	if q.Page < totalPages {
+		p.Next = link(u, "page", strconv.Itoa(q.Page+1))
+	}
+	if q.Page > 1 {
+		prev := q.Page - 1
+		if prev > totalPages {
+			prev = totalPages
+		}
+		if prev > 0 {
+			p.Prev = link(u, "page", strconv.Itoa(prev))
+		}
+	}
+	return p
+}
+
+// CursorPagination builds the keyset pagination of a page, last is the cursor column of its
+// last row. Only a next link is given, a client goes back by keeping the cursors it used.
+func (q *Query) CursorPagination(u *url.URL, more bool, last int64) *Pagination {
+	p := &Pagination{
+		PageSize: q.PageSize,
+	}
+	if more {
+		next := encodeCursor(last)
+		p.NextCursor = &next
+		p.Next = link(u, "cursor", next)
+	}
+	return p
+}
+
+func link(u *url.URL, key string, value string) *string {
+	values := u.Query()
+	values.Set(key, value)
+	res := constant.BASE_URL + u.Path + "?" + values.Encode()
+	return &res
+}
+
+func encodeCursor(after int64) string {
+	b, _ := json.Marshal(cursor{After: after})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(value string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, err
+	}
+	var c cursor
+	if err = json.Unmarshal(b, &c); err != nil {
+		return 0, err
+	}
+	return c.After, nil
+}
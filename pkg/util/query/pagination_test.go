@@ -0,0 +1,150 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseCursorWithoutSpecCursor(t *testing.T) {
+	spec := *testSpec
+	spec.Cursor = ""
+	_, err := Parse(url.Values{"cursor": {"abc"}}, &spec)
+	var queryErr *Error
+	if !errors.As(err, &queryErr) || queryErr.Param != "cursor" {
+		t.Fatalf("Parse() error = %v, want cursor to be an unknown field", err)
+	}
+}
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		isCursor bool
+		after    *int64
+	}{
+		{name: "offset", query: "page=2", isCursor: false},
+		{name: "first page", query: "cursor=", isCursor: true},
+		{name: "next page", query: "cursor=" + encodeCursor(42), isCursor: true, after: ptr(42)},
+		{name: "negative id", query: "cursor=" + encodeCursor(-1), isCursor: true, after: ptr(-1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := Parse(values, testSpec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if q.IsCursor() != tt.isCursor {
+				t.Errorf("IsCursor() = %v, want %v", q.IsCursor(), tt.isCursor)
+			}
+			if !reflect.DeepEqual(q.after, tt.after) {
+				t.Errorf("after = %v, want %v", q.after, tt.after)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, after := range []int64{0, 1, 42, 1 << 40} {
+		got, err := decodeCursor(encodeCursor(after))
+		if err != nil {
+			t.Fatalf("decodeCursor() error = %v", err)
+		}
+		if got != after {
+			t.Errorf("decodeCursor(encodeCursor(%d)) = %d", after, got)
+		}
+	}
+}
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		n      int
+		rows   int
+		isMore bool
+	}{
+		{name: "offset never has more", query: "page_size=2", n: 3, rows: 3, isMore: false},
+		{name: "cursor with an extra row", query: "cursor=&page_size=2", n: 3, rows: 2, isMore: true},
+		{name: "cursor on the last page", query: "cursor=&page_size=2", n: 2, rows: 2, isMore: false},
+		{name: "cursor past the end", query: "cursor=&page_size=2", n: 0, rows: 0, isMore: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := Parse(values, testSpec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			rows, more := q.Trim(tt.n)
+			if rows != tt.rows || more != tt.isMore {
+				t.Errorf("Trim(%d) = %d, %v, want %d, %v", tt.n, rows, more, tt.rows, tt.isMore)
+			}
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	u, _ := url.Parse("/user?page=2&page_size=10&search=a")
+	tests := []struct {
+		name       string
+		page       int
+		total      int
+		totalPages int
+		next       bool
+		prev       bool
+	}{
+		{name: "middle", page: 2, total: 35, totalPages: 4, next: true, prev: true},
+		{name: "first", page: 1, total: 35, totalPages: 4, next: true, prev: false},
+		{name: "last", page: 4, total: 35, totalPages: 4, next: false, prev: true},
+		{name: "empty", page: 1, total: 0, totalPages: 0, next: false, prev: false},
+		{name: "past the end points back to the last page", page: 9, total: 35, totalPages: 4, next: false, prev: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Query{Page: tt.page, PageSize: 10}
+			p := q.Pagination(u, tt.total)
+			if *p.TotalPages != tt.totalPages {
+				t.Errorf("TotalPages = %d, want %d", *p.TotalPages, tt.totalPages)
+			}
+			if (p.Next != nil) != tt.next || (p.Prev != nil) != tt.prev {
+				t.Errorf("Next = %v, Prev = %v, want %v, %v", p.Next, p.Prev, tt.next, tt.prev)
+			}
+		})
+	}
+
+	p := (&Query{Page: 9, PageSize: 10}).Pagination(u, 35)
+	prev, _ := url.Parse(*p.Prev)
+	if got := prev.Query().Get("page"); got != "4" {
+		t.Errorf("Prev page = %s, want 4", got)
+	}
+	if got := prev.Query().Get("search"); got != "a" {
+		t.Errorf("Prev search = %s, want the other params kept", got)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	u, _ := url.Parse("/user?cursor=&page_size=10")
+	q := &Query{cursor: "id", PageSize: 10}
+
+	if p := q.CursorPagination(u, false, 7); p.Next != nil || p.NextCursor != nil {
+		t.Errorf("last page has a next link %v", *p.Next)
+	}
+	p := q.CursorPagination(u, true, 7)
+	if p.NextCursor == nil || p.Next == nil {
+		t.Fatal("next link is missing")
+	}
+	after, err := decodeCursor(*p.NextCursor)
+	if err != nil || after != 7 {
+		t.Errorf("NextCursor = %s decodes to %d, %v, want 7", *p.NextCursor, after, err)
+	}
+}
+
+func ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,359 @@
+package query
+
+import (
+	"daarul_mukhtarin/pkg/util/general"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OP_EQ      = "eq"
+	OP_NE      = "ne"
+	OP_IN      = "in"
+	OP_LIKE    = "like"
+	OP_GTE     = "gte"
+	OP_LTE     = "lte"
+	OP_BETWEEN = "between"
+)
+
+const (
+	TYPE_STRING = iota
+	TYPE_INT
+	TYPE_BOOL
+	TYPE_DATE
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 10
+	MAX_PAGE_SIZE     = 100
+	DATE_LAYOUT       = "2006-01-02"
+)
+
+// reserved are the query params handled by the engine itself rather than as filters.
+var reserved = map[string]bool{
+	"search":    true,
+	"page":      true,
+	"page_size": true,
+	"order":     true,
+	"order_by":  true,
+	"format":    true,
+	"columns":   true,
+	"fields":    true,
+	"expand":    true,
+	"lang":      true,
+	"pretty":    true,
+}
+
+// Field is a filterable column, the first operator is used when the param has none.
+type Field struct {
+	Column    string
+	Type      int
+	Operators []string
+}
+
+// Spec declares how a list endpoint can be filtered and sorted. Filters are written as
+// `name=value` for the default operator or `name[op]=value`, e.g. `role_id[in]=1,2` or
+// `created_at[between]=2024-01-01,2024-01-31`.
+type Spec struct {
+	Filters      map[string]Field
+	Search       []string
+	Sorts        map[string]string
+	DefaultOrder string
+	// Cursor is the unique, sortable column used for keyset pagination with ?cursor=, empty
+	// when the endpoint only pages by offset.
+	Cursor string
+	// Params are other query params the endpoint binds itself, they are accepted but not filtered on.
+	Params []string
+}
+
+// Error is an invalid query param, it should be answered with 400.
+type Error struct {
+	Param   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid query param %s: %s", e.Param, e.Message)
+}
+
+type condition struct {
+	sql  string
+	args []interface{}
+}
+
+// Query is a parsed list request, applied to a statement with its scopes.
+type Query struct {
+	conditions []condition
+	column     string
+	direction  string
+	cursor     string
+	after      *int64
+	Page       int
+	PageSize   int
+}
+
+// Parse validates values against spec, every value ends up as a bind parameter, never in the sql.
+func Parse(values url.Values, spec *Spec) (*Query, error) {
+	q := &Query{
+		column:    "id",
+		direction: "ASC",
+		Page:      1,
+		PageSize:  DEFAULT_PAGE_SIZE,
+	}
+	if order := strings.Fields(spec.DefaultOrder); len(order) > 0 {
+		q.column = order[0]
+		if len(order) > 1 {
+			q.direction = strings.ToUpper(order[1])
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		params := values[key]
+		name, op := key, ""
+		if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+			name, op = key[:i], key[i+1:len(key)-1]
+		}
+		if reserved[key] || contains(spec.Params, key) || (key == "cursor" && spec.Cursor != "") {
+			continue
+		}
+		field, ok := spec.Filters[name]
+		if !ok {
+			return nil, &Error{Param: key, Message: "unknown field"}
+		}
+		if op == "" {
+			op = field.Operators[0]
+		}
+		if !contains(field.Operators, op) {
+			return nil, &Error{Param: key, Message: fmt.Sprintf("operator %s is not supported", op)}
+		}
+		for _, param := range params {
+			cond, err := field.condition(op, param)
+			if err != nil {
+				return nil, &Error{Param: key, Message: err.Error()}
+			}
+			q.conditions = append(q.conditions, cond)
+		}
+	}
+
+	if search := strings.TrimSpace(values.Get("search")); search != "" && len(spec.Search) > 0 {
+		var (
+			likes []string
+			args  []interface{}
+		)
+		for _, column := range spec.Search {
+			likes = append(likes, "LOWER("+column+") LIKE ? ESCAPE '!'")
+			args = append(args, likePattern(search))
+		}
+		q.conditions = append(q.conditions, condition{sql: "(" + strings.Join(likes, " OR ") + ")", args: args})
+	}
+
+	if order := values.Get("order"); order != "" {
+		column, ok := spec.Sorts[strings.ToLower(order)]
+		if !ok {
+			return nil, &Error{Param: "order", Message: "field is not sortable"}
+		}
+		q.column, q.direction = column, "ASC"
+	}
+	if orderBy := values.Get("order_by"); orderBy != "" {
+		q.direction = strings.ToUpper(orderBy)
+		if q.direction != "ASC" && q.direction != "DESC" {
+			return nil, &Error{Param: "order_by", Message: "must be asc or desc"}
+		}
+	}
+
+	var err error
+	if q.Page, err = positive(values, "page", q.Page); err != nil {
+		return nil, err
+	}
+	if q.PageSize, err = positive(values, "page_size", q.PageSize); err != nil {
+		return nil, err
+	}
+	if q.PageSize > MAX_PAGE_SIZE {
+		return nil, &Error{Param: "page_size", Message: fmt.Sprintf("must be at most %d", MAX_PAGE_SIZE)}
+	}
+
+	if cursor, ok := values["cursor"]; ok && spec.Cursor != "" {
+		if q.column != spec.Cursor {
+			return nil, &Error{Param: "cursor", Message: "can only be used when ordering by " + spec.Cursor}
+		}
+		if values.Get("page") != "" {
+			return nil, &Error{Param: "cursor", Message: "can not be used with page"}
+		}
+		q.cursor = spec.Cursor
+		if cursor[0] != "" {
+			after, err := decodeCursor(cursor[0])
+			if err != nil {
+				return nil, &Error{Param: "cursor", Message: "is not valid"}
+			}
+			q.after = &after
+		}
+	}
+	return q, nil
+}
+
+// Where applies the filters and the search.
+func (q *Query) Where(db *gorm.DB) *gorm.DB {
+	for _, cond := range q.conditions {
+		db = db.Where(cond.sql, cond.args...)
+	}
+	return db
+}
+
+// Order applies the requested order, or the default one of the spec.
+func (q *Query) Order(db *gorm.DB) *gorm.DB {
+	return db.Order(q.column + " " + q.direction)
+}
+
+// Paginate applies page and page_size, or with a cursor seeks past the last row of the previous
+// page and fetches one row more than page_size so Trim can tell whether there is a next page.
+func (q *Query) Paginate(db *gorm.DB) *gorm.DB {
+	if q.cursor == "" {
+		return db.Limit(q.PageSize).Offset((q.Page - 1) * q.PageSize)
+	}
+	if q.after != nil {
+		if q.direction == "DESC" {
+			db = db.Where(q.cursor+" < ?", *q.after)
+		} else {
+			db = db.Where(q.cursor+" > ?", *q.after)
+		}
+	}
+	return db.Limit(q.PageSize + 1)
+}
+
+func (f Field) condition(op string, param string) (condition, error) {
+	switch op {
+	case OP_LIKE:
+		return condition{sql: "LOWER(" + f.Column + ") LIKE ? ESCAPE '!'", args: []interface{}{likePattern(param)}}, nil
+	case OP_IN:
+		var values []interface{}
+		for _, v := range strings.Split(param, ",") {
+			value, err := f.value(strings.TrimSpace(v), false)
+			if err != nil {
+				return condition{}, err
+			}
+			values = append(values, value)
+		}
+		return condition{sql: f.Column + " IN ?", args: []interface{}{values}}, nil
+	case OP_BETWEEN:
+		sep := ","
+		if f.Type == TYPE_DATE && !strings.Contains(param, ",") {
+			// the old created_at format, YYYY-MM-DD_YYYY-MM-DD
+			sep = "_"
+		}
+		bounds := strings.Split(param, sep)
+		if len(bounds) != 2 {
+			return condition{}, fmt.Errorf("between needs two values")
+		}
+		start, err := f.value(strings.TrimSpace(bounds[0]), false)
+		if err != nil {
+			return condition{}, err
+		}
+		end, err := f.value(strings.TrimSpace(bounds[1]), true)
+		if err != nil {
+			return condition{}, err
+		}
+		return condition{sql: f.Column + " BETWEEN ? AND ?", args: []interface{}{start, end}}, nil
+	}
+
+	value, err := f.value(param, op == OP_LTE)
+	if err != nil {
+		return condition{}, err
+	}
+	switch op {
+	case OP_NE:
+		return condition{sql: f.Column + " <> ?", args: []interface{}{value}}, nil
+	case OP_GTE:
+		return condition{sql: f.Column + " >= ?", args: []interface{}{value}}, nil
+	case OP_LTE:
+		return condition{sql: f.Column + " <= ?", args: []interface{}{value}}, nil
+	default:
+		return condition{sql: f.Column + " = ?", args: []interface{}{value}}, nil
+	}
+}
+
+// value converts param to the field type, an upper bound date covers the whole day.
+func (f Field) value(param string, upper bool) (interface{}, error) {
+	switch f.Type {
+	case TYPE_INT:
+		value, err := strconv.ParseInt(param, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", param)
+		}
+		return value, nil
+	case TYPE_BOOL:
+		value, err := strconv.ParseBool(param)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", param)
+		}
+		return value, nil
+	case TYPE_DATE:
+		value, err := general.Parse(DATE_LAYOUT, param)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date, use YYYY-MM-DD", param)
+		}
+		if upper {
+			return general.EndOfDay(value), nil
+		}
+		return value, nil
+	default:
+		return param, nil
+	}
+}
+
+// likePattern matches value anywhere, with the wildcards in it escaped by '!'.
+func likePattern(value string) string {
+	value = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(strings.ToLower(value))
+	return "%" + value + "%"
+}
+
+func positive(values url.Values, key string, def int) (int, error) {
+	param := values.Get(key)
+	if param == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(param)
+	if err != nil || value < 1 {
+		return 0, &Error{Param: key, Message: "must be a positive number"}
+	}
+	return value, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Ints is a shorthand for an integer id column.
+func Ints(column string) Field {
+	return Field{Column: column, Type: TYPE_INT, Operators: []string{OP_EQ, OP_NE, OP_IN}}
+}
+
+// Strings is a shorthand for a text column matched partially by default.
+func Strings(column string) Field {
+	return Field{Column: column, Type: TYPE_STRING, Operators: []string{OP_LIKE, OP_EQ, OP_NE, OP_IN}}
+}
+
+// Bools is a shorthand for a flag column.
+func Bools(column string) Field {
+	return Field{Column: column, Type: TYPE_BOOL, Operators: []string{OP_EQ}}
+}
+
+// Dates is a shorthand for a timestamp column filtered by day.
+func Dates(column string) Field {
+	return Field{Column: column, Type: TYPE_DATE, Operators: []string{OP_BETWEEN, OP_GTE, OP_LTE}}
+}
@@ -0,0 +1,206 @@
+package query
+
+import (
+	"daarul_mukhtarin/pkg/util/general"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testSpec = &Spec{
+	Filters: map[string]Field{
+		"name":       Strings("name"),
+		"role_id":    Ints("role_id"),
+		"is_locked":  Bools("is_locked"),
+		"created_at": Dates("created_at"),
+	},
+	Search:       []string{"name", "email"},
+	Sorts:        map[string]string{"name": "name", "id": "id"},
+	DefaultOrder: "id desc",
+	Cursor:       "id",
+	Params:       []string{"divisi"},
+}
+
+func TestParse(t *testing.T) {
+	day := func(s string) time.Time {
+		value, err := general.Parse(DATE_LAYOUT, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	endOfDay := func(s string) time.Time {
+		return general.EndOfDay(day(s))
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		conditions []condition
+		column     string
+		direction  string
+		page       int
+		pageSize   int
+	}{
+		{
+			name:      "defaults",
+			query:     "",
+			column:    "id",
+			direction: "DESC",
+			page:      1,
+			pageSize:  DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "default operator",
+			query: "role_id=2",
+			conditions: []condition{
+				{sql: "role_id = ?", args: []interface{}{int64(2)}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "in",
+			query: "role_id[in]=1, 3",
+			conditions: []condition{
+				{sql: "role_id IN ?", args: []interface{}{[]interface{}{int64(1), int64(3)}}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "like escapes wildcards",
+			query: "name=50%25_Off!",
+			conditions: []condition{
+				{sql: "LOWER(name) LIKE ? ESCAPE '!'", args: []interface{}{"%50!%!_off!!%"}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "bool",
+			query: "is_locked=true",
+			conditions: []condition{
+				{sql: "is_locked = ?", args: []interface{}{true}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "between covers the last day",
+			query: "created_at=2024-01-01,2024-01-31",
+			conditions: []condition{
+				{sql: "created_at BETWEEN ? AND ?", args: []interface{}{day("2024-01-01"), endOfDay("2024-01-31")}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "between in the old format",
+			query: "created_at=2024-01-01_2024-01-31",
+			conditions: []condition{
+				{sql: "created_at BETWEEN ? AND ?", args: []interface{}{day("2024-01-01"), endOfDay("2024-01-31")}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "lte covers the whole day",
+			query: "created_at[lte]=2024-01-31",
+			conditions: []condition{
+				{sql: "created_at <= ?", args: []interface{}{endOfDay("2024-01-31")}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:  "search",
+			query: "search=Ahmad",
+			conditions: []condition{
+				{sql: "(LOWER(name) LIKE ? ESCAPE '!' OR LOWER(email) LIKE ? ESCAPE '!')", args: []interface{}{"%ahmad%", "%ahmad%"}},
+			},
+			column: "id", direction: "DESC", page: 1, pageSize: DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:      "order and page",
+			query:     "order=NAME&order_by=desc&page=3&page_size=25",
+			column:    "name",
+			direction: "DESC",
+			page:      3,
+			pageSize:  25,
+		},
+		{
+			name:      "order defaults to ascending",
+			query:     "order=name",
+			column:    "name",
+			direction: "ASC",
+			page:      1,
+			pageSize:  DEFAULT_PAGE_SIZE,
+		},
+		{
+			name:      "reserved and endpoint params are not filters",
+			query:     "lang=en&format=csv&pretty&divisi=1",
+			column:    "id",
+			direction: "DESC",
+			page:      1,
+			pageSize:  DEFAULT_PAGE_SIZE,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := Parse(values, testSpec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(q.conditions, tt.conditions) {
+				t.Errorf("conditions = %#v, want %#v", q.conditions, tt.conditions)
+			}
+			if q.column != tt.column || q.direction != tt.direction {
+				t.Errorf("order = %s %s, want %s %s", q.column, q.direction, tt.column, tt.direction)
+			}
+			if q.Page != tt.page || q.PageSize != tt.pageSize {
+				t.Errorf("page = %d/%d, want %d/%d", q.Page, q.PageSize, tt.page, tt.pageSize)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		param string
+	}{
+		{name: "unknown field", query: "password=x", param: "password"},
+		{name: "unsupported operator", query: "is_locked[ne]=true", param: "is_locked[ne]"},
+		{name: "not a number", query: "role_id=abc", param: "role_id"},
+		{name: "one bad value of in", query: "role_id[in]=1,x", param: "role_id[in]"},
+		{name: "not a boolean", query: "is_locked=maybe", param: "is_locked"},
+		{name: "not a date", query: "created_at[gte]=31-01-2024", param: "created_at[gte]"},
+		{name: "between with one value", query: "created_at=2024-01-01", param: "created_at"},
+		{name: "not sortable", query: "order=password", param: "order"},
+		{name: "bad direction", query: "order_by=up", param: "order_by"},
+		{name: "page zero", query: "page=0", param: "page"},
+		{name: "page not a number", query: "page=one", param: "page"},
+		{name: "page size too large", query: "page_size=101", param: "page_size"},
+		{name: "cursor with another order", query: "order=name&cursor=", param: "cursor"},
+		{name: "cursor with page", query: "cursor=&page=2", param: "cursor"},
+		{name: "cursor not base64", query: "cursor=***", param: "cursor"},
+		{name: "cursor not json", query: "cursor=" + "bm90IGpzb24", param: "cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Parse(values, testSpec)
+			var queryErr *Error
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Parse() error = %v, want a *Error", err)
+			}
+			if queryErr.Param != tt.param {
+				t.Errorf("Param = %s, want %s", queryErr.Param, tt.param)
+			}
+		})
+	}
+}
@@ -1,9 +1,19 @@
 package response
 
+import "daarul_mukhtarin/pkg/util/query"
+
 type MetaSuccess struct {
 	Success bool        `json:"success"`
 	Code    int         `json:"code"`
 	Data    interface{} `json:"data"`
+	Meta    *Meta       `json:"meta,omitempty"`
+}
+
+// Meta is sent next to the data of a list, the data itself is always an array.
+type Meta struct {
+	Pagination *query.Pagination `json:"pagination,omitempty"`
+	// Summary holds the totals a list reports besides its pagination
+	Summary interface{} `json:"summary,omitempty"`
 }
 
 type MetaError struct {
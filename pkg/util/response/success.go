@@ -1,6 +1,9 @@
 package response
 
 import (
+	"daarul_mukhtarin/pkg/util/etag"
+	"daarul_mukhtarin/pkg/util/fields"
+	"encoding/json"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -18,6 +21,48 @@ func SuccessResponse(data interface{}) *MetaSuccess {
 	return SuccessBuilder(http.StatusOK, data)
 }
 
+// ListResponse answers a list endpoint, data should be a slice that is empty rather than nil
+// so it is sent as [] when nothing matches.
+func ListResponse(data interface{}, meta *Meta) *MetaSuccess {
+	res := SuccessBuilder(http.StatusOK, data)
+	res.Meta = meta
+	return res
+}
+
+// SendSuccess answers with m as json, trimmed to the fields the service selected. A GET carries an
+// ETag, the one set by the service for a versioned record or else a hash of the body, and is
+// answered 304 when If-None-Match lists it. A trimmed record is always tagged by its body since
+// its version only stands for the whole record.
 func (m *MetaSuccess) SendSuccess(c echo.Context) error {
-	return c.JSON(m.Code, m)
+	data, trimmed, err := fields.Apply(c, m.Data)
+	if err != nil {
+		return err
+	}
+	if trimmed {
+		m.Data = data
+		c.Response().Header().Del(etag.HEADER_ETAG)
+	}
+
+	if c.Request().Method != http.MethodGet || m.Code != http.StatusOK {
+		return c.JSON(m.Code, m)
+	}
+
+	var body []byte
+	if _, pretty := c.QueryParams()["pretty"]; pretty {
+		body, err = json.MarshalIndent(m, "", "  ")
+	} else {
+		body, err = json.Marshal(m)
+	}
+	if err != nil {
+		return err
+	}
+	tag := c.Response().Header().Get(etag.HEADER_ETAG)
+	if tag == "" {
+		tag = etag.Of(body)
+		c.Response().Header().Set(etag.HEADER_ETAG, tag)
+	}
+	if header := c.Request().Header.Get(etag.HEADER_IF_NONE_MATCH); header != "" && etag.Match(header, tag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSONBlob(m.Code, body)
 }